@@ -0,0 +1,211 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of ageing alerts
+const (
+	alertBufferAged        = "BUFFER_AGED"
+	alertPastDue           = "PAST_DUE"
+	alertTestReportExpired = "TEST_REPORT_EXPIRED"
+)
+
+// ageingOptions are the date the ageing report is made as of and its thresholds in days.
+type ageingOptions struct {
+	AsOf               Date
+	BufferMaxAge       int
+	TestReportValidity int
+}
+
+// ageingAlert is stock, or a batch, that has aged past a threshold. Since is the date the age is counted from:
+// the due date of a batch past due, the approval date of aged buffer drums and of drums whose test report expired.
+type ageingAlert struct {
+	Kind        string
+	ContractNo  string
+	LI          string
+	BatchNo     string
+	DrumSize    int
+	Since       Date
+	Days        int
+	Quantity    int
+	DrumNumbers []int
+}
+
+// daysBetween is the number of days from one date to a later one.
+func daysBetween(from, to Date) int {
+	return int(to.Sub(from.Time).Hours() / 24)
+}
+
+// ageInventory lists the ageing alerts of an inventory as of a date: batches past their due date whose documents
+// are still pending, buffer drums held longer than the buffer age since their first approval and available or
+// buffer drums whose latest test report is older than its validity. A threshold of zero days turns its alert off.
+func ageInventory(records UploadInventoryInput, options ageingOptions) []ageingAlert {
+	alerts := make([]ageingAlert, 0)
+	for _, contract := range records.Contracts {
+		for _, li := range contract.LIs {
+			liName := li.LiCode + "-" + li.LiNumber
+			for _, batch := range li.Batches {
+				for _, alert := range ageBatch(batch, options) {
+					alert.ContractNo = contract.ContractNo
+					alert.LI = liName
+					alert.BatchNo = batch.BatchNo
+					alerts = append(alerts, alert)
+				}
+			}
+		}
+	}
+	return alerts
+}
+
+func ageBatch(batch Batch, options ageingOptions) []ageingAlert {
+	var alerts []ageingAlert
+
+	if batch.Status == "DOCS_PENDING_UPLOAD" && !batch.SubmissionDate.IsZero() && batch.SubmissionDate.Before(options.AsOf) {
+		alerts = append(alerts, ageingAlert{
+			Kind:     alertPastDue,
+			Since:    batch.SubmissionDate,
+			Days:     daysBetween(batch.SubmissionDate, options.AsOf),
+			Quantity: batch.TotalQuantity,
+		})
+	}
+
+	for _, dp := range batch.DrumPartitions {
+		first, latest := approvalDatesOf(batch, dp.DrumSize)
+
+		if options.BufferMaxAge > 0 {
+			alerts = append(alerts, agedDrums(alertBufferAged, dp, dp.BufferDrumNumbers, first, options.AsOf, options.BufferMaxAge)...)
+		}
+		if options.TestReportValidity > 0 {
+			held, _ := combineSortAndCheckDuplicates(dp.AvailableDrumNumbers, dp.BufferDrumNumbers)
+			alerts = append(alerts, agedDrums(alertTestReportExpired, dp, held, latest, options.AsOf, options.TestReportValidity)...)
+		}
+	}
+	return alerts
+}
+
+// approvalDatesOf returns the dates each drum of a drum size was first and last approved on. Superseded approvals
+// do not count.
+func approvalDatesOf(batch Batch, drumSize int) (map[int]Date, map[int]Date) {
+	first := make(map[int]Date)
+	latest := make(map[int]Date)
+	for _, bta := range batch.BatchTestApprovals {
+		if bta.Status != "APPROVED" || bta.Superseded {
+			continue
+		}
+		for _, approvalDrumNumber := range bta.ApprovalDrumNumbers {
+			if approvalDrumNumber.DrumSize != drumSize {
+				continue
+			}
+			for _, drumNo := range approvalDrumNumber.DrumNumbers {
+				if date, ok := first[drumNo]; !ok || bta.ApprovalDate.Before(date) {
+					first[drumNo] = bta.ApprovalDate
+				}
+				if date, ok := latest[drumNo]; !ok || bta.ApprovalDate.After(date) {
+					latest[drumNo] = bta.ApprovalDate
+				}
+			}
+		}
+	}
+	return first, latest
+}
+
+// agedDrums groups the drums older than maxAge days by the date their age is counted from, oldest first. Drums
+// without a date are not aged.
+func agedDrums(kind string, dp DrumPartition, drumNumbers []int, since map[int]Date, asOf Date, maxAge int) []ageingAlert {
+	// by ISO date, which sorts oldest first
+	byDate := make(map[string][]int)
+	dates := make(map[string]Date)
+	for _, drumNo := range drumNumbers {
+		date, ok := since[drumNo]
+		if !ok || date.IsZero() || daysBetween(date, asOf) <= maxAge {
+			continue
+		}
+		key := date.Format(isoDateLayout)
+		byDate[key] = append(byDate[key], drumNo)
+		dates[key] = date
+	}
+
+	keys := make([]string, 0, len(byDate))
+	for key := range byDate {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	alerts := make([]ageingAlert, 0, len(keys))
+	for _, key := range keys {
+		date, drums := dates[key], byDate[key]
+		sort.Ints(drums)
+		alerts = append(alerts, ageingAlert{
+			Kind:        kind,
+			DrumSize:    dp.DrumSize,
+			Since:       date,
+			Days:        daysBetween(date, asOf),
+			Quantity:    dp.DrumSize * len(drums),
+			DrumNumbers: drums,
+		})
+	}
+	return alerts
+}
+
+var ageingHeader = []string{"Alert", "Contract", "LI", "Batch", "Drum size", "Since", "Days", "Quantity", "Drum nos."}
+
+// record is the alert as the cells of ageingHeader.
+func (a ageingAlert) record() []string {
+	drumSize := ""
+	if a.DrumSize > 0 {
+		drumSize = strconv.Itoa(a.DrumSize)
+	}
+	drumNumbers := make([]string, 0, len(a.DrumNumbers))
+	for _, drumNo := range a.DrumNumbers {
+		drumNumbers = append(drumNumbers, strconv.Itoa(drumNo))
+	}
+	return []string{
+		a.Kind, a.ContractNo, a.LI, a.BatchNo, drumSize, a.Since.String(),
+		strconv.Itoa(a.Days), strconv.Itoa(a.Quantity), strings.Join(drumNumbers, " "),
+	}
+}
+
+// writeAgeing writes the ageing report in one of the formats of the summary report.
+func writeAgeing(w io.Writer, alerts []ageingAlert, format string) error {
+	records := make([][]string, 0, len(alerts))
+	for _, alert := range alerts {
+		records = append(records, alert.record())
+	}
+	return writeTable(w, ageingHeader, records, format, 6, nil)
+}
+
+// runAgeing is the ageing command: it writes the ageing alerts of a converted inventory or of a vendor file.
+func runAgeing(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("ageing", flag.ContinueOnError)
+	in := flags.String("in", "output.json", "JSON inventory, or vendor CSV file to convert, to age")
+	out := flags.String("out", "", "file to write the report to, standard output by default")
+	format := flags.String("format", summaryFormatText, "format of the report: text, csv or markdown")
+	asOf := flags.String("as-of", "", "date the report is made as of, dd-mm-yyyy or yyyy-mm-dd, today by default")
+	bufferDays := flags.Int("buffer-days", 90, "days buffer drums may be held after their approval, 0 for no alert")
+	validityDays := flags.Int("test-validity-days", 365, "days a batch test report is valid, 0 for no alert")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	options := ageingOptions{AsOf: dateOf(time.Now()), BufferMaxAge: *bufferDays, TestReportValidity: *validityDays}
+	if *asOf != "" {
+		date, err := parseDate(*asOf)
+		if err != nil {
+			return fmt.Errorf("invalid -as-of: %w", err)
+		}
+		options.AsOf = date
+	}
+	if *bufferDays < 0 || *validityDays < 0 {
+		return fmt.Errorf("-buffer-days and -test-validity-days may not be negative")
+	}
+	return writeReport(*in, *out, *format, stdout, func(w io.Writer, records UploadInventoryInput) error {
+		return writeAgeing(w, ageInventory(records, options), *format)
+	})
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ageBatch(t *testing.T) {
+	batch := testBatch("1/50",
+		[]BatchTestApproval{
+			testApproval("01-02-2024", "APPROVED", 250, 1, 2),
+			testApproval("01-06-2024", "APPROVED", 250, 4, 5),
+			// retested, drum 2 stays buffer since its first approval but its test report is renewed
+			testApproval("01-09-2024", "APPROVED", 250, 2),
+			{ApprovalDate: testDate("01-01-2024"), Status: "APPROVED", Superseded: true, ApprovalDrumNumbers: []ApprovalDrumNumber{{DrumSize: 250, DrumNumbers: []int{6}}}},
+			testApproval("01-10-2024", "REJECTED", 250, 6),
+		},
+		DrumPartition{
+			DrumSize: 250, Quantity: 1500,
+			AvailableQuantity: 500, AvailableDrumNumbers: []int{1, 4},
+			BufferQuantity: 750, BufferDrumNumbers: []int{2, 5, 6},
+			UnapprovedQuantity: 250,
+		},
+	)
+	batch.SubmissionDate = testDate("01-01-2024")
+	batch.Status = "PARTIAL_BUFFER"
+	pending := batch
+	pending.Status = "DOCS_PENDING_UPLOAD"
+
+	tests := []struct {
+		name    string
+		batch   Batch
+		options ageingOptions
+		want    []ageingAlert
+	}{
+		{
+			name:    "nothing aged",
+			batch:   batch,
+			options: ageingOptions{AsOf: testDate("01-03-2024"), BufferMaxAge: 90, TestReportValidity: 365},
+		},
+		{
+			name:    "buffer aged from its first approval",
+			batch:   batch,
+			options: ageingOptions{AsOf: testDate("01-07-2024"), BufferMaxAge: 90, TestReportValidity: 365},
+			want: []ageingAlert{
+				{Kind: alertBufferAged, DrumSize: 250, Since: testDate("01-02-2024"), Days: 151, Quantity: 250, DrumNumbers: []int{2}},
+			},
+		},
+		{
+			name:    "on the day the threshold is reached",
+			batch:   batch,
+			options: ageingOptions{AsOf: testDate("01-05-2024"), BufferMaxAge: 90},
+		},
+		{
+			name:    "test reports expired on their latest approval",
+			batch:   batch,
+			options: ageingOptions{AsOf: testDate("01-07-2025"), TestReportValidity: 365},
+			want: []ageingAlert{
+				{Kind: alertTestReportExpired, DrumSize: 250, Since: testDate("01-02-2024"), Days: 516, Quantity: 250, DrumNumbers: []int{1}},
+				{Kind: alertTestReportExpired, DrumSize: 250, Since: testDate("01-06-2024"), Days: 395, Quantity: 500, DrumNumbers: []int{4, 5}},
+			},
+		},
+		{
+			name:    "past due with documents pending",
+			batch:   pending,
+			options: ageingOptions{AsOf: testDate("01-03-2024")},
+			want: []ageingAlert{
+				{Kind: alertPastDue, Since: testDate("01-01-2024"), Days: 60, Quantity: 1500},
+			},
+		},
+		{
+			name:    "due today",
+			batch:   pending,
+			options: ageingOptions{AsOf: testDate("01-01-2024")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ageBatch(tt.batch, tt.options))
+		})
+	}
+}
+
+func Test_ageInventory(t *testing.T) {
+	batch := testBatch("1/50",
+		[]BatchTestApproval{testApproval("01-02-2024", "APPROVED", 250, 1, 2), testApproval("01-06-2024", "APPROVED", 250, 3)},
+		DrumPartition{DrumSize: 250, Quantity: 750, AvailableQuantity: 250, AvailableDrumNumbers: []int{1}, BufferQuantity: 500, BufferDrumNumbers: []int{2, 3}},
+	)
+	records := testInventory(testContract("9190369", "", testLI("1", "", "", batch)))
+
+	alerts := ageInventory(records, ageingOptions{AsOf: testDate("01-07-2025"), BufferMaxAge: 90, TestReportValidity: 365})
+	assert.Len(t, alerts, 4)
+	for _, alert := range alerts {
+		assert.Equal(t, "9190369", alert.ContractNo)
+		assert.Equal(t, "Li-1", alert.LI)
+		assert.Equal(t, "1/50", alert.BatchNo)
+	}
+	assert.Equal(t, []string{alertBufferAged, "9190369", "Li-1", "1/50", "250", "2024-02-01", "516", "250", "2"}, alerts[0].record())
+	assert.Equal(t, []string{alertBufferAged, "9190369", "Li-1", "1/50", "250", "2024-06-01", "395", "250", "3"}, alerts[1].record())
+	assert.Equal(t, []string{alertTestReportExpired, "9190369", "Li-1", "1/50", "250", "2024-02-01", "516", "500", "1 2"}, alerts[2].record())
+
+	assert.Equal(t, []ageingAlert{}, ageInventory(records, ageingOptions{AsOf: testDate("01-07-2025")}))
+}
+
+func Test_runAgeing(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "inventory.json")
+	records := testInventory(testContract("9190369", "", testLI("1", "", "", testBatch("1/50",
+		[]BatchTestApproval{testApproval("01-02-2024", "APPROVED", 250, 2)},
+		DrumPartition{DrumSize: 250, Quantity: 250, BufferQuantity: 250, BufferDrumNumbers: []int{2}},
+	))))
+	jsonData, err := marshalRecords(records, outputOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(jsonPath, jsonData, 0644))
+
+	var stdout bytes.Buffer
+	assert.NoError(t, runAgeing([]string{"-in", jsonPath, "-as-of", "01-07-2024", "-format", summaryFormatCSV}, &stdout))
+	assert.Equal(t, "Alert,Contract,LI,Batch,Drum size,Since,Days,Quantity,Drum nos.\n"+
+		"BUFFER_AGED,9190369,Li-1,1/50,250,2024-02-01,151,250,2\n", stdout.String())
+
+	// the same as of date gives the same report
+	stdout.Reset()
+	assert.NoError(t, runAgeing([]string{"-in", jsonPath, "-as-of", "2024-07-01", "-format", summaryFormatCSV}, &stdout))
+	assert.Contains(t, stdout.String(), "BUFFER_AGED,9190369,Li-1,1/50,250,2024-02-01,151,250,2\n")
+
+	assert.Error(t, runAgeing([]string{"-in", jsonPath, "-as-of", "July"}, &stdout))
+	assert.Error(t, runAgeing([]string{"-in", jsonPath, "-buffer-days", "-1"}, &stdout))
+	assert.Error(t, runAgeing([]string{"-in", jsonPath, "-format", "xlsx"}, &stdout))
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"VMIStockUpload/allocation"
+)
+
+// availableStockDrums lists the available drums of the LIs measured in length, oldest approval first.
+func availableStockDrums(records UploadInventoryInput) []allocation.Drum {
+	drums := make([]allocation.Drum, 0)
+	for _, contract := range records.Contracts {
+		for _, li := range contract.LIs {
+			for _, batch := range li.Batches {
+				for _, dp := range batch.DrumPartitions {
+					unit := dp.Unit
+					if unit == "" {
+						unit = li.Unit
+					}
+					metres, err := convertQuantity(float64(dp.DrumSize), unit, UnitMetre)
+					if err != nil {
+						// not sold by length
+						continue
+					}
+					first, _ := approvalDatesOf(batch, dp.DrumSize)
+					for _, drumNo := range dp.AvailableDrumNumbers {
+						drums = append(drums, allocation.Drum{
+							ContractNo:   contract.ContractNo,
+							MaterialCode: li.MaterialCode,
+							LiCode:       li.LiCode,
+							LiNumber:     li.LiNumber,
+							BatchNo:      batch.BatchNo,
+							DrumSize:     dp.DrumSize,
+							Unit:         unit,
+							DrumNumber:   drumNo,
+							Metres:       int(math.Round(metres)),
+							ApprovalDate: first[drumNo].String(),
+						})
+					}
+				}
+			}
+		}
+	}
+	sort.SliceStable(drums, func(i, j int) bool { return compareStockDrums(drums[i], drums[j]) < 0 })
+	return drums
+}
+
+// compareStockDrums orders drums first in, first out: by approval date, drums without one last, then by their place
+// in the inventory.
+func compareStockDrums(a, b allocation.Drum) int {
+	// ISO-8601 dates sort in date order
+	if (a.ApprovalDate == "") != (b.ApprovalDate == "") {
+		if a.ApprovalDate == "" {
+			return 1
+		}
+		return -1
+	}
+	if c := strings.Compare(a.ApprovalDate, b.ApprovalDate); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.ContractNo, b.ContractNo); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.LiCode, b.LiCode); c != 0 {
+		return c
+	}
+	if c := compareNumericStrings(a.LiNumber, b.LiNumber); c != 0 {
+		return c
+	}
+	if c := compareBatchNo(a.BatchNo, b.BatchNo); c != 0 {
+		return c
+	}
+	if a.DrumSize != b.DrumSize {
+		return a.DrumSize - b.DrumSize
+	}
+	return a.DrumNumber - b.DrumNumber
+}
+
+// runReserve is the reserve command: it reserves available drums of a converted inventory, or of a vendor file,
+// and records the reservation with the drums it locks.
+func runReserve(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("reserve", flag.ContinueOnError)
+	in := flags.String("in", "output.json", "JSON inventory, or vendor CSV file to convert, to reserve drums from")
+	reservationsPath := flags.String("reservations", "reservations.json", "JSON file of the reservations holding drums")
+	var request allocation.Request
+	flags.StringVar(&request.MaterialCode, "material", "", "material code of the drums to reserve")
+	flags.IntVar(&request.Metres, "metres", 0, "length to reserve in metres")
+	flags.IntVar(&request.PreferredDrumSize, "drum-size", 0, "preferred drum size in metres")
+	flags.StringVar(&request.Strategy, "strategy", allocation.StrategyFIFO, "how drums are picked: fifo, fewest-drums or least-waste")
+	flags.StringVar(&request.ReservedFor, "for", "", "project or team the drums are reserved for")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	records, errs, err := readInventory(*in)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s has %d errors, convert it without errors before reserving drums", *in, len(errs))
+	}
+
+	return allocation.WithLock(*reservationsPath, func() error {
+		reservations, err := allocation.ReadReservations(*reservationsPath)
+		if err != nil {
+			return err
+		}
+		a, err := allocation.New(availableStockDrums(records), reservations)
+		if err != nil {
+			return err
+		}
+		r, err := a.Reserve(request)
+		if err != nil {
+			return err
+		}
+		if err := allocation.WriteReservations(*reservationsPath, a.Current()); err != nil {
+			return err
+		}
+		fmt.Fprint(stdout, r)
+		return nil
+	})
+}
+
+// runRelease is the release command: it unlocks the drums of a reservation.
+func runRelease(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("release", flag.ContinueOnError)
+	reservationsPath := flags.String("reservations", "reservations.json", "JSON file of the reservations holding drums")
+	id := flags.String("id", "", "reservation to release")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	return allocation.WithLock(*reservationsPath, func() error {
+		reservations, err := allocation.ReadReservations(*reservationsPath)
+		if err != nil {
+			return err
+		}
+		a, err := allocation.New(nil, reservations)
+		if err != nil {
+			return err
+		}
+		if err := a.Release(*id); err != nil {
+			return err
+		}
+		if err := allocation.WriteReservations(*reservationsPath, a.Current()); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "reservation %s released\n", *id)
+		return nil
+	})
+}
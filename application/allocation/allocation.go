@@ -0,0 +1,366 @@
+// Package allocation reserves whole drums of available stock for requests of a length of a material, and keeps the
+// reserved drums locked until their reservation is released.
+package allocation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Strategies to pick the drums of a reservation
+const (
+	StrategyFIFO        = "fifo"
+	StrategyFewestDrums = "fewest-drums"
+	StrategyLeastWaste  = "least-waste"
+)
+
+// dateLayout is the ISO-8601 layout of the dates of drums and reservations.
+const dateLayout = "2006-01-02"
+
+// Request asks for whole drums of a material adding up to at least a length in metres. Drums of the preferred size,
+// in metres, are used when they can cover the request alone.
+type Request struct {
+	MaterialCode      string `json:"material_code"`
+	Metres            int    `json:"metres"`
+	PreferredDrumSize int    `json:"preferred_drum_size,omitempty"`
+	Strategy          string `json:"strategy"`
+	ReservedFor       string `json:"reserved_for,omitempty"`
+}
+
+// Drum is an available drum that can be reserved. Its age is counted from its first approval, an ISO-8601 date.
+type Drum struct {
+	ContractNo   string `json:"contract_no"`
+	MaterialCode string `json:"material_code"`
+	LiCode       string `json:"li_code"`
+	LiNumber     string `json:"li_number"`
+	BatchNo      string `json:"batch_no"`
+	DrumSize     int    `json:"drum_size"`
+	Unit         string `json:"unit"`
+	DrumNumber   int    `json:"drum_number"`
+	Metres       int    `json:"metres"`
+	ApprovalDate string `json:"approval_date"`
+}
+
+// Key identifies the drum in the inventory.
+func (d Drum) Key() string {
+	return strings.Join([]string{d.ContractNo, d.LiCode, d.LiNumber, d.BatchNo, strconv.Itoa(d.DrumSize), strconv.Itoa(d.DrumNumber)}, "|")
+}
+
+// Reservation locks the drums picked for a request until it is released. It is dated by an ISO-8601 date.
+type Reservation struct {
+	ID         string  `json:"id"`
+	Request    Request `json:"request"`
+	Drums      []Drum  `json:"drums"`
+	Metres     int     `json:"metres"`
+	ReservedOn string  `json:"reserved_on"`
+}
+
+// Waste is the length reserved beyond the length requested.
+func (r Reservation) Waste() int {
+	return r.Metres - r.Request.Metres
+}
+
+// String describes the reservation and its drums.
+func (r Reservation) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "reservation %s", r.ID)
+	if r.Request.ReservedFor != "" {
+		fmt.Fprintf(&sb, " for %s", r.Request.ReservedFor)
+	}
+	fmt.Fprintf(&sb, ": %d drums of material code %s, %d m for %d m requested (%d m waste, %s)\n",
+		len(r.Drums), r.Request.MaterialCode, r.Metres, r.Request.Metres, r.Waste(), r.Request.Strategy)
+	for _, drum := range r.Drums {
+		fmt.Fprintf(&sb, "  contract %s LI %s-%s batch %s drum size %d %s drum no. %d, approved %s\n",
+			drum.ContractNo, drum.LiCode, drum.LiNumber, drum.BatchNo, drum.DrumSize, drum.Unit, drum.DrumNumber, drum.ApprovalDate)
+	}
+	return sb.String()
+}
+
+// Allocator reserves drums of available stock. It is safe for concurrent use, a drum is locked by at most one
+// reservation.
+type Allocator struct {
+	mu           sync.Mutex
+	drums        []Drum
+	order        map[string]int // place of each drum in first in, first out order
+	locked       map[string]string
+	reservations []Reservation
+	nextID       int
+	now          func() time.Time // clock the reservations are dated by
+}
+
+// New makes an allocator for available drums in first in, first out order, with the drums of earlier reservations
+// locked.
+func New(drums []Drum, reservations []Reservation) (*Allocator, error) {
+	a := &Allocator{drums: drums, order: make(map[string]int, len(drums)), locked: make(map[string]string), nextID: 1, now: time.Now}
+	for i, drum := range drums {
+		a.order[drum.Key()] = i
+	}
+	for _, r := range reservations {
+		for _, drum := range r.Drums {
+			if id, ok := a.locked[drum.Key()]; ok {
+				return nil, fmt.Errorf("drum number %d of batch %s is reserved by both %s and %s", drum.DrumNumber, drum.BatchNo, id, r.ID)
+			}
+			a.locked[drum.Key()] = r.ID
+		}
+		if n, err := strconv.Atoi(strings.TrimPrefix(r.ID, "R")); err == nil && n >= a.nextID {
+			a.nextID = n + 1
+		}
+		a.reservations = append(a.reservations, r)
+	}
+	return a, nil
+}
+
+// Reserve picks drums for a request by its strategy and locks them.
+func (a *Allocator) Reserve(request Request) (Reservation, error) {
+	if request.Strategy == "" {
+		request.Strategy = StrategyFIFO
+	}
+	pick, ok := strategies[request.Strategy]
+	if !ok {
+		return Reservation{}, fmt.Errorf("unknown strategy %s, expected %s, %s or %s", request.Strategy, StrategyFIFO, StrategyFewestDrums, StrategyLeastWaste)
+	}
+	if request.Metres <= 0 {
+		return Reservation{}, fmt.Errorf("requested length %d m is not positive", request.Metres)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var candidates, preferred []Drum
+	var total, preferredTotal int
+	for _, drum := range a.drums {
+		if drum.MaterialCode != request.MaterialCode {
+			continue
+		}
+		if _, locked := a.locked[drum.Key()]; locked {
+			continue
+		}
+		candidates = append(candidates, drum)
+		total += drum.Metres
+		if drum.Metres == request.PreferredDrumSize {
+			preferred = append(preferred, drum)
+			preferredTotal += drum.Metres
+		}
+	}
+	if total < request.Metres {
+		return Reservation{}, fmt.Errorf("only %d m of material code %s is available, %d m requested", total, request.MaterialCode, request.Metres)
+	}
+	if request.PreferredDrumSize > 0 && preferredTotal >= request.Metres {
+		candidates = preferred
+	}
+
+	drums := pick(candidates, request.Metres)
+	sort.SliceStable(drums, func(i, j int) bool { return a.order[drums[i].Key()] < a.order[drums[j].Key()] })
+
+	r := Reservation{ID: "R" + strconv.Itoa(a.nextID), Request: request, Drums: drums, ReservedOn: a.now().Format(dateLayout)}
+	for _, drum := range drums {
+		r.Metres += drum.Metres
+		a.locked[drum.Key()] = r.ID
+	}
+	a.nextID++
+	a.reservations = append(a.reservations, r)
+	return r, nil
+}
+
+// Release unlocks the drums of a reservation.
+func (a *Allocator) Release(id string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i, r := range a.reservations {
+		if r.ID != id {
+			continue
+		}
+		for _, drum := range r.Drums {
+			delete(a.locked, drum.Key())
+		}
+		a.reservations = append(a.reservations[:i:i], a.reservations[i+1:]...)
+		return nil
+	}
+	return fmt.Errorf("no reservation %s", id)
+}
+
+// Current returns the reservations holding drums, in the order they were made.
+func (a *Allocator) Current() []Reservation {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]Reservation{}, a.reservations...)
+}
+
+// strategies pick drums covering metres from candidates in first in, first out order. The candidates always cover
+// the request.
+var strategies = map[string]func(candidates []Drum, metres int) []Drum{
+	StrategyFIFO:        pickFIFO,
+	StrategyFewestDrums: pickFewestDrums,
+	StrategyLeastWaste:  pickLeastWaste,
+}
+
+// pickFIFO takes the oldest drums until the request is covered.
+func pickFIFO(candidates []Drum, metres int) []Drum {
+	var picked []Drum
+	total := 0
+	for _, drum := range candidates {
+		if total >= metres {
+			break
+		}
+		picked = append(picked, drum)
+		total += drum.Metres
+	}
+	return picked
+}
+
+// pickFewestDrums takes the longest drums until the request is covered, which needs the fewest drums, then swaps
+// the last one for the shortest drum that still covers it.
+func pickFewestDrums(candidates []Drum, metres int) []Drum {
+	byLength := append([]Drum{}, candidates...)
+	sort.SliceStable(byLength, func(i, j int) bool { return byLength[i].Metres > byLength[j].Metres })
+
+	picked := pickFIFO(byLength, metres)
+	last := len(picked) - 1
+	covered := 0
+	for _, drum := range picked[:last] {
+		covered += drum.Metres
+	}
+	for _, drum := range byLength[last:] {
+		if covered+drum.Metres >= metres && drum.Metres < picked[last].Metres {
+			picked[last] = drum
+		}
+	}
+	return picked
+}
+
+// pickLeastWaste takes the drums whose length exceeds the request the least, with the fewest drums among those and
+// the oldest drums of each length. Drums of one length are interchangeable, so it searches the counts of each length
+// reaching every total up to the request plus the longest drum.
+func pickLeastWaste(candidates []Drum, metres int) []Drum {
+	var lengths []int
+	byLength := make(map[int][]Drum)
+	longest := 0
+	for _, drum := range candidates {
+		if _, ok := byLength[drum.Metres]; !ok {
+			lengths = append(lengths, drum.Metres)
+		}
+		byLength[drum.Metres] = append(byLength[drum.Metres], drum)
+		if drum.Metres > longest {
+			longest = drum.Metres
+		}
+	}
+	sort.Ints(lengths)
+
+	// bounded knapsack: drums of a length are split into groups of 1, 2, 4, ... drums taken together
+	type group struct {
+		length, drums int
+	}
+	var groups []group
+	for _, length := range lengths {
+		count := len(byLength[length])
+		for size := 1; count > 0; size *= 2 {
+			if size > count {
+				size = count
+			}
+			groups = append(groups, group{length: length, drums: size})
+			count -= size
+		}
+	}
+
+	limit := metres + longest
+	fewest := make([]int, limit+1)
+	for total := range fewest {
+		fewest[total] = -1
+	}
+	fewest[0] = 0
+	taken := make([][]bool, len(groups))
+	for i, g := range groups {
+		taken[i] = make([]bool, limit+1)
+		length := g.length * g.drums
+		for total := limit; total >= length; total-- {
+			if from := fewest[total-length]; from >= 0 && (fewest[total] < 0 || from+g.drums < fewest[total]) {
+				fewest[total] = from + g.drums
+				taken[i][total] = true
+			}
+		}
+	}
+
+	best := metres
+	for best <= limit && fewest[best] < 0 {
+		best++
+	}
+
+	counts := make(map[int]int)
+	for i, total := len(groups)-1, best; i >= 0; i-- {
+		if taken[i][total] {
+			counts[groups[i].length] += groups[i].drums
+			total -= groups[i].length * groups[i].drums
+		}
+	}
+
+	var picked []Drum
+	for _, length := range lengths {
+		picked = append(picked, byLength[length][:counts[length]]...)
+	}
+	return picked
+}
+
+// LockTimeout is how long WithLock waits for another process to finish with a reservations file.
+var LockTimeout = 10 * time.Second
+
+// WithLock runs fn while holding the lock file of a reservations file, so reservations made by processes running at
+// once do not lock the same drums.
+func WithLock(path string, fn func() error) error {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(LockTimeout)
+	for {
+		lock, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			lock.Close()
+			defer os.Remove(lockPath)
+			return fn()
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s is locked by another process, remove %s if none is running", path, lockPath)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// ReadReservations reads a reservations file, a missing file holds no reservations.
+func ReadReservations(path string) ([]Reservation, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var reservations []Reservation
+	if err := json.Unmarshal(data, &reservations); err != nil {
+		return nil, fmt.Errorf("failed to read reservations %s: %w", path, err)
+	}
+	return reservations, nil
+}
+
+// WriteReservations replaces a reservations file, through a temporary file so it is never half written.
+func WriteReservations(path string, reservations []Reservation) error {
+	if reservations == nil {
+		reservations = []Reservation{}
+	}
+	data, err := json.MarshalIndent(reservations, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,179 @@
+package allocation
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// drumsOf lists the metres and drum number of the drums of a reservation.
+func drumsOf(r Reservation) [][2]int {
+	drums := make([][2]int, 0, len(r.Drums))
+	for _, drum := range r.Drums {
+		drums = append(drums, [2]int{drum.Metres, drum.DrumNumber})
+	}
+	return drums
+}
+
+// TestAllocator reserves from material M in drums of 1 km approved on 01-01-2024, of 250 m approved on 01-02-2024
+// and of 500 m approved on 01-03-2024, in first in, first out order.
+func TestAllocator(t *testing.T) {
+	drum := func(liNumber, batchNo string, drumSize int, unit string, drumNumber, metres int, approvalDate string) Drum {
+		return Drum{
+			ContractNo: "1", MaterialCode: "M", LiCode: "Li", LiNumber: liNumber, BatchNo: batchNo,
+			DrumSize: drumSize, Unit: unit, DrumNumber: drumNumber, Metres: metres, ApprovalDate: approvalDate,
+		}
+	}
+	drums := []Drum{
+		drum("2", "2/50", 1, "km", 1, 1000, "2024-01-01"),
+		drum("1", "1/50", 250, "m", 1, 250, "2024-02-01"),
+		drum("1", "1/50", 250, "m", 2, 250, "2024-02-01"),
+		drum("1", "1/50", 250, "m", 3, 250, "2024-02-01"),
+		drum("1", "1/50", 250, "m", 4, 250, "2024-02-01"),
+		drum("1", "1/50", 500, "m", 5, 500, "2024-03-01"),
+		drum("1", "1/50", 500, "m", 6, 500, "2024-03-01"),
+	}
+
+	t.Run("reserve", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			request Request
+			want    [][2]int
+			wantErr string
+		}{
+			{name: "fifo", request: Request{MaterialCode: "M", Metres: 1200, Strategy: StrategyFIFO}, want: [][2]int{{1000, 1}, {250, 1}}},
+			{name: "fifo by default", request: Request{MaterialCode: "M", Metres: 1100}, want: [][2]int{{1000, 1}, {250, 1}}},
+			{name: "fewest drums", request: Request{MaterialCode: "M", Metres: 1400, Strategy: StrategyFewestDrums}, want: [][2]int{{1000, 1}, {500, 5}}},
+			{name: "fewest drums, shortest last drum", request: Request{MaterialCode: "M", Metres: 1200, Strategy: StrategyFewestDrums}, want: [][2]int{{1000, 1}, {250, 1}}},
+			{name: "least waste", request: Request{MaterialCode: "M", Metres: 750, Strategy: StrategyLeastWaste}, want: [][2]int{{250, 1}, {500, 5}}},
+			{name: "least waste, fewest drums", request: Request{MaterialCode: "M", Metres: 1000, Strategy: StrategyLeastWaste}, want: [][2]int{{1000, 1}}},
+			{name: "least waste, all drums", request: Request{MaterialCode: "M", Metres: 3000, Strategy: StrategyLeastWaste}, want: [][2]int{{1000, 1}, {250, 1}, {250, 2}, {250, 3}, {250, 4}, {500, 5}, {500, 6}}},
+			{name: "preferred drum size", request: Request{MaterialCode: "M", Metres: 600, PreferredDrumSize: 500}, want: [][2]int{{500, 5}, {500, 6}}},
+			{name: "preferred drum size too short", request: Request{MaterialCode: "M", Metres: 1200, PreferredDrumSize: 500}, want: [][2]int{{1000, 1}, {250, 1}}},
+			{name: "not enough", request: Request{MaterialCode: "M", Metres: 3001}, wantErr: "only 3000 m of material code M is available, 3001 m requested"},
+			{name: "not by length", request: Request{MaterialCode: "N", Metres: 1}, wantErr: "only 0 m of material code N is available, 1 m requested"},
+			{name: "unknown strategy", request: Request{MaterialCode: "M", Metres: 1, Strategy: "lifo"}, wantErr: "unknown strategy lifo, expected fifo, fewest-drums or least-waste"},
+			{name: "no length", request: Request{MaterialCode: "M"}, wantErr: "requested length 0 m is not positive"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				a, err := New(drums, nil)
+				assert.NoError(t, err)
+
+				got, err := a.Reserve(tt.request)
+				if tt.wantErr != "" {
+					assert.EqualError(t, err, tt.wantErr)
+					assert.Empty(t, a.Current())
+					return
+				}
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, drumsOf(got))
+				assert.Equal(t, "R1", got.ID)
+				assert.GreaterOrEqual(t, got.Waste(), 0)
+				assert.Equal(t, []Reservation{got}, a.Current())
+			})
+		}
+	})
+
+	t.Run("locks", func(t *testing.T) {
+		a, err := New(drums, nil)
+		assert.NoError(t, err)
+
+		first, err := a.Reserve(Request{MaterialCode: "M", Metres: 1000, Strategy: StrategyFIFO})
+		assert.NoError(t, err)
+		assert.Equal(t, [][2]int{{1000, 1}}, drumsOf(first))
+
+		second, err := a.Reserve(Request{MaterialCode: "M", Metres: 1000, Strategy: StrategyFIFO})
+		assert.NoError(t, err)
+		assert.Equal(t, [][2]int{{250, 1}, {250, 2}, {250, 3}, {250, 4}}, drumsOf(second))
+		assert.Equal(t, "R2", second.ID)
+
+		_, err = a.Reserve(Request{MaterialCode: "M", Metres: 1001})
+		assert.EqualError(t, err, "only 1000 m of material code M is available, 1001 m requested")
+
+		assert.NoError(t, a.Release("R1"))
+		assert.EqualError(t, a.Release("R1"), "no reservation R1")
+		third, err := a.Reserve(Request{MaterialCode: "M", Metres: 1001, Strategy: StrategyLeastWaste})
+		assert.NoError(t, err)
+		assert.Equal(t, [][2]int{{1000, 1}, {500, 5}}, drumsOf(third))
+		assert.Equal(t, "R3", third.ID)
+
+		// reservations made earlier keep their drums locked
+		b, err := New(drums, a.Current())
+		assert.NoError(t, err)
+		fourth, err := b.Reserve(Request{MaterialCode: "M", Metres: 500})
+		assert.NoError(t, err)
+		assert.Equal(t, [][2]int{{500, 6}}, drumsOf(fourth))
+		assert.Equal(t, "R4", fourth.ID)
+
+		_, err = New(drums, []Reservation{first, {ID: "R9", Drums: first.Drums}})
+		assert.EqualError(t, err, "drum number 1 of batch 2/50 is reserved by both R1 and R9")
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		a, err := New(drums, nil)
+		assert.NoError(t, err)
+
+		var wg sync.WaitGroup
+		results := make([]Reservation, 20)
+		errs := make([]error, 20)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], errs[i] = a.Reserve(Request{MaterialCode: "M", Metres: 250, PreferredDrumSize: 250})
+			}(i)
+		}
+		wg.Wait()
+
+		reserved := make(map[string]string)
+		ids := make(map[string]bool)
+		succeeded := 0
+		for i, r := range results {
+			if errs[i] != nil {
+				continue
+			}
+			succeeded++
+			assert.False(t, ids[r.ID], "reservation %s made twice", r.ID)
+			ids[r.ID] = true
+			for _, drum := range r.Drums {
+				assert.Empty(t, reserved[drum.Key()], "drum %s reserved by %s and %s", drum.Key(), reserved[drum.Key()], r.ID)
+				reserved[drum.Key()] = r.ID
+			}
+		}
+		// the 250 m drums first, then the longer drums, until all 3000 m are reserved
+		assert.Equal(t, 7, succeeded)
+		assert.Len(t, reserved, 7)
+		assert.Len(t, a.Current(), 7)
+	})
+}
+
+func TestWithLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reservations.json")
+	assert.NoError(t, os.WriteFile(path+".lock", nil, 0644))
+
+	timeout := LockTimeout
+	LockTimeout = 100 * time.Millisecond
+	defer func() { LockTimeout = timeout }()
+
+	ran := false
+	err := WithLock(path, func() error {
+		ran = true
+		return nil
+	})
+	assert.Error(t, err)
+	assert.False(t, ran)
+
+	assert.NoError(t, os.Remove(path+".lock"))
+	assert.NoError(t, WithLock(path, func() error {
+		ran = true
+		assert.FileExists(t, path+".lock")
+		return nil
+	}))
+	assert.True(t, ran)
+	assert.NoFileExists(t, path+".lock")
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"VMIStockUpload/allocation"
+)
+
+func Test_availableStockDrums(t *testing.T) {
+	records := testInventory(testContract("1", "",
+		testLI("1", "M", UnitMetre, testBatch("1/50",
+			[]BatchTestApproval{testApproval("01-02-2024", "APPROVED", 250, 1, 2), testApproval("01-03-2024", "APPROVED", 500, 5, 7)},
+			DrumPartition{DrumSize: 250, AvailableDrumNumbers: []int{2, 1}},
+			DrumPartition{DrumSize: 500, Unit: UnitMetre, AvailableDrumNumbers: []int{5}, BufferDrumNumbers: []int{7}},
+		)),
+		testLI("2", "M", UnitKilometre, testBatch("2/50",
+			[]BatchTestApproval{testApproval("01-01-2024", "APPROVED", 1, 1)},
+			DrumPartition{DrumSize: 1, Unit: UnitKilometre, AvailableDrumNumbers: []int{1, 2}},
+		)),
+		testLI("3", "N", UnitKilogram, testBatch("3/50", nil, DrumPartition{DrumSize: 100, Unit: UnitKilogram, AvailableDrumNumbers: []int{1}})),
+	))
+
+	drum := func(liNumber, batchNo string, drumSize int, unit string, drumNumber, metres int, approvalDate string) allocation.Drum {
+		return allocation.Drum{
+			ContractNo: "1", MaterialCode: "M", LiCode: "Li", LiNumber: liNumber, BatchNo: batchNo,
+			DrumSize: drumSize, Unit: unit, DrumNumber: drumNumber, Metres: metres, ApprovalDate: approvalDate,
+		}
+	}
+	// oldest approval first, drums never approved last; available drums only, of the LIs measured in length
+	assert.Equal(t, []allocation.Drum{
+		drum("2", "2/50", 1, UnitKilometre, 1, 1000, "2024-01-01"),
+		drum("1", "1/50", 250, UnitMetre, 1, 250, "2024-02-01"),
+		drum("1", "1/50", 250, UnitMetre, 2, 250, "2024-02-01"),
+		drum("1", "1/50", 500, UnitMetre, 5, 500, "2024-03-01"),
+		drum("2", "2/50", 1, UnitKilometre, 2, 1000, ""),
+	}, availableStockDrums(records))
+}
+
+func Test_runReserve(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "inventory.json")
+	reservationsPath := filepath.Join(dir, "reservations.json")
+	records := testInventory(testContract("1", "",
+		testLI("1", "M", UnitMetre, testBatch("1/50",
+			[]BatchTestApproval{testApproval("01-02-2024", "APPROVED", 250, 1, 2, 3, 4), testApproval("01-03-2024", "APPROVED", 500, 5, 6, 7)},
+			DrumPartition{DrumSize: 250, Unit: UnitMetre, AvailableDrumNumbers: []int{1, 2, 3, 4}},
+			DrumPartition{DrumSize: 500, Unit: UnitMetre, AvailableDrumNumbers: []int{5, 6}, BufferDrumNumbers: []int{7}},
+		)),
+		testLI("2", "M", UnitKilometre, testBatch("2/50",
+			[]BatchTestApproval{testApproval("01-01-2024", "APPROVED", 1, 1)},
+			DrumPartition{DrumSize: 1, Unit: UnitKilometre, AvailableDrumNumbers: []int{1}},
+		)),
+	))
+	jsonData, err := marshalRecords(records, outputOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(jsonPath, jsonData, 0644))
+
+	var stdout bytes.Buffer
+	assert.NoError(t, runReserve([]string{"-in", jsonPath, "-reservations", reservationsPath, "-material", "M", "-metres", "600", "-drum-size", "500", "-for", "Substation A"}, &stdout))
+	assert.Equal(t, "reservation R1 for Substation A: 2 drums of material code M, 1000 m for 600 m requested (400 m waste, fifo)\n"+
+		"  contract 1 LI Li-1 batch 1/50 drum size 500 m drum no. 5, approved 2024-03-01\n"+
+		"  contract 1 LI Li-1 batch 1/50 drum size 500 m drum no. 6, approved 2024-03-01\n", stdout.String())
+
+	stdout.Reset()
+	assert.NoError(t, runReserve([]string{"-in", jsonPath, "-reservations", reservationsPath, "-material", "M", "-metres", "2000", "-strategy", allocation.StrategyLeastWaste}, &stdout))
+	assert.Contains(t, stdout.String(), "reservation R2: 5 drums of material code M, 2000 m for 2000 m requested (0 m waste, least-waste)")
+
+	assert.EqualError(t, runReserve([]string{"-in", jsonPath, "-reservations", reservationsPath, "-material", "M", "-metres", "1"}, &stdout),
+		"only 0 m of material code M is available, 1 m requested")
+
+	reservations, err := allocation.ReadReservations(reservationsPath)
+	assert.NoError(t, err)
+	assert.Len(t, reservations, 2)
+	assert.Equal(t, dateOf(time.Now()).String(), reservations[0].ReservedOn)
+
+	stdout.Reset()
+	assert.NoError(t, runRelease([]string{"-reservations", reservationsPath, "-id", "R1"}, &stdout))
+	assert.Equal(t, "reservation R1 released\n", stdout.String())
+	reservations, err = allocation.ReadReservations(reservationsPath)
+	assert.NoError(t, err)
+	assert.Len(t, reservations, 1)
+	assert.Equal(t, "R2", reservations[0].ID)
+
+	assert.Error(t, runRelease([]string{"-reservations", reservationsPath, "-id", "R1"}, &stdout))
+	assert.NoFileExists(t, reservationsPath+".lock")
+}
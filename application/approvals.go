@@ -0,0 +1,371 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// approvalStatuses are the outcomes of a batch test.
+var approvalStatuses = []string{"APPROVED", "REJECTED", "RETEST"}
+
+// parseApprovalStatus normalises the outcome of a batch test. Rows without an outcome are approvals, which is how
+// every file produced before the "Batch Test Status" column was introduced has to be read.
+func parseApprovalStatus(raw string) (string, error) {
+	switch strings.ToUpper(strings.TrimSpace(raw)) {
+	case "", "APPROVED":
+		return "APPROVED", nil
+	case "REJECTED":
+		return "REJECTED", nil
+	case "RETEST":
+		return "RETEST", nil
+	}
+	return "", fmt.Errorf("unknown batch test status: %s", raw)
+}
+
+// rejectsDrums reports whether the row's batch test did not approve the drums it lists.
+func (row *CSVRow) rejectsDrums() bool {
+	return row.BatchTestReportDate != "" && (row.BatchTestStatus == "REJECTED" || row.BatchTestStatus == "RETEST")
+}
+
+// createUnapprovedDrumPartition creates a drum partition for a row whose drums were rejected or sent for retest,
+// all of its quantity is unapproved until a later approval.
+func createUnapprovedDrumPartition(row CSVRow, rowIndex int) (DrumPartition, []Error) {
+	remarks := addRemark(nil, row.Remarks)
+	unapproved, errors := unapprovedDrums(row, rowIndex)
+	return DrumPartition{
+		DrumSize:              row.DrumSize,
+		Unit:                  row.Unit,
+		Quantity:              row.TotalQty,
+		UnapprovedQuantity:    row.TotalQty,
+		UnapprovedDrumNumbers: unapproved,
+		AvailableDrumNumbers:  []int{},
+		BufferDrumNumbers:     []int{},
+		TestDrumNumbers:       []DrumDetails{},
+		ShortDrumNumbers:      []DrumDetails{},
+		PartialDrumNumbers:    []DrumDetails{},
+		Remarks:               remarks,
+		RemarkFlags:           remarkFlags(remarks),
+	}, errors
+}
+
+// newBatchTestApproval creates the batch test approval of a row's report date, without drums.
+func newBatchTestApproval(row CSVRow) BatchTestApproval {
+	res := BatchTestApproval{
+		ApprovalDate:    mustParseDate(row.BatchTestReportDate),
+		Status:          row.BatchTestStatus,
+		ApprovalComment: row.ApprovalComment,
+		TestDrumNumbers: []BatchTestDrumNumbers{},
+		Remarks:         []string{},
+	}
+	if res.Status == "" {
+		res.Status = "APPROVED"
+	}
+	return res
+}
+
+// addToApproval adds the remark, the test drums and the approved drums of a row to a batch test approval. The drums
+// go to the entries of the row's drum size, which are created when the approval has none for that drum size yet.
+// The approval gets its own copy of every slice it changes, and approved drums listed twice for one drum size are
+// returned as an error.
+func addToApproval(bta BatchTestApproval, row CSVRow) (BatchTestApproval, error) {
+	bta.Remarks = addRemark(append([]string{}, bta.Remarks...), row.Remarks)
+
+	// only rows with sample drums were tested on drums of their drum size
+	if row.SampleDrum {
+		testDrumNumbers := make([]BatchTestDrumNumbers, 0, len(bta.TestDrumNumbers)+1)
+		found := false
+		for _, testDrumNumber := range bta.TestDrumNumbers {
+			testDrumNumber.DrumNumbers = append([]DrumDetails{}, testDrumNumber.DrumNumbers...)
+			if testDrumNumber.DrumSize == row.DrumSize {
+				testDrumNumber.DrumNumbers = append(testDrumNumber.DrumNumbers, sampleDrumDetails(row)...)
+				found = true
+			}
+			testDrumNumbers = append(testDrumNumbers, testDrumNumber)
+		}
+		if !found {
+			testDrumNumbers = append(testDrumNumbers, BatchTestDrumNumbers{DrumSize: row.DrumSize, DrumNumbers: sampleDrumDetails(row)})
+		}
+		bta.TestDrumNumbers = testDrumNumbers
+	}
+
+	var err error
+	approvalDrumNumbers := make([]ApprovalDrumNumber, 0, len(bta.ApprovalDrumNumbers)+1)
+	found := false
+	for _, approvalDrumNumber := range bta.ApprovalDrumNumbers {
+		if approvalDrumNumber.DrumSize == row.DrumSize {
+			approvalDrumNumber.DrumNumbers, err = combineSortAndCheckDuplicates(approvalDrumNumber.DrumNumbers, row.ApprovedDrumNumbers)
+			found = true
+		} else {
+			approvalDrumNumber.DrumNumbers = append([]int{}, approvalDrumNumber.DrumNumbers...)
+		}
+		approvalDrumNumbers = append(approvalDrumNumbers, approvalDrumNumber)
+	}
+	if !found {
+		approvalDrumNumbers = append(approvalDrumNumbers, ApprovalDrumNumber{DrumSize: row.DrumSize, DrumNumbers: append([]int{}, row.ApprovedDrumNumbers...)})
+	}
+	bta.ApprovalDrumNumbers = approvalDrumNumbers
+
+	return bta, err
+}
+
+// sampleDrumDetails lists the sample cuts of a row's sample drums.
+func sampleDrumDetails(row CSVRow) []DrumDetails {
+	details := make([]DrumDetails, 0, len(row.SampleDrumNo))
+	for i, drumNo := range row.SampleDrumNo {
+		details = append(details, DrumDetails{DrumNumber: drumNo, Quantity: row.SampleLength[i]})
+	}
+	return details
+}
+
+// aggregateApproval adds a row with a batch test report date to the batch test approval of its batch and date,
+// creating the approval when the batch has none for the date yet. Rows without a report date have no approval.
+func (b *inventoryBuilder) aggregateApproval(batch *Batch, row CSVRow, rowIndex int) {
+	if row.BatchTestReportDate == "" {
+		return
+	}
+
+	bta := newBatchTestApproval(row)
+	key := approvalKey{batchKey: batchKeyOf(row), approvalDate: bta.ApprovalDate, status: bta.Status}
+	if i, ok := b.index.approvals[key]; ok {
+		bta = batch.BatchTestApprovals[i]
+	}
+
+	bta, err := addToApproval(bta, row)
+	if err != nil {
+		b.errors = append(b.errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("failed to combine, sort and check drum no. duplicates : %s", err)})
+	}
+	b.putBatchTestApproval(batch, key, bta)
+}
+
+// resolveApprovalHistory applies the batch test approvals of a batch in date order, the latest outcome for a
+// drum supersedes the earlier ones:
+//   - a drum rejected or sent for retest and approved later is counted once, as approved
+//   - a drum approved and rejected or sent for retest later moves back to unapproved
+//
+// Approvals whose drums all have a later outcome are marked as superseded and the batch status is recomputed.
+func resolveApprovalHistory(batch Batch) Batch {
+	if !hasUnapprovedOutcome(batch) {
+		return batch
+	}
+
+	approvals := sortedApprovalIndexes(batch.BatchTestApprovals)
+
+	// latest approval index per drum size and drum number
+	latest := make(map[int]map[int]int)
+
+	for dpIndex, dp := range batch.DrumPartitions {
+		history := make(map[int][]int)
+		for _, approvalIndex := range approvals {
+			for _, approvalDrumNumber := range batch.BatchTestApprovals[approvalIndex].ApprovalDrumNumbers {
+				if approvalDrumNumber.DrumSize != dp.DrumSize {
+					continue
+				}
+				for _, drumNo := range approvalDrumNumber.DrumNumbers {
+					history[drumNo] = append(history[drumNo], approvalIndex)
+				}
+			}
+		}
+
+		drumNumbers := make([]int, 0, len(history))
+		for drumNo := range history {
+			drumNumbers = append(drumNumbers, drumNo)
+		}
+		sort.Ints(drumNumbers)
+
+		latest[dp.DrumSize] = make(map[int]int)
+		for _, drumNo := range drumNumbers {
+			outcomes := history[drumNo]
+			latestIndex := outcomes[len(outcomes)-1]
+			latest[dp.DrumSize][drumNo] = latestIndex
+			latestApproved := batch.BatchTestApprovals[latestIndex].Status == "APPROVED"
+
+			// drop the earlier contributions of the drum so it is only counted for its latest outcome
+			for _, approvalIndex := range outcomes[:len(outcomes)-1] {
+				if batch.BatchTestApprovals[approvalIndex].Status == "APPROVED" {
+					if latestApproved {
+						// approved twice, reported as duplicate drum numbers
+						continue
+					}
+					var removed bool
+					dp, removed = removeApprovedDrum(dp, drumNo)
+					if !removed {
+						continue
+					}
+				} else {
+					dp.UnapprovedQuantity -= dp.DrumSize
+					dp.UnapprovedDrumNumbers = removeDrumNumber(dp.UnapprovedDrumNumbers, drumNo)
+				}
+				dp.Quantity -= dp.DrumSize
+				batch.TotalQuantity -= dp.DrumSize
+			}
+		}
+		batch.DrumPartitions[dpIndex] = dp
+	}
+
+	for _, approvalIndex := range approvals {
+		bta := batch.BatchTestApprovals[approvalIndex]
+		superseded := true
+		var drumCount int
+		for _, approvalDrumNumber := range bta.ApprovalDrumNumbers {
+			for _, drumNo := range approvalDrumNumber.DrumNumbers {
+				drumCount++
+				if latest[approvalDrumNumber.DrumSize][drumNo] == approvalIndex {
+					superseded = false
+				}
+			}
+		}
+		batch.BatchTestApprovals[approvalIndex].Superseded = superseded && drumCount > 0
+	}
+
+	batch.Status = determineBatchStatus(batch)
+	return batch
+}
+
+func hasUnapprovedOutcome(batch Batch) bool {
+	for _, bta := range batch.BatchTestApprovals {
+		if bta.Status == "REJECTED" || bta.Status == "RETEST" {
+			return true
+		}
+	}
+	return false
+}
+
+// removeApprovedDrum takes an approved drum out of the available, buffer or sample drums of the drum partition.
+func removeApprovedDrum(dp DrumPartition, drumNo int) (DrumPartition, bool) {
+	if containsDrumNumber(dp.AvailableDrumNumbers, drumNo) {
+		dp.AvailableDrumNumbers = removeDuplicateDrumNumbers(dp.AvailableDrumNumbers, []int{drumNo})
+		dp.AvailableQuantity -= dp.DrumSize
+		return dp, true
+	}
+	if containsDrumNumber(dp.BufferDrumNumbers, drumNo) {
+		dp.BufferDrumNumbers = removeDuplicateDrumNumbers(dp.BufferDrumNumbers, []int{drumNo})
+		dp.BufferQuantity -= dp.DrumSize
+		return dp, true
+	}
+
+	var removed bool
+	var qty float64
+	dp.TestDrumNumbers, qty = removeDrumDetails(dp.TestDrumNumbers, drumNo)
+	dp.TestQuantity -= qty
+	removed = removed || qty > 0
+	dp.ShortDrumNumbers, qty = removeDrumDetails(dp.ShortDrumNumbers, drumNo)
+	dp.ShortQuantity -= qty
+	removed = removed || qty > 0
+	dp.PartialDrumNumbers, qty = removeDrumDetails(dp.PartialDrumNumbers, drumNo)
+	dp.PartialQuantity -= qty
+	removed = removed || qty > 0
+	return dp, removed
+}
+
+func removeDrumDetails(details []DrumDetails, drumNo int) ([]DrumDetails, float64) {
+	result := make([]DrumDetails, 0)
+	var removedQty float64
+	for _, detail := range details {
+		if detail.DrumNumber == drumNo {
+			removedQty += detail.Quantity
+			continue
+		}
+		result = append(result, detail)
+	}
+	return result, removedQty
+}
+
+func containsDrumNumber(drumNumbers []int, drumNo int) bool {
+	for _, num := range drumNumbers {
+		if num == drumNo {
+			return true
+		}
+	}
+	return false
+}
+
+// approvalBefore reports whether the outcome of approval a precedes that of b: by approval date, and a rejection or
+// retest dated on the day of an approval counts as the later outcome.
+func approvalBefore(a, b BatchTestApproval) bool {
+	if !a.ApprovalDate.Equal(b.ApprovalDate) {
+		return a.ApprovalDate.Before(b.ApprovalDate)
+	}
+	return a.Status == "APPROVED" && b.Status != "APPROVED"
+}
+
+// sortedApprovalIndexes returns the indexes of the approvals in the order of their outcomes, oldest first.
+func sortedApprovalIndexes(approvals []BatchTestApproval) []int {
+	indexes := make([]int, len(approvals))
+	for i := range approvals {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return approvalBefore(approvals[indexes[i]], approvals[indexes[j]])
+	})
+	return indexes
+}
+
+// latestApproval returns the most recent batch test approval that has not been superseded.
+func latestApproval(batch Batch) (BatchTestApproval, bool) {
+	indexes := sortedApprovalIndexes(batch.BatchTestApprovals)
+	for i := len(indexes) - 1; i >= 0; i-- {
+		if bta := batch.BatchTestApprovals[indexes[i]]; !bta.Superseded {
+			return bta, true
+		}
+	}
+	return BatchTestApproval{}, false
+}
+
+// approvedDrumsOf returns the drums of a drum size whose latest outcome among the batch test approvals of a batch
+// approved them.
+func approvedDrumsOf(batch Batch, drumSize int) map[int]bool {
+	approved := make(map[int]bool)
+	for _, approvalIndex := range sortedApprovalIndexes(batch.BatchTestApprovals) {
+		bta := batch.BatchTestApprovals[approvalIndex]
+		for _, approvalDrumNumber := range bta.ApprovalDrumNumbers {
+			if approvalDrumNumber.DrumSize != drumSize {
+				continue
+			}
+			for _, drumNo := range approvalDrumNumber.DrumNumbers {
+				approved[drumNo] = bta.Status == "APPROVED"
+			}
+		}
+	}
+	return approved
+}
+
+// approvedDrumNumbersOf returns the drums an approval still holds approved. Rejections and retests hold none, and
+// an approved drum is dropped once a later batch test rejects it or sends it for retest, later in the order
+// resolveApprovalHistory applies them.
+func approvedDrumNumbersOf(batch Batch, approvalIndex int) []ApprovalDrumNumber {
+	bta := batch.BatchTestApprovals[approvalIndex]
+	if bta.Status == "REJECTED" || bta.Status == "RETEST" {
+		return []ApprovalDrumNumber{}
+	}
+	if !hasUnapprovedOutcome(batch) {
+		return bta.ApprovalDrumNumbers
+	}
+
+	revoked := make(map[int]map[int]bool)
+	for _, later := range batch.BatchTestApprovals {
+		if later.Status == "APPROVED" || !approvalBefore(bta, later) {
+			continue
+		}
+		for _, approvalDrumNumber := range later.ApprovalDrumNumbers {
+			if revoked[approvalDrumNumber.DrumSize] == nil {
+				revoked[approvalDrumNumber.DrumSize] = make(map[int]bool)
+			}
+			for _, drumNo := range approvalDrumNumber.DrumNumbers {
+				revoked[approvalDrumNumber.DrumSize][drumNo] = true
+			}
+		}
+	}
+
+	result := make([]ApprovalDrumNumber, 0, len(bta.ApprovalDrumNumbers))
+	for _, approvalDrumNumber := range bta.ApprovalDrumNumbers {
+		drumNumbers := make([]int, 0, len(approvalDrumNumber.DrumNumbers))
+		for _, drumNo := range approvalDrumNumber.DrumNumbers {
+			if !revoked[approvalDrumNumber.DrumSize][drumNo] {
+				drumNumbers = append(drumNumbers, drumNo)
+			}
+		}
+		result = append(result, ApprovalDrumNumber{DrumSize: approvalDrumNumber.DrumSize, DrumNumbers: drumNumbers, Sources: approvalDrumNumber.Sources})
+	}
+	return result
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseApprovalStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty is approved", raw: "", want: "APPROVED"},
+		{name: "approved", raw: "Approved", want: "APPROVED"},
+		{name: "rejected", raw: " rejected ", want: "REJECTED"},
+		{name: "retest", raw: "RETEST", want: "RETEST"},
+		{name: "unknown", raw: "pending", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseApprovalStatus(tt.raw)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func approvalTestRow(date, status string, availableDrumNos []int) CSVRow {
+	return CSVRow{
+		Vendor:                  "Supplier A",
+		MaterialCode:            "MAT100",
+		MaterialDesc:            "Material A",
+		ContractNo:              "C123",
+		LIName:                  LIName{LICode: "LI001", LINumber: "1"},
+		LIDate:                  "01-01-2024",
+		BatchNo:                 "1/2",
+		BatchDueDate:            "01-01-2025",
+		DrumSize:                250,
+		TotalNoOfDrums:          len(availableDrumNos),
+		TotalQty:                250 * len(availableDrumNos),
+		AvailableDrumNos:        availableDrumNos,
+		AvailableFullDrums:      len(availableDrumNos),
+		FullDrumTotalQuantity:   250 * len(availableDrumNos),
+		ApprovedDrumNumbers:     availableDrumNos,
+		BatchTestReportDate:     date,
+		BatchTestReportFileName: "report.pdf",
+		BatchTestStatus:         status,
+		ApprovalComment:         status + " on " + date,
+	}
+}
+
+func TestProcessRows_ApprovalHistory(t *testing.T) {
+	tests := []struct {
+		name               string
+		rows               []CSVRow
+		wantStatus         string
+		wantTotalQty       int
+		wantAvailable      []int
+		wantUnapprovedQty  int
+		wantApprovals      []string
+		wantSupersededDate string
+	}{
+		{
+			name:              "rejected batch stays unapproved",
+			rows:              []CSVRow{approvalTestRow("01-03-2024", "REJECTED", []int{1, 2})},
+			wantStatus:        "REJECTED",
+			wantTotalQty:      500,
+			wantAvailable:     []int{},
+			wantUnapprovedQty: 500,
+			wantApprovals:     []string{"REJECTED"},
+		},
+		{
+			name:              "retest pending",
+			rows:              []CSVRow{approvalTestRow("01-03-2024", "RETEST", []int{1, 2})},
+			wantStatus:        "RETEST_PENDING",
+			wantTotalQty:      500,
+			wantAvailable:     []int{},
+			wantUnapprovedQty: 500,
+			wantApprovals:     []string{"RETEST"},
+		},
+		{
+			name: "later approval supersedes rejection",
+			rows: []CSVRow{
+				approvalTestRow("01-03-2024", "REJECTED", []int{1, 2}),
+				approvalTestRow("01-04-2024", "APPROVED", []int{1, 2}),
+			},
+			wantStatus:         "AVAILABLE",
+			wantTotalQty:       500,
+			wantAvailable:      []int{1, 2},
+			wantUnapprovedQty:  0,
+			wantApprovals:      []string{"REJECTED", "APPROVED"},
+			wantSupersededDate: "01-03-2024",
+		},
+		{
+			name: "later rejection moves drums back to unapproved",
+			rows: []CSVRow{
+				approvalTestRow("01-03-2024", "APPROVED", []int{1, 2, 3}),
+				approvalTestRow("01-04-2024", "REJECTED", []int{2, 3}),
+			},
+			wantStatus:        "AVAILABLE",
+			wantTotalQty:      750,
+			wantAvailable:     []int{1},
+			wantUnapprovedQty: 500,
+			wantApprovals:     []string{"APPROVED", "REJECTED"},
+		},
+		{
+			name: "history is applied by date, not by row order",
+			rows: []CSVRow{
+				approvalTestRow("01-04-2024", "APPROVED", []int{1, 2}),
+				approvalTestRow("01-03-2024", "RETEST", []int{1, 2}),
+			},
+			wantStatus:         "AVAILABLE",
+			wantTotalQty:       500,
+			wantAvailable:      []int{1, 2},
+			wantUnapprovedQty:  0,
+			wantApprovals:      []string{"RETEST", "APPROVED"},
+			wantSupersededDate: "01-03-2024",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := processRows(tt.rows)
+			assert.Empty(t, errs)
+
+			batch := got.Contracts[0].LIs[0].Batches[0]
+			assert.Equal(t, tt.wantStatus, batch.Status)
+			assert.Equal(t, tt.wantTotalQty, batch.TotalQuantity)
+			assert.Equal(t, tt.wantAvailable, batch.DrumPartitions[0].AvailableDrumNumbers)
+			assert.Equal(t, tt.wantUnapprovedQty, batch.DrumPartitions[0].UnapprovedQuantity)
+			assert.Equal(t, tt.wantTotalQty, batch.DrumPartitions[0].Quantity)
+
+			var gotApprovals []string
+			for _, bta := range batch.BatchTestApprovals {
+				gotApprovals = append(gotApprovals, bta.Status)
+				assert.Equal(t, bta.Status+" on "+bta.ApprovalDate.Format(legacyDateLayout), bta.ApprovalComment)
+				assert.Equal(t, bta.ApprovalDate.Equal(testDate(tt.wantSupersededDate)), bta.Superseded, bta.ApprovalDate)
+			}
+			assert.Equal(t, tt.wantApprovals, gotApprovals)
+		})
+	}
+}
+
+func Test_approvedDrumNumbersOf(t *testing.T) {
+	batch := Batch{
+		BatchTestApprovals: []BatchTestApproval{
+			{ApprovalDate: testDate("01-03-2024"), Status: "APPROVED", ApprovalDrumNumbers: []ApprovalDrumNumber{{DrumSize: 250, DrumNumbers: []int{1, 2, 3}}}},
+			{ApprovalDate: testDate("01-04-2024"), Status: "REJECTED", ApprovalDrumNumbers: []ApprovalDrumNumber{{DrumSize: 250, DrumNumbers: []int{2, 3}}}},
+			{ApprovalDate: testDate("01-05-2024"), Status: "APPROVED", ApprovalDrumNumbers: []ApprovalDrumNumber{{DrumSize: 250, DrumNumbers: []int{3}}}},
+		},
+	}
+
+	assert.Equal(t, []ApprovalDrumNumber{{DrumSize: 250, DrumNumbers: []int{1}}}, approvedDrumNumbersOf(batch, 0))
+	assert.Equal(t, []ApprovalDrumNumber{}, approvedDrumNumbersOf(batch, 1))
+	assert.Equal(t, []ApprovalDrumNumber{{DrumSize: 250, DrumNumbers: []int{3}}}, approvedDrumNumbersOf(batch, 2))
+}
+
+func TestProcessRows_SameDayRejectionRevokesApproval(t *testing.T) {
+	rejected := approvalTestRow("01-03-2024", "REJECTED", []int{2})
+	approved := approvalTestRow("01-03-2024", "APPROVED", []int{1, 2})
+
+	got, errs := processRows([]CSVRow{rejected, approved})
+	assert.Empty(t, errs)
+
+	batch := got.Contracts[0].LIs[0].Batches[0]
+	assert.Equal(t, []int{1}, batch.DrumPartitions[0].AvailableDrumNumbers)
+	assert.Equal(t, []int{2}, batch.DrumPartitions[0].UnapprovedDrumNumbers)
+
+	claims := got.collectDrumClaims()
+	assert.Len(t, claims[1], 1)
+	assert.Empty(t, claims[2])
+}
+
+func TestProcessRows_SampleDrumFlagDrivesTestDrums(t *testing.T) {
+	withoutSamples := approvalTestRow("01-03-2024", "APPROVED", []int{1, 2})
+	withSamples := approvalTestRow("01-04-2024", "APPROVED", []int{3})
+	withSamples.SampleDrum = true
+	withSamples.SampleDrumNo = []int{3}
+	withSamples.SampleLength = []float64{2.5}
+	withSamples.AvailableDrumNos = []int{}
+	withSamples.AvailableFullDrums = 0
+	withSamples.FullDrumTotalQuantity = 0
+
+	got, errs := processRows([]CSVRow{withoutSamples, withSamples})
+	assert.Empty(t, errs)
+
+	approvals := got.Contracts[0].LIs[0].Batches[0].BatchTestApprovals
+	// an empty list rather than null in the JSON output
+	assert.Equal(t, []BatchTestDrumNumbers{}, approvals[0].TestDrumNumbers)
+	assert.Equal(t, []BatchTestDrumNumbers{{DrumSize: 250, DrumNumbers: []DrumDetails{{DrumNumber: 3, Quantity: 2.5}}}}, approvals[1].TestDrumNumbers)
+}
+
+// approvalCaseRow is a row approving the drums of a drum size, and testing the sample drum when it is not 0.
+func approvalCaseRow(date string, drumSize int, drums []int, sampleDrum int) CSVRow {
+	row := approvalTestRow(date, "APPROVED", drums)
+	row.DrumSize = drumSize
+	row.TotalQty = drumSize * len(drums)
+	row.FullDrumTotalQuantity = drumSize * len(drums)
+	if sampleDrum != 0 {
+		row.SampleDrum = true
+		row.SampleDrumNo = []int{sampleDrum}
+		row.SampleLength = []float64{2.5}
+		row.ApprovedDrumNumbers = append(append([]int{}, drums...), sampleDrum)
+		row.TotalNoOfDrums++
+		row.TotalQty += drumSize
+	}
+	return row
+}
+
+// approvalSummary lists the approved and tested drums of each batch test approval of a batch by drum size.
+func approvalSummary(batch Batch) []string {
+	summary := make([]string, 0, len(batch.BatchTestApprovals))
+	for _, bta := range batch.BatchTestApprovals {
+		line := bta.ApprovalDate.Time.Format("2006-01-02") + " approved"
+		for _, approvalDrumNumber := range bta.ApprovalDrumNumbers {
+			line += fmt.Sprintf(" %d:%v", approvalDrumNumber.DrumSize, approvalDrumNumber.DrumNumbers)
+		}
+		line += " tested"
+		for _, testDrumNumber := range bta.TestDrumNumbers {
+			drumNumbers := make([]int, 0, len(testDrumNumber.DrumNumbers))
+			for _, details := range testDrumNumber.DrumNumbers {
+				drumNumbers = append(drumNumbers, details.DrumNumber)
+			}
+			line += fmt.Sprintf(" %d:%v", testDrumNumber.DrumSize, drumNumbers)
+		}
+		summary = append(summary, line)
+	}
+	return summary
+}
+
+func TestProcessRows_ApprovalCases(t *testing.T) {
+	first := approvalCaseRow("01-03-2024", 250, []int{1, 2}, 3)
+
+	tests := []struct {
+		name          string
+		rows          []CSVRow
+		wantAvailable map[int][]int
+		wantApprovals []string
+		wantErrors    []Error
+	}{
+		{
+			name:          "1. same drum size, no batch test approval date",
+			rows:          []CSVRow{first, approvalCaseRow("", 250, []int{4}, 0)},
+			wantAvailable: map[int][]int{250: {1, 2}},
+			wantApprovals: []string{"2024-03-01 approved 250:[1 2 3] tested 250:[3]"},
+			wantErrors:    []Error{{RowNo: 2, Err: fmt.Errorf("drum number 4 of drum size 250 is declared available but is not approved")}},
+		},
+		{
+			name:          "2. same drum size, same batch test approval date",
+			rows:          []CSVRow{first, approvalCaseRow("01-03-2024", 250, []int{4}, 5)},
+			wantAvailable: map[int][]int{250: {1, 2, 4}},
+			wantApprovals: []string{"2024-03-01 approved 250:[1 2 3 4 5] tested 250:[3 5]"},
+		},
+		{
+			name:          "3. same drum size, different batch test approval date",
+			rows:          []CSVRow{first, approvalCaseRow("01-04-2024", 250, []int{4}, 5)},
+			wantAvailable: map[int][]int{250: {1, 2, 4}},
+			wantApprovals: []string{"2024-03-01 approved 250:[1 2 3] tested 250:[3]", "2024-04-01 approved 250:[4 5] tested 250:[5]"},
+		},
+		{
+			name:          "4. different drum size, no batch test approval date",
+			rows:          []CSVRow{first, approvalCaseRow("", 500, []int{4}, 0)},
+			wantAvailable: map[int][]int{250: {1, 2}, 500: {}},
+			wantApprovals: []string{"2024-03-01 approved 250:[1 2 3] tested 250:[3]"},
+			wantErrors:    []Error{{RowNo: 2, Err: fmt.Errorf("drum number 4 of drum size 500 is declared available but is not approved")}},
+		},
+		{
+			name:          "5. different drum size, same batch test approval date",
+			rows:          []CSVRow{first, approvalCaseRow("01-03-2024", 500, []int{4}, 5)},
+			wantAvailable: map[int][]int{250: {1, 2}, 500: {4}},
+			wantApprovals: []string{"2024-03-01 approved 250:[1 2 3] 500:[4 5] tested 250:[3] 500:[5]"},
+		},
+		{
+			name:          "6. different drum size, different batch test approval date",
+			rows:          []CSVRow{first, approvalCaseRow("01-04-2024", 500, []int{4}, 5)},
+			wantAvailable: map[int][]int{250: {1, 2}, 500: {4}},
+			wantApprovals: []string{"2024-03-01 approved 250:[1 2 3] tested 250:[3]", "2024-04-01 approved 500:[4 5] tested 500:[5]"},
+		},
+		{
+			name: "2. same drum size, same date as an approval created for another drum size",
+			rows: []CSVRow{
+				approvalCaseRow("01-03-2024", 250, []int{1}, 0),
+				approvalCaseRow("01-04-2024", 250, []int{2}, 0),
+				approvalCaseRow("01-03-2024", 500, []int{3}, 0),
+				approvalCaseRow("01-04-2024", 500, []int{4}, 5),
+			},
+			wantAvailable: map[int][]int{250: {1, 2}, 500: {3, 4}},
+			wantApprovals: []string{"2024-03-01 approved 250:[1] 500:[3] tested", "2024-04-01 approved 250:[2] 500:[4 5] tested 500:[5]"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := processRows(tt.rows)
+			assert.Equal(t, tt.wantErrors, errs)
+
+			batch := got.Contracts[0].LIs[0].Batches[0]
+			available := make(map[int][]int)
+			for _, dp := range batch.DrumPartitions {
+				available[dp.DrumSize] = dp.AvailableDrumNumbers
+			}
+			assert.Equal(t, tt.wantAvailable, available)
+			assert.Equal(t, tt.wantApprovals, approvalSummary(batch))
+		})
+	}
+}
+
+func TestProcessRows_ApprovalDrumsAreNotShared(t *testing.T) {
+	row := approvalTestRow("01-03-2024", "APPROVED", []int{1, 2})
+
+	got, errs := processRows([]CSVRow{row})
+	assert.Empty(t, errs)
+
+	batch := got.Contracts[0].LIs[0].Batches[0]
+	batch.BatchTestApprovals[0].ApprovalDrumNumbers[0].DrumNumbers[0] = 99
+	assert.Equal(t, []int{1, 2}, batch.DrumPartitions[0].AvailableDrumNumbers)
+	assert.Equal(t, []int{1, 2}, row.ApprovedDrumNumbers)
+}
+
+func TestProcessRows_DuplicateApprovedDrums(t *testing.T) {
+	first := approvalCaseRow("01-03-2024", 250, []int{1, 2}, 0)
+	second := approvalCaseRow("01-03-2024", 500, []int{3}, 0)
+	// the sample drum of the third row was approved by the second row
+	third := approvalCaseRow("01-03-2024", 500, []int{4}, 3)
+
+	_, errs := processRows([]CSVRow{first, second, third})
+	assert.Equal(t, []Error{{RowNo: 3, Err: fmt.Errorf("failed to combine, sort and check drum no. duplicates : duplicates found: [3]")}}, errs)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// availableDrums applies the availability rule to a row: the available drums of its drum size are the drums whose
+// latest outcome in the approval history of the batch approved them, counting the row's own batch test, that are
+// neither sample nor buffer drums of the row. approvedBefore holds the drums the approvals placed before the row
+// approved, see approvedDrumsOf. The drums the vendor declared available are checked against them and every declared
+// drum outside them is reported, as is every drum the row's batch test approved that is not declared.
+//
+// Only the row's own batch test makes drums available, the drums approved earlier already are. A row without a
+// batch test report date produces no available stock.
+func availableDrums(row CSVRow, rowIndex int, approvedBefore map[int]bool) ([]int, []Error) {
+	var errors []Error
+
+	var rowApproved []int
+	if row.BatchTestReportDate != "" {
+		rowApproved = row.ApprovedDrumNumbers
+	}
+	available := removeDuplicateDrumNumbers(rowApproved, row.SampleDrumNo)
+	available = removeDuplicateDrumNumbers(available, row.BufferDrumNo)
+
+	isDeclared := drumSet(row.AvailableDrumNos)
+	isApproved := drumSet(rowApproved)
+	for drumNo, approved := range approvedBefore {
+		if approved {
+			isApproved[drumNo] = true
+		}
+	}
+	isSample := drumSet(row.SampleDrumNo)
+	isBuffer := drumSet(row.BufferDrumNo)
+
+	drumNumbers := distinctDrumNumbers(append(append([]int{}, available...), row.AvailableDrumNos...))
+	sort.Ints(drumNumbers)
+	for _, drumNo := range drumNumbers {
+		var reason string
+		switch {
+		case !isDeclared[drumNo]:
+			reason = "is approved but not declared available"
+		case isSample[drumNo]:
+			reason = "is declared available but is a sample drum"
+		case isBuffer[drumNo]:
+			reason = "is declared available but is a buffer drum"
+		case !isApproved[drumNo]:
+			reason = "is declared available but is not approved"
+		default:
+			continue
+		}
+		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("drum number %d of drum size %d %s", drumNo, row.DrumSize, reason)})
+	}
+
+	return available, errors
+}
+
+func drumSet(drumNumbers []int) map[int]bool {
+	set := make(map[int]bool, len(drumNumbers))
+	for _, drumNo := range drumNumbers {
+		set[drumNo] = true
+	}
+	return set
+}
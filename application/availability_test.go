@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_availableDrums(t *testing.T) {
+	tests := []struct {
+		name           string
+		noReport       bool
+		declared       []int
+		approved       []int
+		approvedBefore []int
+		buffer         []int
+		sample         []int
+		want           []int
+		wantErrors     []string
+	}{
+		{name: "declared as derived", declared: []int{1, 2}, approved: []int{1, 2, 3, 4}, buffer: []int{3}, sample: []int{4}, want: []int{1, 2}},
+		{name: "nothing available", declared: []int{}, approved: []int{3}, buffer: []int{3}, want: []int{}},
+		{
+			name:       "sample drum declared available",
+			declared:   []int{1, 4},
+			approved:   []int{1, 4},
+			sample:     []int{4},
+			want:       []int{1},
+			wantErrors: []string{"drum number 4 of drum size 250 is declared available but is a sample drum"},
+		},
+		{
+			name:       "buffer drum declared available",
+			declared:   []int{1, 3},
+			approved:   []int{1, 3},
+			buffer:     []int{3},
+			want:       []int{1},
+			wantErrors: []string{"drum number 3 of drum size 250 is declared available but is a buffer drum"},
+		},
+		{
+			name:       "drum declared available but not approved",
+			declared:   []int{1, 5},
+			approved:   []int{1},
+			want:       []int{1},
+			wantErrors: []string{"drum number 5 of drum size 250 is declared available but is not approved"},
+		},
+		{
+			name:       "approved drum not declared",
+			declared:   []int{1},
+			approved:   []int{1, 2, 6},
+			want:       []int{1, 2, 6},
+			wantErrors: []string{"drum number 2 of drum size 250 is approved but not declared available", "drum number 6 of drum size 250 is approved but not declared available"},
+		},
+		{name: "drum approved earlier declared again", declared: []int{1, 7}, approved: []int{1}, approvedBefore: []int{7}, want: []int{1}},
+		{
+			name:       "no batch test report",
+			noReport:   true,
+			declared:   []int{1},
+			approved:   []int{1},
+			want:       []int{},
+			wantErrors: []string{"drum number 1 of drum size 250 is declared available but is not approved"},
+		},
+		{name: "no batch test report, drum approved earlier", noReport: true, declared: []int{1}, approved: []int{1}, approvedBefore: []int{1}, want: []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := CSVRow{DrumSize: 250, AvailableDrumNos: tt.declared, ApprovedDrumNumbers: tt.approved, BufferDrumNo: tt.buffer, SampleDrumNo: tt.sample, BatchTestReportDate: "01-03-2024"}
+			if tt.noReport {
+				row.BatchTestReportDate = ""
+			}
+
+			got, errs := availableDrums(row, 0, drumSet(tt.approvedBefore))
+			assert.Equal(t, tt.want, got)
+
+			var messages []string
+			for _, e := range errs {
+				assert.Equal(t, 1, e.RowNo)
+				messages = append(messages, e.Err.Error())
+			}
+			assert.Equal(t, tt.wantErrors, messages)
+		})
+	}
+}
+
+func TestProcessRows_AvailableDrumsOfNewAndExistingPartitions(t *testing.T) {
+	first := approvalTestRow("01-03-2024", "APPROVED", []int{1, 2})
+	first.AvailableDrumNos = []int{1}
+	second := approvalTestRow("01-04-2024", "APPROVED", []int{3, 4})
+	second.AvailableDrumNos = []int{3}
+
+	got, errs := processRows([]CSVRow{first, second})
+
+	dp := got.Contracts[0].LIs[0].Batches[0].DrumPartitions[0]
+	assert.Equal(t, []int{1, 2, 3, 4}, dp.AvailableDrumNumbers)
+	assert.Equal(t, 1000, dp.AvailableQuantity)
+	assert.Equal(t, []Error{
+		{RowNo: 1, Err: fmt.Errorf("drum number 2 of drum size 250 is approved but not declared available")},
+		{RowNo: 2, Err: fmt.Errorf("drum number 4 of drum size 250 is approved but not declared available")},
+	}, errs)
+}
+
+func TestProcessRows_DeclaredDrumRejectedEarlier(t *testing.T) {
+	approved := approvalTestRow("01-03-2024", "APPROVED", []int{1, 2})
+	rejected := approvalTestRow("01-04-2024", "REJECTED", []int{2})
+	undated := approvalTestRow("", "", []int{2})
+
+	got, errs := processRows([]CSVRow{undated, approved, rejected})
+
+	dp := got.Contracts[0].LIs[0].Batches[0].DrumPartitions[0]
+	assert.Equal(t, []int{1}, dp.AvailableDrumNumbers)
+	assert.Equal(t, 250, dp.AvailableQuantity)
+	assert.Equal(t, []Error{
+		{RowNo: 1, Err: fmt.Errorf("drum number 2 of drum size 250 is declared available but is not approved")},
+	}, errs)
+}
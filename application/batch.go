@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// fileResult is the outcome of converting one vendor file in batch mode.
+type fileResult struct {
+	Path       string
+	OutputPath string
+	ReportPath string
+	Records    UploadInventoryInput
+	Errors     []Error
+	Warnings   []Error
+	// BufferFindings are the LIs and batches whose buffer is out of the policies the batch is checked against
+	BufferFindings []bufferFinding
+}
+
+// batchSummary sums up a batch run: each file's outcome and the problems found across files.
+type batchSummary struct {
+	Files           []fileResult
+	CrossFileErrors []Error
+}
+
+// resolveBatchInputs lists the CSV files of a directory, or the files matching a glob, in name order.
+func resolveBatchInputs(input string) ([]string, error) {
+	pattern := input
+	if info, err := os.Stat(input); err == nil && info.IsDir() {
+		pattern = filepath.Join(input, "*.csv")
+	}
+
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid batch input %s: %w", input, err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no CSV files found for %s", input)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// runBatch converts every file of the batch input into outDir with a pool of workers, checking the buffer of each
+// against the policies, then checks the drums approved in more than one file.
+func runBatch(input, outDir string, workers int, parse parseOptions, output outputOptions, policies []bufferPolicy) (batchSummary, error) {
+	paths, err := resolveBatchInputs(input)
+	if err != nil {
+		return batchSummary{}, err
+	}
+	names, err := outputNames(paths)
+	if err != nil {
+		return batchSummary{}, err
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return batchSummary{}, err
+	}
+
+	outputs := make(map[string]string, len(paths))
+	for i, path := range paths {
+		outputs[path] = names[i]
+	}
+	results := convertFiles(paths, workers, func(path string) fileResult {
+		return convertFile(path, outputs[path], outDir, parse, output, policies)
+	})
+
+	return batchSummary{
+		Files:           results,
+		CrossFileErrors: validateOverlappingDrumNumbersAcrossFiles(results, parse.overlapScope),
+	}, nil
+}
+
+// outputNames names the output of each path after its file name, without its extension. Files of the same name in
+// different directories are named after their directory too, with its separators replaced by underscores.
+func outputNames(paths []string) ([]string, error) {
+	count := make(map[string]int, len(paths))
+	for _, path := range paths {
+		count[filepath.Base(path)]++
+	}
+
+	names := make([]string, len(paths))
+	taken := make(map[string]string, len(paths))
+	for i, path := range paths {
+		name := strings.TrimSuffix(path, filepath.Ext(path))
+		if count[filepath.Base(path)] > 1 {
+			name = strings.NewReplacer("/", "_", ":", "_").Replace(filepath.ToSlash(filepath.Clean(name)))
+			name = strings.TrimLeft(name, "_")
+		} else {
+			name = filepath.Base(name)
+		}
+		if other, ok := taken[name]; ok {
+			return nil, fmt.Errorf("batch files %s and %s would both be written to %s", other, path, name+".json")
+		}
+		taken[name] = path
+		names[i] = name
+	}
+	return names, nil
+}
+
+// convertFiles runs convert on each path with at most workers files at once. Results are in the order of paths.
+func convertFiles(paths []string, workers int, convert func(path string) fileResult) []fileResult {
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]fileResult, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = convert(paths[i])
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// convertFile converts one vendor file to <name>.json in outDir and writes its errors, then its warnings, then its
+// buffer findings, to <name>.report.txt.
+func convertFile(path, name, outDir string, parse parseOptions, output outputOptions, policies []bufferPolicy) fileResult {
+	result := fileResult{
+		Path:       path,
+		OutputPath: filepath.Join(outDir, name+".json"),
+		ReportPath: filepath.Join(outDir, name+".report.txt"),
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		result.Errors = append(result.Errors, Error{RowNo: 0, Err: err})
+	} else {
+		records, errorSlice := parseNamedCSV(path, file, parse)
+		file.Close()
+		result.Records = records
+		result.Errors = append(result.Errors, errorSlice...)
+		result.Warnings = append(records.reconcileRemarks(), records.unlistedUnapprovedDrums()...)
+		result.BufferFindings = evaluateBufferPolicies(records, policies)
+
+		jsonData, err := marshalRecords(records, output)
+		if err != nil {
+			result.Errors = append(result.Errors, Error{RowNo: 0, Err: err})
+		} else if err := os.WriteFile(result.OutputPath, jsonData, 0644); err != nil {
+			result.Errors = append(result.Errors, Error{RowNo: 0, Err: err})
+		}
+	}
+
+	var report strings.Builder
+	for _, e := range result.Errors {
+		fmt.Fprintf(&report, "Row %d: %s\n", e.RowNo, e.Err)
+	}
+	for _, w := range result.Warnings {
+		fmt.Fprintf(&report, "Warning: Row %d: %s\n", w.RowNo, w.Err)
+	}
+	for _, finding := range result.BufferFindings {
+		fmt.Fprintf(&report, "Buffer: %s\n", finding)
+	}
+	if err := os.WriteFile(result.ReportPath, []byte(report.String()), 0644); err != nil {
+		result.Errors = append(result.Errors, Error{RowNo: 0, Err: err})
+	}
+
+	return result
+}
+
+// validateOverlappingDrumNumbersAcrossFiles looks for drum numbers approved in more than one file within a group of
+// the scope. Overlaps within a file are left to validateDrumOverlaps.
+func validateOverlappingDrumNumbersAcrossFiles(results []fileResult, scope string) []Error {
+	claims := make(map[int][]drumClaim)
+	for _, result := range results {
+		for drumNo, fileClaims := range result.Records.collectDrumClaims() {
+			for _, claim := range fileClaims {
+				claim.File = result.Path
+				claims[drumNo] = append(claims[drumNo], claim)
+			}
+		}
+	}
+
+	return drumOverlaps(claims, scope, func(claims []drumClaim) bool {
+		for _, claim := range claims[1:] {
+			if claim.File != claims[0].File {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// String formats the summary as a short plain text report.
+func (s batchSummary) String() string {
+	var sb strings.Builder
+	var totalErrors, totalWarnings, totalContracts int
+	for _, result := range s.Files {
+		totalErrors += len(result.Errors)
+		totalWarnings += len(result.Warnings)
+		totalContracts += len(result.Records.Contracts)
+		fmt.Fprintf(&sb, "%s: %d contracts, %d errors, %d warnings -> %s\n", result.Path, len(result.Records.Contracts), len(result.Errors), len(result.Warnings), result.OutputPath)
+	}
+	fmt.Fprintf(&sb, "files: %d, contracts: %d, errors: %d, warnings: %d, cross-file overlaps: %d\n", len(s.Files), totalContracts, totalErrors, totalWarnings, len(s.CrossFileErrors))
+	for _, e := range s.CrossFileErrors {
+		fmt.Fprintf(&sb, "  %s\n", e.Err)
+	}
+	return sb.String()
+}
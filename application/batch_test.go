@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeBatchFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+func Test_resolveBatchInputs(t *testing.T) {
+	dir := writeBatchFiles(t, map[string]string{"b.csv": "", "a.csv": "", "notes.txt": ""})
+
+	got, err := resolveBatchInputs(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "a.csv"), filepath.Join(dir, "b.csv")}, got)
+
+	got, err = resolveBatchInputs(filepath.Join(dir, "b*"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "b.csv")}, got)
+
+	_, err = resolveBatchInputs(filepath.Join(dir, "*.json"))
+	assert.Error(t, err)
+}
+
+func Test_runBatch(t *testing.T) {
+	// the second month repeats the first 10 rows, so their drums are approved in both files
+	lines := strings.SplitAfter(generateCSV(20), "\n")
+	dir := writeBatchFiles(t, map[string]string{
+		"2024-01.csv": strings.Join(lines[:21], ""),
+		"2024-02.csv": strings.Join(lines[:11], ""),
+		"2024-03.csv": benchmarkCSVHeader,
+	})
+	outDir := filepath.Join(t.TempDir(), "out")
+
+	summary, err := runBatch(dir, outDir, 2, defaultParseOptions(), outputOptions{}, nil)
+	assert.NoError(t, err)
+
+	assert.Len(t, summary.Files, 3)
+	for _, result := range summary.Files {
+		assert.Empty(t, result.Errors, result.Path)
+		assert.FileExists(t, result.OutputPath)
+		assert.FileExists(t, result.ReportPath)
+	}
+	assert.Equal(t, filepath.Join(outDir, "2024-01.json"), summary.Files[0].OutputPath)
+
+	// each repeated row approves drums 3i+1 and 3i+2 and 3i+3
+	assert.Len(t, summary.CrossFileErrors, 30)
+	assert.Equal(t, "overlapping drum numbers found for contract 9190369 material code 101642: drum 1 claimed by "+
+		"contract 9190369 LI Li-1 batch 1/50 drum size 250 approved 2024-12-30 ("+filepath.Join(dir, "2024-01.csv")+"); "+
+		"contract 9190369 LI Li-1 batch 1/50 drum size 250 approved 2024-12-30 ("+filepath.Join(dir, "2024-02.csv")+")",
+		summary.CrossFileErrors[0].Err.Error())
+	assert.Contains(t, summary.String(), "files: 3, contracts: 2, errors: 0, warnings: 0, cross-file overlaps: 30")
+
+	// with provenance, the claims cite their rows; an LI scope still finds the same drums
+	parse := defaultParseOptions()
+	parse.overlapScope = overlapScopeLI
+	parse.withProvenance = true
+	summary, err = runBatch(dir, outDir, 2, parse, outputOptions{}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, summary.CrossFileErrors, 30)
+	assert.Contains(t, summary.CrossFileErrors[0].Err.Error(), "for contract 9190369 LI Li-1: drum 1 claimed by")
+	assert.Contains(t, summary.CrossFileErrors[0].Err.Error(), "("+filepath.Join(dir, "2024-02.csv")+" row 1)")
+
+	// the buffer of each file is checked against the policies
+	summary, err = runBatch(dir, outDir, 2, defaultParseOptions(), outputOptions{}, []bufferPolicy{{PercentOfQuantity: 50}})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, summary.Files[0].BufferFindings)
+	assert.Empty(t, summary.Files[2].BufferFindings)
+	report, err := os.ReadFile(summary.Files[0].ReportPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(report), "Buffer: "+summary.Files[0].BufferFindings[0].Error()+"\n")
+}
+
+func Test_outputNames(t *testing.T) {
+	got, err := outputNames([]string{"in/2024-01.csv", "a/b/2024-02.csv", "c/2024-02.csv"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2024-01", "a_b_2024-02", "c_2024-02"}, got)
+
+	_, err = outputNames([]string{"a_b/2024-02.csv", "a/b/2024-02.csv"})
+	assert.EqualError(t, err, "batch files a_b/2024-02.csv and a/b/2024-02.csv would both be written to a_b_2024-02.json")
+
+	_, err = outputNames([]string{"a/b.csv", "a/b.txt"})
+	assert.EqualError(t, err, "batch files a/b.csv and a/b.txt would both be written to b.json")
+}
+
+func Test_convertFiles_KeepsOrder(t *testing.T) {
+	paths := []string{"a", "b", "c", "d", "e"}
+	got := convertFiles(paths, 3, func(path string) fileResult {
+		return fileResult{Path: path}
+	})
+
+	var gotPaths []string
+	for _, result := range got {
+		gotPaths = append(gotPaths, result.Path)
+	}
+	assert.Equal(t, paths, gotPaths)
+}
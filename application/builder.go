@@ -0,0 +1,302 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// inventoryBuilder builds the inventory from rows grouped by contract, LI, batch and drum size, so the inventory and
+// its errors do not depend on the order of the rows. Contracts, LIs, batches, drum partitions and batch test
+// approvals are indexed by their keys as they are created, so placing a row costs the same whatever the number of
+// rows already placed.
+type inventoryBuilder struct {
+	res    UploadInventoryInput
+	errors []Error
+	index  inventoryIndex
+
+	// rows added and not placed yet, the held rows in memory and the rest in sorted runs on disk
+	held        []pendingRow
+	runs        []*os.File
+	maxHeldRows int // 0 holds every row in memory
+
+	// with provenance, the file the rows are read from and the rows that listed each drum of a drum partition
+	file           string
+	withProvenance bool
+	drumRows       map[partitionKey]map[int][]RowSource
+}
+
+// inventoryIndex maps the keys of a row to the position of its contract, LI, batch, drum partition and batch test
+// approval in their parent's slice.
+type inventoryIndex struct {
+	contracts  map[string]int
+	lis        map[liKey]int
+	batches    map[batchKey]int
+	partitions map[partitionKey]int
+	approvals  map[approvalKey]int
+}
+
+type liKey struct {
+	contractNo string
+	liCode     string
+	liNumber   string
+}
+
+type batchKey struct {
+	liKey
+	batchNo string
+}
+
+type partitionKey struct {
+	batchKey
+	drumSize int
+}
+
+// approvalKey keys a batch test approval by its date and outcome, rows of one date with different outcomes are
+// separate approvals.
+type approvalKey struct {
+	batchKey
+	approvalDate Date
+	status       string
+}
+
+func newInventoryBuilder() *inventoryBuilder {
+	return &inventoryBuilder{
+		maxHeldRows: defaultMaxHeldRows,
+		drumRows:    make(map[partitionKey]map[int][]RowSource),
+		index: inventoryIndex{
+			contracts:  make(map[string]int),
+			lis:        make(map[liKey]int),
+			batches:    make(map[batchKey]int),
+			partitions: make(map[partitionKey]int),
+			approvals:  make(map[approvalKey]int),
+		},
+	}
+}
+
+func liKeyOf(row CSVRow) liKey {
+	return liKey{contractNo: row.ContractNo, liCode: row.LIName.LICode, liNumber: row.LIName.LINumber}
+}
+
+func batchKeyOf(row CSVRow) batchKey {
+	return batchKey{liKey: liKeyOf(row), batchNo: row.BatchNo}
+}
+
+func partitionKeyOf(row CSVRow) partitionKey {
+	return partitionKey{batchKey: batchKeyOf(row), drumSize: row.DrumSize}
+}
+
+// indexBatch registers a new batch at batchIndex in its LI, along with its drum partitions and batch test approvals.
+func (b *inventoryBuilder) indexBatch(key batchKey, batchIndex int, batch Batch) {
+	b.index.batches[key] = batchIndex
+	for i, dp := range batch.DrumPartitions {
+		b.index.partitions[partitionKey{batchKey: key, drumSize: dp.DrumSize}] = i
+	}
+	for i, bta := range batch.BatchTestApprovals {
+		b.index.approvals[approvalKey{batchKey: key, approvalDate: bta.ApprovalDate, status: bta.Status}] = i
+	}
+}
+
+// putDrumPartition replaces the batch's drum partition for the key, or appends it when the batch has none yet.
+func (b *inventoryBuilder) putDrumPartition(batch *Batch, key partitionKey, dp DrumPartition) {
+	if i, ok := b.index.partitions[key]; ok {
+		batch.DrumPartitions[i] = dp
+		return
+	}
+	b.index.partitions[key] = len(batch.DrumPartitions)
+	batch.DrumPartitions = append(batch.DrumPartitions, dp)
+}
+
+// putBatchTestApproval replaces the batch's test approval for the key, or appends it when the batch has none yet.
+func (b *inventoryBuilder) putBatchTestApproval(batch *Batch, key approvalKey, bta BatchTestApproval) {
+	if i, ok := b.index.approvals[key]; ok {
+		batch.BatchTestApprovals[i] = bta
+		return
+	}
+	b.index.approvals[key] = len(batch.BatchTestApprovals)
+	batch.BatchTestApprovals = append(batch.BatchTestApprovals, bta)
+}
+
+// pendingRow is a row waiting to be placed, with its position in the input and the batch test report date it is
+// ordered by within its drum partition.
+type pendingRow struct {
+	row          CSVRow
+	rowIndex     int
+	approvalDate Date
+}
+
+// addRow adds a row to the drum partition it belongs to, it is placed once every row is known. Once maxHeldRows rows
+// are held, they are sorted into a run on disk, so memory use does not grow with the number of rows. A row with a
+// date that does not parse is reported and not placed, it has no date to be placed by.
+func (b *inventoryBuilder) addRow(row CSVRow, rowIndex int) {
+	// rows without a unit are in metres
+	if row.Unit == "" {
+		row.Unit = defaultUnit
+	}
+
+	approvalDate, errors := parseRowDates(row, rowIndex)
+	if len(errors) > 0 {
+		b.errors = append(b.errors, errors...)
+		return
+	}
+
+	b.held = append(b.held, pendingRow{row: row, rowIndex: rowIndex, approvalDate: approvalDate})
+	if b.maxHeldRows > 0 && len(b.held) >= b.maxHeldRows {
+		b.spill()
+	}
+}
+
+// spill sorts the held rows into a run on disk. When the run cannot be written the rows stay in memory, and so do the
+// rows added after them.
+func (b *inventoryBuilder) spill() {
+	sortPendingRows(b.held)
+	run, err := writeRun(b.held)
+	if err != nil {
+		b.errors = append(b.errors, Error{RowNo: 0, Err: fmt.Errorf("failed to hold rows on disk, they are held in memory: %w", err)})
+		b.maxHeldRows = 0
+		return
+	}
+	b.runs = append(b.runs, run)
+	b.held = b.held[:0]
+}
+
+// parseRowDates parses the dates of a row and returns its batch test report date, the zero date when it has none.
+func parseRowDates(row CSVRow, rowIndex int) (Date, []Error) {
+	var errors []Error
+	if _, err := parseDate(row.LIDate); err != nil {
+		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("failed to parse LI date: %w", err)})
+	}
+	if _, err := parseDate(row.BatchDueDate); err != nil {
+		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("failed to parse batch due date: %w", err)})
+	}
+	if row.BatchTestReportDate == "" {
+		return Date{}, errors
+	}
+	approvalDate, err := parseDate(row.BatchTestReportDate)
+	if err != nil {
+		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("failed to parse batch test report date: %w", err)})
+	}
+	return approvalDate, errors
+}
+
+// placeRows places the added rows grouped by contract, LI, batch and drum size, by batch test report date within a
+// drum size and the rows without one last. Rows of one group and date are ordered by their content, so the first row
+// of a contract, LI or batch, whose values the other rows are checked against, is the same whatever the order of the
+// input. The runs on disk are merged with the held rows, one row of each at a time.
+func (b *inventoryBuilder) placeRows() {
+	sortPendingRows(b.held)
+	runs := []rowRun{&heldRun{rows: b.held}}
+	for _, run := range b.runs {
+		defer removeRun(run)
+		runs = append(runs, newFileRun(run))
+	}
+
+	err := mergeRuns(runs, func(pending pendingRow) {
+		if !b.withProvenance {
+			b.placeRow(pending.row, pending.rowIndex)
+			return
+		}
+		key := partitionKeyOf(pending.row)
+		source := sourceOf(b.file, pending.rowIndex, listedDrums(pending.row))
+		b.citeDrumConflicts(key, source, pending.row)
+		b.placeRow(pending.row, pending.rowIndex)
+		b.recordSource(key, pending.row, pending.rowIndex)
+	})
+	if err != nil {
+		b.errors = append(b.errors, Error{RowNo: 0, Err: fmt.Errorf("failed to read back the rows held on disk, the rows not read are not placed: %w", err)})
+	}
+	b.held, b.runs = nil, nil
+
+	sort.SliceStable(b.errors, func(i, j int) bool {
+		return b.errors[i].RowNo < b.errors[j].RowNo
+	})
+}
+
+func comparePartitionKeys(a, b partitionKey) int {
+	if c := compareNumericStrings(a.contractNo, b.contractNo); c != 0 {
+		return c
+	}
+	if c := compareNumericStrings(a.liCode, b.liCode); c != 0 {
+		return c
+	}
+	if c := compareNumericStrings(a.liNumber, b.liNumber); c != 0 {
+		return c
+	}
+	if c := compareBatchNo(a.batchNo, b.batchNo); c != 0 {
+		return c
+	}
+	if a.drumSize != b.drumSize {
+		return a.drumSize - b.drumSize
+	}
+	// keys that are only equal as numbers, such as LI numbers "01" and "1"
+	for _, c := range [...]int{
+		strings.Compare(a.contractNo, b.contractNo),
+		strings.Compare(a.liCode, b.liCode),
+		strings.Compare(a.liNumber, b.liNumber),
+		strings.Compare(a.batchNo, b.batchNo),
+	} {
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareRowContent orders rows of one drum partition and report date by the values the first row of a contract, LI
+// or batch sets and the other rows are checked against, then by their drums, quantities and remarks.
+func compareRowContent(a, b CSVRow) int {
+	for _, c := range [...]int{
+		strings.Compare(a.Vendor, b.Vendor),
+		strings.Compare(a.MaterialCode, b.MaterialCode),
+		strings.Compare(a.MaterialDesc, b.MaterialDesc),
+		strings.Compare(a.LIDate, b.LIDate),
+		strings.Compare(a.BatchDueDate, b.BatchDueDate),
+		strings.Compare(a.Unit, b.Unit),
+		a.LIQuantity - b.LIQuantity,
+		strings.Compare(a.BatchTestStatus, b.BatchTestStatus),
+		a.TotalNoOfDrums - b.TotalNoOfDrums,
+		a.TotalQty - b.TotalQty,
+		compareInts(a.AvailableDrumNos, b.AvailableDrumNos),
+		compareInts(a.BufferDrumNo, b.BufferDrumNo),
+		compareInts(a.SampleDrumNo, b.SampleDrumNo),
+		compareInts(a.ApprovedDrumNumbers, b.ApprovedDrumNumbers),
+		compareInts(a.DrumNos, b.DrumNos),
+		compareFloats(a.SampleLength, b.SampleLength),
+		compareFloats(a.ShortLengths, b.ShortLengths),
+		strings.Compare(a.Remarks, b.Remarks),
+		strings.Compare(a.ApprovalComment, b.ApprovalComment),
+		strings.Compare(a.BatchTestReportFileName, b.BatchTestReportFileName),
+	} {
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareInts compares two lists of numbers element by element, a shorter list first when one starts the other.
+func compareInts(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
+
+// compareFloats compares two lists of lengths like compareInts.
+func compareFloats(a, b []float64) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const benchmarkCSVHeader = "Vendor,Material ,Description,Contract,PO Number,PO line item,Li No,LI Date,Batch No.,Batch Due date,Drum Size,Total nos. of Drum,Available Drum Nos.,Available Full Drums,Full  Drum Total Quantity,Buffer Drum No.,Buffer  No. of Drum ,Buffer Quantity,Sample Drum (Yes/No),Sample Drum No.,Sample Length (m),No of Short length Drums,Short Length total Quantity,Batch Test Report Date,Remarks ,Batch Test Report File Name\n"
+
+// generateCSV generates a vendor file of n valid rows with distinct drum numbers, 20 LIs per contract and 50 batches per LI.
+func generateCSV(n int) string {
+	var sb strings.Builder
+	sb.WriteString(benchmarkCSVHeader)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "ABC,%d,22kV 3C/300mm2 CU/XLPE/DSTA/PVC Cable,%d,,,Li - %d,27-03-2021,%d/50,27-03-2025,250,3,%d,1,250,%d,1,250,yes,%d,2.5,1,247.5,30-12-2024,Partial,Test_report_B.pdf\n",
+			101642+i/50, 9190369+i/1000, i/50%20+1, i%50+1, 3*i+1, 3*i+2, 3*i+3)
+	}
+	return sb.String()
+}
+
+func Test_parseCSV_Streaming(t *testing.T) {
+	input := generateCSV(1500)
+
+	got, errs := parseCSV(strings.NewReader(input))
+	assert.Empty(t, errs)
+	assert.Len(t, got.Contracts, 2)
+	assert.Len(t, got.Contracts[0].LIs, 20)
+	assert.Len(t, got.Contracts[1].LIs, 10)
+	assert.Len(t, got.Contracts[0].LIs[0].Batches, 50)
+}
+
+func Test_parseCSV_MalformedRow(t *testing.T) {
+	lines := strings.Split(generateCSV(3), "\n")
+	lines[2] = "ABC,101642"
+
+	got, errs := parseCSV(strings.NewReader(strings.Join(lines, "\n")))
+	assert.Len(t, errs, 1)
+	assert.Equal(t, 2, errs[0].RowNo)
+	assert.Len(t, got.Contracts[0].LIs[0].Batches, 2)
+}
+
+// TestProcessRows_OrderIndependent shuffles the rows and expects the same inventory and the same errors for the
+// same rows.
+func TestProcessRows_OrderIndependent(t *testing.T) {
+	buffer := approvalTestRow("01-03-2024", "APPROVED", []int{1, 2})
+	buffer.Remarks = "Buffer"
+	partial := approvalTestRow("01-03-2024", "APPROVED", []int{3})
+	partial.Remarks = "Partial"
+	otherSize := approvalTestRow("01-03-2024", "APPROVED", []int{4})
+	otherSize.DrumSize = 500
+	otherSize.TotalQty = 500
+	otherSize.FullDrumTotalQuantity = 500
+	rejected := approvalTestRow("01-04-2024", "REJECTED", []int{5})
+	rejectedSameDay := approvalTestRow("01-03-2024", "REJECTED", []int{8})
+	noReport := approvalTestRow("", "", []int{6})
+	noReport.BatchNo = "2/2"
+	otherHosDate := approvalTestRow("01-03-2024", "APPROVED", []int{7})
+	otherHosDate.BatchNo = "2/2"
+	otherHosDate.LIDate = "02-01-2024"
+	secondLI := approvalTestRow("01-03-2024", "APPROVED", []int{1})
+	secondLI.LIName = LIName{LICode: "LI002", LINumber: "1"}
+	otherMaterial := secondLI
+	otherMaterial.AvailableDrumNos = []int{2}
+	otherMaterial.ApprovedDrumNumbers = []int{2}
+	otherMaterial.MaterialCode = "MAT200"
+	secondContract := approvalTestRow("01-03-2024", "APPROVED", []int{1})
+	secondContract.ContractNo = "C124"
+	otherVendor := secondContract
+	otherVendor.AvailableDrumNos = []int{2}
+	otherVendor.ApprovedDrumNumbers = []int{2}
+	otherVendor.Vendor = "Supplier B"
+
+	rows := []CSVRow{buffer, partial, otherSize, rejected, rejectedSameDay, noReport, otherHosDate, secondLI, otherMaterial, secondContract, otherVendor}
+	want, wantErrs := processRows(rows)
+	assert.Len(t, wantErrs, 4)
+
+	// errors by the original row they were raised for
+	errorsOf := func(errs []Error, rowOf func(int) int) []string {
+		messages := make([]string, 0, len(errs))
+		for _, e := range errs {
+			messages = append(messages, fmt.Sprintf("row %d: %s", rowOf(e.RowNo), e.Err))
+		}
+		return messages
+	}
+	identity := func(rowNo int) int { return rowNo }
+
+	for seed := int64(1); seed <= 20; seed++ {
+		perm := rand.New(rand.NewSource(seed)).Perm(len(rows))
+		shuffled := make([]CSVRow, len(rows))
+		for i, j := range perm {
+			shuffled[i] = rows[j]
+		}
+
+		got, gotErrs := processRows(shuffled)
+		assert.Equal(t, want, got, "seed %d", seed)
+		assert.ElementsMatch(t, errorsOf(wantErrs, identity), errorsOf(gotErrs, func(rowNo int) int { return perm[rowNo-1] + 1 }), "seed %d", seed)
+
+		// the same rows sorted in runs on disk
+		got, gotErrs = processRowsHolding(shuffled, 4)
+		assert.Equal(t, want, got, "seed %d", seed)
+		assert.ElementsMatch(t, errorsOf(wantErrs, identity), errorsOf(gotErrs, func(rowNo int) int { return perm[rowNo-1] + 1 }), "seed %d", seed)
+	}
+}
+
+// processRowsHolding processes rows holding at most maxHeldRows of them in memory.
+func processRowsHolding(rows []CSVRow, maxHeldRows int) (UploadInventoryInput, []Error) {
+	builder := newInventoryBuilder()
+	builder.maxHeldRows = maxHeldRows
+	for rowIndex, row := range rows {
+		builder.addRow(row, rowIndex)
+	}
+	return builder.finish()
+}
+
+func Test_inventoryBuilder_RunsOnDisk(t *testing.T) {
+	rows := make([]CSVRow, 25)
+	for i := range rows {
+		rows[i] = approvalTestRow("01-03-2024", "APPROVED", []int{2*i + 1, 2*i + 2})
+		rows[i].BatchNo = fmt.Sprintf("%d/50", 25-i)
+	}
+	rows[3].Remarks = "Buffer"
+	rows[4].AvailableDrumNos = []int{}
+
+	builder := newInventoryBuilder()
+	builder.maxHeldRows = 10
+	for rowIndex, row := range rows {
+		builder.addRow(row, rowIndex)
+		assert.Less(t, len(builder.held), 10)
+	}
+	assert.Len(t, builder.runs, 2)
+	runNames := []string{builder.runs[0].Name(), builder.runs[1].Name()}
+
+	got, errs := builder.finish()
+	want, wantErrs := processRows(rows)
+	assert.Equal(t, want, got)
+	assert.Equal(t, wantErrs, errs)
+
+	for _, name := range runNames {
+		assert.NoFileExists(t, name)
+	}
+}
+
+func benchmarkSizes() []int {
+	return []int{1000, 10000, 100000}
+}
+
+func BenchmarkParseCSV(b *testing.B) {
+	for _, n := range benchmarkSizes() {
+		input := generateCSV(n)
+		b.Run(fmt.Sprintf("rows=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				parseCSV(strings.NewReader(input))
+			}
+		})
+	}
+}
+
+func BenchmarkProcessRows(b *testing.B) {
+	for _, n := range benchmarkSizes() {
+		rows := make([]CSVRow, n)
+		for i := range rows {
+			rows[i] = approvalTestRow("01-03-2024", "APPROVED", []int{1, 2})
+			rows[i].ContractNo = fmt.Sprint(9190369 + i/1000)
+			rows[i].LIName.LINumber = fmt.Sprint(i/50%20 + 1)
+			rows[i].BatchNo = fmt.Sprintf("%d/50", i%50+1)
+		}
+		b.Run(fmt.Sprintf("rows=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				processRows(rows)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Date layouts. Vendor files carry dates as dd-mm-yyyy, the output uses ISO-8601 unless the legacy layout is asked for.
+const (
+	legacyDateLayout = "02-01-2006"
+	isoDateLayout    = "2006-01-02"
+)
+
+// Date is a calendar date without a time of day. It is written in its layout, ISO-8601 when it has none.
+type Date struct {
+	time.Time
+	layout string
+}
+
+// parseDate reads a date in the vendor's dd-mm-yyyy layout or in ISO-8601.
+func parseDate(str string) (Date, error) {
+	str = strings.TrimSpace(str)
+	for _, layout := range []string{legacyDateLayout, isoDateLayout} {
+		if t, err := time.Parse(layout, str); err == nil {
+			return Date{Time: t}, nil
+		}
+	}
+	return Date{}, fmt.Errorf("invalid date: %q", str)
+}
+
+// parseLegacyDate reads a date in the vendor's dd-mm-yyyy layout only, as required in CSV rows.
+func parseLegacyDate(str string) (Date, bool) {
+	t, err := time.Parse(legacyDateLayout, str)
+	if err != nil {
+		return Date{}, false
+	}
+	return Date{Time: t}, true
+}
+
+// mustParseDate parses a date the inventory builder has already parsed, see inventoryBuilder.addRow.
+func mustParseDate(str string) Date {
+	date, err := parseDate(str)
+	if err != nil {
+		panic(err)
+	}
+	return date
+}
+
+// String formats the date as ISO-8601, the zero date is empty.
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(isoDateLayout)
+}
+
+// Before reports whether d is an earlier day than other.
+func (d Date) Before(other Date) bool {
+	return d.Time.Before(other.Time)
+}
+
+// After reports whether d is a later day than other.
+func (d Date) After(other Date) bool {
+	return d.Time.After(other.Time)
+}
+
+// Equal reports whether d and other are the same day.
+func (d Date) Equal(other Date) bool {
+	return d.Time.Equal(other.Time)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() || d.layout == "" {
+		return json.Marshal(d.String())
+	}
+	return json.Marshal(d.Format(d.layout))
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	if str == "" {
+		*d = Date{}
+		return nil
+	}
+	date, err := parseDate(str)
+	if err != nil {
+		return err
+	}
+	*d = date
+	return nil
+}
+
+// parseDateLayout selects the output date layout by name, "iso" or "legacy".
+func parseDateLayout(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case "iso":
+		return isoDateLayout, nil
+	case "legacy":
+		return legacyDateLayout, nil
+	}
+	return "", fmt.Errorf("unknown date format: %s", name)
+}
+
+// withDateLayout returns the records with every date written in the layout. The records passed in are not changed.
+func withDateLayout(records UploadInventoryInput, layout string) UploadInventoryInput {
+	contracts := make([]Contracts, len(records.Contracts))
+	for i, contract := range records.Contracts {
+		if contract.LIs != nil {
+			lis := make([]LI, len(contract.LIs))
+			for j, li := range contract.LIs {
+				li.HosApprovalDate.layout = layout
+				if li.Batches != nil {
+					batches := make([]Batch, len(li.Batches))
+					for k, batch := range li.Batches {
+						batch.SubmissionDate.layout = layout
+						if batch.BatchTestApprovals != nil {
+							approvals := make([]BatchTestApproval, len(batch.BatchTestApprovals))
+							for l, bta := range batch.BatchTestApprovals {
+								bta.ApprovalDate.layout = layout
+								approvals[l] = bta
+							}
+							batch.BatchTestApprovals = approvals
+						}
+						batches[k] = batch
+					}
+					li.Batches = batches
+				}
+				lis[j] = li
+			}
+			contract.LIs = lis
+		}
+		contracts[i] = contract
+	}
+	if records.Contracts != nil {
+		records.Contracts = contracts
+	}
+	return records
+}
+
+// dateOf is the date of a time, without time of day.
+func dateOf(t time.Time) Date {
+	y, m, d := t.Date()
+	return Date{Time: time.Date(y, m, d, 0, 0, 0, 0, time.UTC)}
+}
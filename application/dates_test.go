@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testDate parses a date for test fixtures, the zero date when it is empty.
+func testDate(str string) Date {
+	if str == "" {
+		return Date{}
+	}
+	date, err := parseDate(str)
+	if err != nil {
+		panic(err)
+	}
+	return date
+}
+
+func Test_parseDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		str     string
+		want    Date
+		wantErr bool
+	}{
+		{name: "legacy layout", str: "27-03-2025", want: Date{Time: time.Date(2025, 3, 27, 0, 0, 0, 0, time.UTC)}},
+		{name: "ISO-8601 layout", str: "2025-03-27", want: Date{Time: time.Date(2025, 3, 27, 0, 0, 0, 0, time.UTC)}},
+		{name: "invalid date", str: "31-02-2025", wantErr: true},
+		{name: "empty date", str: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDate(tt.str)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDate_MarshalJSON(t *testing.T) {
+	type dated struct {
+		Date Date `json:"date"`
+	}
+
+	got, err := json.Marshal(dated{Date: testDate("27-03-2025")})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"date":"2025-03-27"}`, string(got))
+
+	got, err = json.Marshal(dated{})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"date":""}`, string(got))
+}
+
+func Test_parseDateLayout(t *testing.T) {
+	layout, err := parseDateLayout("Legacy")
+	assert.NoError(t, err)
+	assert.Equal(t, legacyDateLayout, layout)
+
+	_, err = parseDateLayout("us")
+	assert.Error(t, err)
+}
+
+func Test_marshalRecords_LegacyDates(t *testing.T) {
+	records := UploadInventoryInput{Contracts: []Contracts{{ContractNo: "1", LIs: []LI{{
+		HosApprovalDate: testDate("01-01-2024"),
+		Batches: []Batch{{
+			BatchNo:            "1/2",
+			SubmissionDate:     testDate("27-03-2025"),
+			Remarks:            `"submission_date": "2025-03-27"`,
+			BatchTestApprovals: []BatchTestApproval{{ApprovalDate: testDate("30-12-2024")}},
+		}},
+	}}}}}
+
+	for _, options := range []outputOptions{{dateLayout: legacyDateLayout}, {canonical: true, dateLayout: legacyDateLayout}} {
+		output, err := marshalRecords(records, options)
+		assert.NoError(t, err)
+
+		var got UploadInventoryInput
+		assert.NoError(t, json.Unmarshal(output, &got))
+		li := got.Contracts[0].LIs[0]
+		assert.Equal(t, testDate("01-01-2024"), li.HosApprovalDate)
+		assert.Equal(t, testDate("27-03-2025"), li.Batches[0].SubmissionDate)
+		assert.Equal(t, testDate("30-12-2024"), li.Batches[0].BatchTestApprovals[0].ApprovalDate)
+		// text that only looks like a date field is left alone
+		assert.Equal(t, `"submission_date": "2025-03-27"`, li.Batches[0].Remarks)
+		assert.Contains(t, string(output), "27-03-2025")
+		assert.NotContains(t, string(output), "2024-12-30")
+	}
+
+	// the records passed in keep their ISO-8601 layout
+	output, err := marshalRecords(records, outputOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, string(output), `"submission_date": "2025-03-27"`)
+}
+
+func TestProcessRows_InvalidDateIsReported(t *testing.T) {
+	row := approvalTestRow("30-02-2024", "APPROVED", []int{1, 2})
+	row.LIDate = "2024-13-01"
+
+	got, errs := processRows([]CSVRow{row})
+	assert.Empty(t, got.Contracts)
+
+	var messages []string
+	for _, e := range errs {
+		assert.Equal(t, 1, e.RowNo)
+		messages = append(messages, e.Err.Error())
+	}
+	assert.Equal(t, []string{
+		`failed to parse LI date: invalid date: "2024-13-01"`,
+		`failed to parse batch test report date: invalid date: "30-02-2024"`,
+	}, messages)
+}
+
+func TestDate_UnmarshalJSON(t *testing.T) {
+	var got struct {
+		ISO    Date `json:"iso"`
+		Legacy Date `json:"legacy"`
+		Empty  Date `json:"empty"`
+	}
+	err := json.Unmarshal([]byte(`{"iso":"2025-03-27","legacy":"27-03-2025","empty":""}`), &got)
+	assert.NoError(t, err)
+	assert.Equal(t, testDate("27-03-2025"), got.ISO)
+	assert.Equal(t, testDate("27-03-2025"), got.Legacy)
+	assert.True(t, got.Empty.IsZero())
+
+	assert.Error(t, json.Unmarshal([]byte(`{"iso":"27/03/2025"}`), &got))
+}
+
+func TestCSVRow_validateRow_Chronology(t *testing.T) {
+	asOf := dateOf(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name                string
+		liDate              string
+		batchDueDate        string
+		batchTestReportDate string
+		want                []string
+	}{
+		{name: "in order", liDate: "01-01-2024", batchDueDate: "01-06-2024", batchTestReportDate: "01-03-2024"},
+		{name: "report on LI date", liDate: "01-01-2024", batchDueDate: "01-06-2024", batchTestReportDate: "01-01-2024"},
+		{name: "report on today", liDate: "01-01-2024", batchDueDate: "01-06-2024", batchTestReportDate: "01-01-2025"},
+		{
+			name: "report before LI date", liDate: "01-01-2024", batchDueDate: "01-06-2024", batchTestReportDate: "31-12-2023",
+			want: []string{"batch test report date must not be before LI date"},
+		},
+		{
+			name: "due date on LI date", liDate: "01-01-2024", batchDueDate: "01-01-2024",
+			want: []string{"batch due date must be after LI date"},
+		},
+		{
+			name: "future-dated report", liDate: "01-01-2024", batchDueDate: "01-06-2024", batchTestReportDate: "02-01-2025",
+			want: []string{"batch test report date is in the future"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := approvalTestRow(tt.batchTestReportDate, "APPROVED", []int{1, 2})
+			row.LIDate = tt.liDate
+			row.BatchDueDate = tt.batchDueDate
+
+			var got []string
+			for _, e := range append(row.validateRow(0), row.validateReportDate(0, asOf)...) {
+				got = append(got, e.Err.Error())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
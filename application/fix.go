@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// fixSuggestion is a deterministic correction of one cell of a vendor CSV.
+type fixSuggestion struct {
+	RowNo  int
+	Column string
+	Old    string
+	New    string
+	Reason string
+}
+
+// rowFix is a row of the vendor CSV before and after its corrections, with the lines of the file it spans.
+type rowFix struct {
+	RowNo       int
+	Old         []string
+	New         []string
+	Suggestions []fixSuggestion
+
+	Line    int    // line of the file the row starts on
+	OldText string // the row as written in the file, with its line ending
+	NewText string // the corrected row, with the same line ending
+}
+
+var liNamePattern = regexp.MustCompile(`^\s*([^\s-]+)\s*-\s*([^\s-]+)\s*$`)
+
+// suggestFixes proposes the corrections of a row that can be made without asking the vendor:
+//   - LI names written as "Li-1" or "Li -1" become "Li - 1"
+//   - the sample drum flag is written "Yes" or "No"
+//   - a buffer quantity that is not the buffer drums times the drum size is recomputed
+//   - a missing full drum total quantity is the available full drums times the drum size
+func suggestFixes(record []string, rowIndex int) rowFix {
+	fix := rowFix{RowNo: rowIndex + 1, Old: record, New: append([]string{}, record...)}
+	set := func(column int, name, value, reason string) {
+		if column >= len(fix.New) || fix.New[column] == value {
+			return
+		}
+		fix.Suggestions = append(fix.Suggestions, fixSuggestion{RowNo: rowIndex + 1, Column: name, Old: fix.New[column], New: value, Reason: reason})
+		fix.New[column] = value
+	}
+	cell := func(column int) string {
+		if column >= len(fix.New) {
+			return ""
+		}
+		return strings.TrimSpace(fix.New[column])
+	}
+
+	if parts := liNamePattern.FindStringSubmatch(cell(LINameColumnIndex)); parts != nil {
+		set(LINameColumnIndex, "Li No", parts[1]+" - "+parts[2], "LI name is written as code - number")
+	}
+
+	switch strings.ToLower(cell(SampleDrumColumnIndex)) {
+	case "yes", "y":
+		set(SampleDrumColumnIndex, "Sample Drum (Yes/No)", "Yes", "sample drum flag is Yes or No")
+	case "no", "n":
+		set(SampleDrumColumnIndex, "Sample Drum (Yes/No)", "No", "sample drum flag is Yes or No")
+	}
+
+	drumSize, err := strconv.Atoi(cell(DrumSizeColumnIndex))
+	if err != nil {
+		return fix
+	}
+
+	if bufferNoOfDrums, err := strconv.Atoi(cell(BufferNoOfDrumsColumnIndex)); err == nil {
+		if bufferQuantity, err := strconv.Atoi(cell(BufferQuantityColumnIndex)); err != nil || bufferQuantity != bufferNoOfDrums*drumSize {
+			set(BufferQuantityColumnIndex, "Buffer Quantity", strconv.Itoa(bufferNoOfDrums*drumSize), "buffer quantity is buffer no. of drums times drum size")
+		}
+	}
+
+	if cell(FullDrumTotalQuantityColumnIndex) == "" {
+		if availableFullDrums, err := strconv.Atoi(cell(AvailableFullDrumsColumnIndex)); err == nil {
+			set(FullDrumTotalQuantityColumnIndex, "Full Drum Total Quantity", strconv.Itoa(availableFullDrums*drumSize), "full drum total quantity is available full drums times drum size")
+		}
+	}
+
+	return fix
+}
+
+// fixCSV writes the vendor CSV with every suggested correction applied and returns the rows it changed. Rows
+// without corrections are written exactly as they were read.
+func fixCSV(input []byte, writer io.Writer) ([]rowFix, error) {
+	csvReader := csv.NewReader(bytes.NewReader(input))
+	csvReader.FieldsPerRecord = -1
+
+	if _, err := csvReader.Read(); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if _, err := writer.Write(input[:csvReader.InputOffset()]); err != nil {
+		return nil, err
+	}
+
+	var fixes []rowFix
+	for i := 0; ; i++ {
+		start := csvReader.InputOffset()
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fixes, fmt.Errorf("failed to read row %d: %w", i+1, err)
+		}
+		line, _ := csvReader.FieldPos(0)
+		text := string(input[start:csvReader.InputOffset()])
+
+		// blank lines before the row are kept as they are
+		for skipped := strings.Count(string(input[:start]), "\n") + 1; skipped < line; skipped++ {
+			blank := strings.Index(text, "\n") + 1
+			if _, err := io.WriteString(writer, text[:blank]); err != nil {
+				return fixes, err
+			}
+			text = text[blank:]
+		}
+
+		fix := suggestFixes(record, i)
+		fix.Line, fix.OldText, fix.NewText = line, text, text
+		if len(fix.Suggestions) > 0 {
+			fix.NewText = csvLine(fix.New, lineEnding(text))
+			fixes = append(fixes, fix)
+		}
+		if _, err := io.WriteString(writer, fix.NewText); err != nil {
+			return fixes, err
+		}
+	}
+	return fixes, nil
+}
+
+// lineEnding is the line ending of a row as written in the file, none on a last line without one.
+func lineEnding(text string) string {
+	switch {
+	case strings.HasSuffix(text, "\r\n"):
+		return "\r\n"
+	case strings.HasSuffix(text, "\n"):
+		return "\n"
+	}
+	return ""
+}
+
+func csvLine(record []string, ending string) string {
+	var buf bytes.Buffer
+	csvWriter := csv.NewWriter(&buf)
+	csvWriter.Write(record)
+	csvWriter.Flush()
+	return strings.TrimSuffix(buf.String(), "\n") + ending
+}
+
+// patchContext is the number of unchanged lines around each change of a patch.
+const patchContext = 3
+
+// formatPatch prints the corrections as a unified diff of the vendor CSV, which patch or git apply apply to the file.
+// The reason for each correction is listed before the diff, where both tools ignore it.
+func formatPatch(name string, input []byte, fixes []rowFix) string {
+	var sb strings.Builder
+	for _, fix := range fixes {
+		for _, suggestion := range fix.Suggestions {
+			fmt.Fprintf(&sb, "row %d: %s %q -> %q, %s\n", fix.RowNo, suggestion.Column, suggestion.Old, suggestion.New, suggestion.Reason)
+		}
+	}
+	if len(fixes) == 0 {
+		return sb.String()
+	}
+
+	lines := splitLines(string(input))
+	fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", patchPath(name), patchPath(name))
+
+	// offset of the new lines from the old ones, by the hunks already written
+	var offset int
+	for first := 0; first < len(fixes); {
+		// a hunk holds the changes whose context lines touch
+		last := first
+		for last+1 < len(fixes) && fixes[last+1].Line-lastLineOf(fixes[last]) <= 2*patchContext+1 {
+			last++
+		}
+		start := fixes[first].Line - patchContext
+		if start < 1 {
+			start = 1
+		}
+		end := lastLineOf(fixes[last]) + patchContext
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		var body strings.Builder
+		oldCount, newCount := 0, 0
+		line := start
+		for _, fix := range fixes[first : last+1] {
+			for ; line < fix.Line; line++ {
+				writePatchLine(&body, " ", lines[line-1])
+				oldCount++
+				newCount++
+			}
+			for _, old := range splitLines(fix.OldText) {
+				writePatchLine(&body, "-", old)
+				oldCount++
+				line++
+			}
+			for _, corrected := range splitLines(fix.NewText) {
+				writePatchLine(&body, "+", corrected)
+				newCount++
+			}
+		}
+		for ; line <= end; line++ {
+			writePatchLine(&body, " ", lines[line-1])
+			oldCount++
+			newCount++
+		}
+
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n%s", start, oldCount, start+offset, newCount, body.String())
+		offset += newCount - oldCount
+		first = last + 1
+	}
+	return sb.String()
+}
+
+// patchPath names the file in a patch applied with -p1 from the working directory, patch and git apply refuse
+// absolute paths.
+func patchPath(name string) string {
+	if filepath.IsAbs(name) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, name); err == nil {
+				name = rel
+			}
+		}
+	}
+	return filepath.ToSlash(name)
+}
+
+// lastLineOf is the last line of the file a corrected row spans.
+func lastLineOf(fix rowFix) int {
+	return fix.Line + len(splitLines(fix.OldText)) - 1
+}
+
+// splitLines splits text into its lines, each with its line ending.
+func splitLines(text string) []string {
+	var lines []string
+	for text != "" {
+		end := strings.Index(text, "\n") + 1
+		if end == 0 {
+			end = len(text)
+		}
+		lines = append(lines, text[:end])
+		text = text[end:]
+	}
+	return lines
+}
+
+func writePatchLine(sb *strings.Builder, prefix, line string) {
+	sb.WriteString(prefix + line)
+	if !strings.HasSuffix(line, "\n") {
+		sb.WriteString("\n\\ No newline at end of file\n")
+	}
+}
+
+// runFix is the fix command: it writes a corrected copy of a vendor CSV and prints the corrections as a patch of the
+// vendor CSV. The input is never overwritten.
+func runFix(args []string, stdout io.Writer) (err error) {
+	flags := flag.NewFlagSet("fix", flag.ContinueOnError)
+	in := flags.String("in", "sample3.csv", "CSV file to fix")
+	out := flags.String("out", "", "corrected CSV file to write, <in>.fixed.csv by default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" {
+		*out = strings.TrimSuffix(*in, ".csv") + ".fixed.csv"
+	}
+	if sameFile(*in, *out) {
+		return fmt.Errorf("-out %s is the file to fix, write the corrected CSV to another file", *out)
+	}
+
+	input, err := os.ReadFile(*in)
+	if err != nil {
+		return err
+	}
+
+	outFile, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := outFile.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	fixes, err := fixCSV(input, outFile)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(stdout, formatPatch(*in, input, fixes))
+	return nil
+}
+
+// sameFile reports whether two paths name one file, an existing one or the same path.
+func sameFile(a, b string) bool {
+	aInfo, aErr := os.Stat(a)
+	bInfo, bErr := os.Stat(b)
+	if aErr == nil && bErr == nil {
+		return os.SameFile(aInfo, bInfo)
+	}
+	absA, aErr := filepath.Abs(a)
+	absB, bErr := filepath.Abs(b)
+	return aErr == nil && bErr == nil && absA == absB
+}
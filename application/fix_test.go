@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fixTestRecord(changes map[int]string) []string {
+	record := strings.Split("ABC,101642,Cable,9190369,,,Li - 1,27-03-2021,6/11,27-03-2025,250,3,3,1,250,5,1,250,Yes,4,2.5,1,247.5,30-12-2024,Partial,report.pdf", ",")
+	for column, value := range changes {
+		record[column] = value
+	}
+	return record
+}
+
+func Test_suggestFixes(t *testing.T) {
+	tests := []struct {
+		name   string
+		record []string
+		want   []fixSuggestion
+	}{
+		{
+			name:   "nothing to fix",
+			record: fixTestRecord(nil),
+		},
+		{
+			name:   "LI name without spaces",
+			record: fixTestRecord(map[int]string{LINameColumnIndex: "Li-1"}),
+			want:   []fixSuggestion{{RowNo: 1, Column: "Li No", Old: "Li-1", New: "Li - 1", Reason: "LI name is written as code - number"}},
+		},
+		{
+			name:   "lower case sample drum flag",
+			record: fixTestRecord(map[int]string{SampleDrumColumnIndex: "no"}),
+			want:   []fixSuggestion{{RowNo: 1, Column: "Sample Drum (Yes/No)", Old: "no", New: "No", Reason: "sample drum flag is Yes or No"}},
+		},
+		{
+			name:   "buffer quantity does not match buffer drums",
+			record: fixTestRecord(map[int]string{BufferQuantityColumnIndex: "200"}),
+			want:   []fixSuggestion{{RowNo: 1, Column: "Buffer Quantity", Old: "200", New: "250", Reason: "buffer quantity is buffer no. of drums times drum size"}},
+		},
+		{
+			name:   "missing full drum total quantity",
+			record: fixTestRecord(map[int]string{FullDrumTotalQuantityColumnIndex: ""}),
+			want:   []fixSuggestion{{RowNo: 1, Column: "Full Drum Total Quantity", Old: "", New: "250", Reason: "full drum total quantity is available full drums times drum size"}},
+		},
+		{
+			name:   "invalid drum size is left to the vendor",
+			record: fixTestRecord(map[int]string{DrumSizeColumnIndex: "abc", BufferQuantityColumnIndex: "200"}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := suggestFixes(tt.record, 0)
+			assert.Equal(t, tt.want, got.Suggestions)
+			assert.Equal(t, tt.record, got.Old)
+		})
+	}
+}
+
+func Test_fixCSV(t *testing.T) {
+	fixed := strings.Join(fixTestRecord(nil), ",")
+	broken := strings.Join(fixTestRecord(map[int]string{LINameColumnIndex: "Li-1"}), ",")
+	input := "header\n" + broken + "\n" + fixed + "\n\n" + broken
+
+	var out bytes.Buffer
+	fixes, err := fixCSV([]byte(input), &out)
+	assert.NoError(t, err)
+
+	assert.Len(t, fixes, 2)
+	assert.Equal(t, "header\n"+fixed+"\n"+fixed+"\n\n"+fixed, out.String())
+	assert.Equal(t, []int{2, 5}, []int{fixes[0].Line, fixes[1].Line})
+
+	assert.Equal(t, `row 1: Li No "Li-1" -> "Li - 1", LI name is written as code - number
+row 3: Li No "Li-1" -> "Li - 1", LI name is written as code - number
+--- a/a.csv
++++ b/a.csv
+@@ -1,5 +1,5 @@
+ header
+-`+broken+`
++`+fixed+`
+ `+fixed+`
+ 
+-`+broken+`
+\ No newline at end of file
++`+fixed+`
+\ No newline at end of file
+`, formatPatch("a.csv", []byte(input), fixes))
+}
+
+func Test_formatPatch_HunksApart(t *testing.T) {
+	fixed := strings.Join(fixTestRecord(nil), ",")
+	broken := strings.Join(fixTestRecord(map[int]string{SampleDrumColumnIndex: "yes"}), ",")
+	input := "header\r\n" + broken + "\r\n" + strings.Repeat(fixed+"\r\n", 7) + broken + "\r\n"
+
+	var out bytes.Buffer
+	fixes, err := fixCSV([]byte(input), &out)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.ReplaceAll(input, broken, fixed), out.String())
+
+	patch := formatPatch("a.csv", []byte(input), fixes)
+	assert.Contains(t, patch, "@@ -1,5 +1,5 @@\n")
+	assert.Contains(t, patch, "@@ -7,4 +7,4 @@\n")
+	assert.Contains(t, patch, "\n+"+fixed+"\r\n")
+}
+
+func Test_runFix_RefusesToOverwriteTheInput(t *testing.T) {
+	in := filepath.Join(t.TempDir(), "a.csv")
+	input := "header\n" + strings.Join(fixTestRecord(map[int]string{LINameColumnIndex: "Li-1"}), ",") + "\n"
+	assert.NoError(t, os.WriteFile(in, []byte(input), 0o644))
+
+	err := runFix([]string{"-in", in, "-out", in}, io.Discard)
+	assert.EqualError(t, err, "-out "+in+" is the file to fix, write the corrected CSV to another file")
+
+	got, err := os.ReadFile(in)
+	assert.NoError(t, err)
+	assert.Equal(t, input, string(got))
+}
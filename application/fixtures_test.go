@@ -0,0 +1,34 @@
+package main
+
+// The tests build their inventories from these, one contract, LI, batch and approval at a time. Every LI is of
+// LI code Li.
+
+func testInventory(contracts ...Contracts) UploadInventoryInput {
+	return UploadInventoryInput{Contracts: contracts}
+}
+
+func testContract(contractNo, vendor string, lis ...LI) Contracts {
+	return Contracts{ContractNo: contractNo, Vendor: vendor, LIs: lis}
+}
+
+func testLI(liNumber, materialCode, unit string, batches ...Batch) LI {
+	return LI{LiCode: "Li", LiNumber: liNumber, MaterialCode: materialCode, Unit: unit, Batches: batches}
+}
+
+// testBatch is a batch of its drum partitions, its total quantity their sum.
+func testBatch(batchNo string, approvals []BatchTestApproval, partitions ...DrumPartition) Batch {
+	batch := Batch{BatchNo: batchNo, BatchTestApprovals: approvals, DrumPartitions: partitions}
+	for _, dp := range partitions {
+		batch.TotalQuantity += dp.Quantity
+	}
+	return batch
+}
+
+// testApproval is a batch test report of a date, dd-mm-yyyy, for drums of one drum size.
+func testApproval(date, status string, drumSize int, drumNumbers ...int) BatchTestApproval {
+	return BatchTestApproval{
+		ApprovalDate:        testDate(date),
+		Status:              status,
+		ApprovalDrumNumbers: []ApprovalDrumNumber{{DrumSize: drumSize, DrumNumbers: drumNumbers}},
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Versioned JSON Schemas of the files the tool reads and writes
+const (
+	schemaLegacyV1    = "legacy-v1"
+	schemaInventoryV1 = "inventory-v1"
+)
+
+//go:embed schemas/*.schema.json
+var schemaFiles embed.FS
+
+// jsonSchema validates documents against a JSON Schema. It supports the keywords the schemas of this tool use:
+// $ref to $defs, type, enum, const, properties, required, additionalProperties, items, uniqueItems, minimum,
+// minLength and pattern.
+type jsonSchema struct {
+	root map[string]any
+}
+
+// loadSchema reads one of the embedded schemas by name.
+func loadSchema(name string) (jsonSchema, error) {
+	data, err := schemaFiles.ReadFile("schemas/" + name + ".schema.json")
+	if err != nil {
+		return jsonSchema{}, fmt.Errorf("unknown schema %s", name)
+	}
+	var root map[string]any
+	if err := decodeJSON(data, &root); err != nil {
+		return jsonSchema{}, fmt.Errorf("failed to read schema %s: %w", name, err)
+	}
+	return jsonSchema{root: root}, nil
+}
+
+// decodeJSON decodes JSON keeping numbers as written, so integers can be told from other numbers.
+func decodeJSON(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// detectSchema names the schema a document is written in: documents with a vendor_name are legacy documents,
+// inventories name their schema version from version 2 on.
+func detectSchema(document any) (string, error) {
+	object, ok := document.(map[string]any)
+	if !ok {
+		return schemaInventoryV1, nil
+	}
+	if _, ok := object["vendor_name"]; ok {
+		return schemaLegacyV1, nil
+	}
+	version, ok := object["schema_version"]
+	switch {
+	case !ok:
+		return schemaInventoryV1, nil
+	case version == inventorySchemaVersion:
+		return schemaInventoryV2, nil
+	}
+	return "", fmt.Errorf("unknown schema version %s", describeJSON(version))
+}
+
+// validateJSON checks a document against the schema it is written in and returns the name of the schema and every
+// violation found, by the path of the offending value.
+func validateJSON(data []byte) (string, []string, error) {
+	var document any
+	if err := decodeJSON(data, &document); err != nil {
+		return "", nil, fmt.Errorf("failed to read JSON: %w", err)
+	}
+	name, err := detectSchema(document)
+	if err != nil {
+		return "", nil, err
+	}
+	schema, err := loadSchema(name)
+	if err != nil {
+		return name, nil, err
+	}
+	return name, schema.validate(document), nil
+}
+
+// validate returns every violation of the schema by the document.
+func (s jsonSchema) validate(document any) []string {
+	var problems []string
+	s.validateValue(s.root, document, "$", &problems)
+	return problems
+}
+
+func (s jsonSchema) validateValue(schema map[string]any, value any, path string, problems *[]string) {
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, err := s.resolve(ref)
+		if err != nil {
+			*problems = append(*problems, fmt.Sprintf("%s: %s", path, err))
+			return
+		}
+		s.validateValue(resolved, value, path, problems)
+	}
+
+	if types, ok := schema["type"]; ok && !matchesType(types, value) {
+		*problems = append(*problems, fmt.Sprintf("%s: %s is not of type %s", path, describeJSON(value), typeNames(types)))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, allowed := range enum {
+			if equalJSON(allowed, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*problems = append(*problems, fmt.Sprintf("%s: %s is not one of %s", path, describeJSON(value), describeJSON(enum)))
+		}
+	}
+
+	if constant, ok := schema["const"]; ok && !equalJSON(constant, value) {
+		*problems = append(*problems, fmt.Sprintf("%s: %s is not %s", path, describeJSON(value), describeJSON(constant)))
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		s.validateObject(schema, v, path, problems)
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				s.validateValue(items, item, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+		if unique, ok := schema["uniqueItems"].(bool); ok && unique {
+			seen := make(map[string]int, len(v))
+			for i, item := range v {
+				if first, ok := seen[describeJSON(item)]; ok {
+					*problems = append(*problems, fmt.Sprintf("%s[%d]: %s repeats %s[%d]", path, i, describeJSON(item), path, first))
+					continue
+				}
+				seen[describeJSON(item)] = i
+			}
+		}
+	case json.Number:
+		if minimum, ok := schema["minimum"].(json.Number); ok {
+			if n, _ := v.Float64(); n < mustFloat(minimum) {
+				*problems = append(*problems, fmt.Sprintf("%s: %s is less than %s", path, v, minimum))
+			}
+		}
+	case string:
+		if minLength, ok := schema["minLength"].(json.Number); ok && float64(len([]rune(v))) < mustFloat(minLength) {
+			*problems = append(*problems, fmt.Sprintf("%s: %q is shorter than %s", path, v, minLength))
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err != nil || !re.MatchString(v) {
+				*problems = append(*problems, fmt.Sprintf("%s: %q does not match %s", path, v, pattern))
+			}
+		}
+	}
+}
+
+func (s jsonSchema) validateObject(schema map[string]any, object map[string]any, path string, problems *[]string) {
+	if required, ok := schema["required"].([]any); ok {
+		for _, name := range required {
+			if _, ok := object[name.(string)]; !ok {
+				*problems = append(*problems, fmt.Sprintf("%s: %s is required", path, name))
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if property, ok := properties[name].(map[string]any); ok {
+			s.validateValue(property, object[name], path+"."+name, problems)
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*problems = append(*problems, fmt.Sprintf("%s: %s is not allowed", path, name))
+			}
+		case map[string]any:
+			s.validateValue(additional, object[name], path+"."+name, problems)
+		}
+	}
+}
+
+// resolve finds the schema a $ref points to, only references within the schema are supported.
+func (s jsonSchema) resolve(ref string) (map[string]any, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported reference %s", ref)
+	}
+	var node any = s.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		object, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %s", ref)
+		}
+		node = object[part]
+	}
+	resolved, ok := node.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unresolved reference %s", ref)
+	}
+	return resolved, nil
+}
+
+// matchesType reports whether a value is of the type, or of one of the types, a schema allows.
+func matchesType(types any, value any) bool {
+	switch t := types.(type) {
+	case string:
+		return matchesTypeName(t, value)
+	case []any:
+		for _, name := range t {
+			if s, ok := name.(string); ok && matchesTypeName(s, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesTypeName(name string, value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return name == "null"
+	case bool:
+		return name == "boolean"
+	case string:
+		return name == "string"
+	case []any:
+		return name == "array"
+	case map[string]any:
+		return name == "object"
+	case json.Number:
+		if name == "number" {
+			return true
+		}
+		f, err := v.Float64()
+		return name == "integer" && err == nil && f == math.Trunc(f)
+	}
+	return false
+}
+
+func typeNames(types any) string {
+	if names, ok := types.([]any); ok {
+		parts := make([]string, 0, len(names))
+		for _, name := range names {
+			parts = append(parts, fmt.Sprint(name))
+		}
+		return strings.Join(parts, " or ")
+	}
+	return fmt.Sprint(types)
+}
+
+func equalJSON(a, b any) bool {
+	return describeJSON(a) == describeJSON(b)
+}
+
+// describeJSON writes a value as JSON for messages.
+func describeJSON(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func mustFloat(n json.Number) float64 {
+	f, _ := n.Float64()
+	return f
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_jsonSchema_validate(t *testing.T) {
+	schema := jsonSchema{}
+	assert.NoError(t, decodeJSON([]byte(`{
+		"type": "object",
+		"required": ["name", "items"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 1, "pattern": "^[a-z]+$"},
+			"status": {"enum": ["OPEN", "CLOSED"]},
+			"items": {"type": ["array", "null"], "items": {"$ref": "#/$defs/item"}, "uniqueItems": true}
+		},
+		"$defs": {
+			"item": {"type": "integer", "minimum": 1}
+		}
+	}`), &schema.root))
+
+	tests := []struct {
+		name     string
+		document string
+		want     []string
+	}{
+		{name: "valid", document: `{"name": "a", "status": "OPEN", "items": [1, 2]}`},
+		{name: "null array", document: `{"name": "a", "items": null}`},
+		{name: "not an object", document: `[]`, want: []string{"$: [] is not of type object"}},
+		{name: "missing property", document: `{"name": "a"}`, want: []string{"$: items is required"}},
+		{name: "unknown property", document: `{"name": "a", "items": [], "other": 1}`, want: []string{"$: other is not allowed"}},
+		{
+			name:     "invalid values",
+			document: `{"name": "A", "status": "DONE", "items": [0, 1.5, "2"]}`,
+			want: []string{
+				`$.items[0]: 0 is less than 1`,
+				`$.items[1]: 1.5 is not of type integer`,
+				`$.items[2]: "2" is not of type integer`,
+				`$.name: "A" does not match ^[a-z]+$`,
+				`$.status: "DONE" is not one of ["OPEN","CLOSED"]`,
+			},
+		},
+		{name: "repeated item", document: `{"name": "a", "items": [1, 2, 1]}`, want: []string{"$.items[2]: 1 repeats $.items[0]"}},
+		{name: "empty string", document: `{"name": "", "items": []}`, want: []string{`$.name: "" is shorter than 1`, `$.name: "" does not match ^[a-z]+$`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var document any
+			assert.NoError(t, decodeJSON([]byte(tt.document), &document))
+			assert.Equal(t, tt.want, schema.validate(document))
+		})
+	}
+}
+
+func Test_validateJSON_Output(t *testing.T) {
+	parse := defaultParseOptions()
+	parse.withProvenance = true
+	records, errs := parseNamedCSV("a.csv", strings.NewReader(generateCSV(3)), parse)
+	assert.Empty(t, errs)
+
+	for _, options := range []outputOptions{{}, {canonical: true}} {
+		output, err := marshalRecords(records, options)
+		assert.NoError(t, err)
+
+		name, problems, err := validateJSON(output)
+		assert.NoError(t, err)
+		assert.Equal(t, schemaInventoryV2, name)
+		assert.Empty(t, problems)
+	}
+
+	name, problems, err := validateJSON([]byte(`{"contracts": [{"contract_no": "1", "lis": [{"material_code": 1}]}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, schemaInventoryV1, name)
+	assert.Contains(t, problems, "$.contracts[0].lis[0].material_code: 1 is not of type string")
+
+	_, _, err = validateJSON([]byte(`{`))
+	assert.Error(t, err)
+}
+
+func Test_validateJSON_V1Output(t *testing.T) {
+	// output.json was written before the schema had a version, without units, quantities or remark flags
+	output, err := os.ReadFile("output.json")
+	assert.NoError(t, err)
+
+	name, problems, err := validateJSON(output)
+	assert.NoError(t, err)
+	assert.Equal(t, schemaInventoryV1, name)
+	assert.Empty(t, problems)
+
+	// a field added in version 2 is not part of version 1
+	var document map[string]any
+	assert.NoError(t, decodeJSON(output, &document))
+	li := document["contracts"].([]any)[0].(map[string]any)["lis"].([]any)[0].(map[string]any)
+	li["unit"] = "m"
+	document["sources"] = []any{}
+	withV2Fields, err := json.Marshal(document)
+	assert.NoError(t, err)
+
+	name, problems, err = validateJSON(withV2Fields)
+	assert.NoError(t, err)
+	assert.Equal(t, schemaInventoryV1, name)
+	assert.Equal(t, []string{"$.contracts[0].lis[0]: unit is not allowed", "$: sources is not allowed"}, problems)
+}
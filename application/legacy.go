@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// LegacyContract is the stock of one contract in the schema of the files migrated at go-live.
+type LegacyContract struct {
+	VendorName string     `json:"vendor_name"`
+	ContractNo string     `json:"contract_no"`
+	LIs        []LegacyLI `json:"lis"`
+}
+
+type LegacyLI struct {
+	MaterialCode string        `json:"material_code"`
+	LiCode       string        `json:"li_code"`
+	LiNumber     string        `json:"li_number"`
+	Description  string        `json:"description"`
+	Batches      []LegacyBatch `json:"batches"`
+}
+
+// LegacyBatch carries the date of its approval on the batch, batch test approvals were not always recorded.
+type LegacyBatch struct {
+	BatchNo            string              `json:"batch_no"`
+	TotalQty           int                 `json:"total_qty"`
+	SubmissionDate     Date                `json:"submission_date"`
+	ApprovalDate       Date                `json:"approval_date"`
+	Status             string              `json:"status"`
+	DrumSizes          []LegacyDrumSize    `json:"drum_sizes"`
+	BatchTestApprovals []BatchTestApproval `json:"batch_test_approvals"`
+}
+
+type LegacyDrumSize struct {
+	DrumSize             int           `json:"drum_size"`
+	Quantity             int           `json:"quantity"`
+	UnapprovedQuantity   int           `json:"unapproved_quantity"`
+	AvailableQuantity    int           `json:"available_quantity"`
+	AvailableDrumNumbers []int         `json:"available_drum_numbers"`
+	BufferQuantity       int           `json:"buffer_quantity"`
+	BufferDrumNumbers    []int         `json:"buffer_drum_numbers"`
+	TestQuantity         float64       `json:"test_quantity"`
+	TestDrumNumbers      []DrumDetails `json:"test_drum_numbers"`
+	ShortQuantity        float64       `json:"short_quantity"`
+	ShortDrumNumbers     []DrumDetails `json:"short_drum_numbers"`
+}
+
+// readLegacy reads a legacy file, which holds one contract or a list of contracts.
+func readLegacy(reader io.Reader) ([]LegacyContract, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var contracts []LegacyContract
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &contracts)
+	} else {
+		var contract LegacyContract
+		err = json.Unmarshal(trimmed, &contract)
+		contracts = []LegacyContract{contract}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read legacy file: %w", err)
+	}
+	return contracts, nil
+}
+
+// convertLegacy converts legacy contracts to the current inventory. Drum sizes become drum partitions in the unit
+// of the LI, metres, and a batch with an approval date but no batch test approval gets the approval of all its
+// available, buffer and test drums on that date. Batch and LI statuses are derived as for vendor files; a legacy
+// batch status that differs from the derived one is reported.
+func convertLegacy(contracts []LegacyContract) (UploadInventoryInput, []Error) {
+	errors := make([]Error, 0)
+	var res UploadInventoryInput
+
+	for _, legacyContract := range contracts {
+		contract := Contracts{ContractNo: legacyContract.ContractNo, Vendor: legacyContract.VendorName}
+		for _, legacyLI := range legacyContract.LIs {
+			li := LI{
+				MaterialCode: legacyLI.MaterialCode,
+				LiCode:       legacyLI.LiCode,
+				LiNumber:     legacyLI.LiNumber,
+				Description:  legacyLI.Description,
+				Unit:         defaultUnit,
+				Batches:      []Batch{},
+			}
+			for _, legacyBatch := range legacyLI.Batches {
+				batch, err := convertLegacyBatch(legacyBatch)
+				if err != nil {
+					errors = append(errors, Error{RowNo: 0, Err: fmt.Errorf("contract %s LI %s-%s batch %s: %w", contract.ContractNo, li.LiCode, li.LiNumber, batch.BatchNo, err)})
+				}
+				li.Batches = append(li.Batches, batch)
+			}
+			li.DeliveredQuantity = deliveredQuantity(li)
+			li.Status = determineLIStatus(li)
+			contract.LIs = append(contract.LIs, li)
+		}
+		res.Contracts = append(res.Contracts, contract)
+	}
+
+	return sortCanonical(res), errors
+}
+
+func convertLegacyBatch(legacyBatch LegacyBatch) (Batch, error) {
+	batch := Batch{
+		BatchNo:            legacyBatch.BatchNo,
+		TotalQuantity:      legacyBatch.TotalQty,
+		SubmissionDate:     legacyBatch.SubmissionDate,
+		DrumPartitions:     []DrumPartition{},
+		BatchTestApprovals: legacyBatch.BatchTestApprovals,
+		RemarkFlags:        []string{},
+	}
+
+	var problems []string
+	var quantity int
+	for _, drumSize := range legacyBatch.DrumSizes {
+		batch.DrumPartitions = append(batch.DrumPartitions, convertLegacyDrumSize(drumSize))
+		quantity += drumSize.Quantity
+	}
+	if quantity != batch.TotalQuantity {
+		problems = append(problems, fmt.Sprintf("total quantity %d is not the sum %d of the drum sizes", batch.TotalQuantity, quantity))
+	}
+
+	if batch.BatchTestApprovals == nil {
+		batch.BatchTestApprovals = []BatchTestApproval{}
+	}
+	for i, bta := range batch.BatchTestApprovals {
+		if bta.Status == "" {
+			bta.Status = "APPROVED"
+		}
+		if bta.Remarks == nil {
+			bta.Remarks = []string{}
+		}
+		batch.BatchTestApprovals[i] = bta
+	}
+
+	if !legacyBatch.ApprovalDate.IsZero() {
+		found := false
+		for _, bta := range batch.BatchTestApprovals {
+			found = found || bta.ApprovalDate.Equal(legacyBatch.ApprovalDate)
+		}
+		switch {
+		case len(batch.BatchTestApprovals) == 0:
+			batch.BatchTestApprovals = append(batch.BatchTestApprovals, legacyApproval(legacyBatch))
+		case !found:
+			problems = append(problems, fmt.Sprintf("approval date %s is not the date of any batch test approval", legacyBatch.ApprovalDate))
+		}
+	}
+
+	batch.Status = determineBatchStatus(batch)
+	if legacyBatch.Status != "" && legacyBatch.Status != batch.Status {
+		problems = append(problems, fmt.Sprintf("status %s differs from the derived status %s", legacyBatch.Status, batch.Status))
+	}
+
+	if len(problems) > 0 {
+		return batch, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return batch, nil
+}
+
+func convertLegacyDrumSize(drumSize LegacyDrumSize) DrumPartition {
+	return DrumPartition{
+		DrumSize:              drumSize.DrumSize,
+		Unit:                  defaultUnit,
+		Quantity:              drumSize.Quantity,
+		UnapprovedQuantity:    drumSize.UnapprovedQuantity,
+		UnapprovedDrumNumbers: []int{},
+		AvailableQuantity:     drumSize.AvailableQuantity,
+		AvailableDrumNumbers:  append([]int{}, drumSize.AvailableDrumNumbers...),
+		BufferQuantity:        drumSize.BufferQuantity,
+		BufferDrumNumbers:     append([]int{}, drumSize.BufferDrumNumbers...),
+		TestQuantity:          drumSize.TestQuantity,
+		TestDrumNumbers:       append([]DrumDetails{}, drumSize.TestDrumNumbers...),
+		ShortQuantity:         drumSize.ShortQuantity,
+		ShortDrumNumbers:      append([]DrumDetails{}, drumSize.ShortDrumNumbers...),
+		PartialDrumNumbers:    []DrumDetails{},
+		Remarks:               []string{},
+		RemarkFlags:           []string{},
+	}
+}
+
+// legacyApproval is the batch test approval of a legacy batch that only recorded its approval date.
+func legacyApproval(legacyBatch LegacyBatch) BatchTestApproval {
+	bta := BatchTestApproval{
+		ApprovalDate:        legacyBatch.ApprovalDate,
+		TestDrumNumbers:     []BatchTestDrumNumbers{},
+		ApprovalDrumNumbers: []ApprovalDrumNumber{},
+		Status:              "APPROVED",
+		ApprovalComment:     "Approval date of the legacy batch",
+		Remarks:             []string{},
+	}
+	for _, drumSize := range legacyBatch.DrumSizes {
+		var tested []int
+		for _, details := range drumSize.TestDrumNumbers {
+			tested = append(tested, details.DrumNumber)
+		}
+		if len(drumSize.TestDrumNumbers) > 0 {
+			bta.TestDrumNumbers = append(bta.TestDrumNumbers, BatchTestDrumNumbers{DrumSize: drumSize.DrumSize, DrumNumbers: append([]DrumDetails{}, drumSize.TestDrumNumbers...)})
+		}
+		approved, _ := combineSortAndCheckDuplicates(drumSize.AvailableDrumNumbers, drumSize.BufferDrumNumbers, distinctDrumNumbers(tested))
+		if len(approved) > 0 {
+			bta.ApprovalDrumNumbers = append(bta.ApprovalDrumNumbers, ApprovalDrumNumber{DrumSize: drumSize.DrumSize, DrumNumbers: approved})
+		}
+	}
+	return bta
+}
+
+// runConvertLegacy is the convert-legacy command: it validates a legacy file against its schema, converts it to the
+// current inventory and writes it.
+func runConvertLegacy(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("convert-legacy", flag.ContinueOnError)
+	in := flags.String("in", "../sample.json", "legacy JSON file to convert")
+	out := flags.String("out", "output.json", "JSON file to write")
+	canonical := flags.Bool("canonical", false, "write canonical JSON (sorted keys, no whitespace)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		return err
+	}
+	name, problems, err := validateJSON(data)
+	if err != nil {
+		return err
+	}
+	if name != schemaLegacyV1 {
+		return fmt.Errorf("%s is not a legacy file", *in)
+	}
+	for _, problem := range problems {
+		fmt.Fprintf(stdout, "%s: %s\n", name, problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s does not match the %s schema", *in, name)
+	}
+
+	contracts, err := readLegacy(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	records, errs := convertLegacy(contracts)
+	for _, e := range errs {
+		fmt.Fprintln(stdout, e.Err)
+	}
+
+	jsonData, err := marshalRecords(records, outputOptions{canonical: *canonical})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(*out, jsonData, 0644)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_convertLegacy_Sample(t *testing.T) {
+	data, err := os.ReadFile("../sample.json")
+	assert.NoError(t, err)
+
+	name, problems, err := validateJSON(data)
+	assert.NoError(t, err)
+	assert.Equal(t, schemaLegacyV1, name)
+	assert.Empty(t, problems)
+
+	contracts, err := readLegacy(strings.NewReader(string(data)))
+	assert.NoError(t, err)
+
+	got, errs := convertLegacy(contracts)
+	assert.Empty(t, errs)
+	assert.Len(t, got.Contracts, 1)
+	assert.Equal(t, "LS CABLE AND SYSTEM LTD SINGAPORE", got.Contracts[0].Vendor)
+
+	batch := got.Contracts[0].LIs[0].Batches[0]
+	assert.Equal(t, 5500, batch.TotalQuantity)
+	assert.Equal(t, testDate("15-01-2023"), batch.SubmissionDate)
+	assert.Equal(t, "AVAILABLE", batch.Status)
+	assert.Len(t, batch.DrumPartitions, 2)
+	assert.Equal(t, UnitMetre, batch.DrumPartitions[1].Unit)
+	assert.Equal(t, 3000, batch.DrumPartitions[1].UnapprovedQuantity)
+	assert.Equal(t, "Historical data, added on go-live migration", batch.BatchTestApprovals[0].ApprovalComment)
+
+	// the converted file is valid in the current schema
+	output, err := marshalRecords(got, outputOptions{})
+	assert.NoError(t, err)
+	name, problems, err = validateJSON(output)
+	assert.NoError(t, err)
+	assert.Equal(t, schemaInventoryV2, name)
+	assert.Empty(t, problems)
+}
+
+func Test_readLegacy_List(t *testing.T) {
+	contracts, err := readLegacy(strings.NewReader(`[{"vendor_name": "A", "contract_no": "1", "lis": []}, {"vendor_name": "B", "contract_no": "2", "lis": []}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, []LegacyContract{{VendorName: "A", ContractNo: "1", LIs: []LegacyLI{}}, {VendorName: "B", ContractNo: "2", LIs: []LegacyLI{}}}, contracts)
+
+	_, err = readLegacy(strings.NewReader(`{"vendor_name": 1}`))
+	assert.Error(t, err)
+}
+
+func Test_convertLegacyBatch(t *testing.T) {
+	drumSize := LegacyDrumSize{
+		DrumSize:             250,
+		Quantity:             750,
+		AvailableQuantity:    250,
+		AvailableDrumNumbers: []int{1},
+		BufferQuantity:       250,
+		BufferDrumNumbers:    []int{2},
+		TestQuantity:         2.5,
+		TestDrumNumbers:      []DrumDetails{{DrumNumber: 3, Quantity: 2.5}},
+		ShortQuantity:        247.5,
+		ShortDrumNumbers:     []DrumDetails{{DrumNumber: 3, Quantity: 247.5}},
+	}
+
+	t.Run("approval date only", func(t *testing.T) {
+		got, err := convertLegacyBatch(LegacyBatch{BatchNo: "1/2", TotalQty: 750, ApprovalDate: testDate("15-01-2023"), Status: "PARTIAL_BUFFER", DrumSizes: []LegacyDrumSize{drumSize}})
+		assert.NoError(t, err)
+		assert.Equal(t, []BatchTestApproval{{
+			ApprovalDate:        testDate("15-01-2023"),
+			TestDrumNumbers:     []BatchTestDrumNumbers{{DrumSize: 250, DrumNumbers: []DrumDetails{{DrumNumber: 3, Quantity: 2.5}}}},
+			ApprovalDrumNumbers: []ApprovalDrumNumber{{DrumSize: 250, DrumNumbers: []int{1, 2, 3}}},
+			Status:              "APPROVED",
+			ApprovalComment:     "Approval date of the legacy batch",
+			Remarks:             []string{},
+		}}, got.BatchTestApprovals)
+		assert.Equal(t, "PARTIAL_BUFFER", got.Status)
+	})
+
+	t.Run("inconsistent batch", func(t *testing.T) {
+		got, err := convertLegacyBatch(LegacyBatch{
+			BatchNo:            "1/2",
+			TotalQty:           1000,
+			ApprovalDate:       testDate("16-01-2023"),
+			Status:             "AVAILABLE",
+			DrumSizes:          []LegacyDrumSize{drumSize},
+			BatchTestApprovals: []BatchTestApproval{{ApprovalDate: testDate("15-01-2023")}},
+		})
+		assert.Equal(t, fmt.Errorf("total quantity 1000 is not the sum 750 of the drum sizes; "+
+			"approval date 2023-01-16 is not the date of any batch test approval; "+
+			"status AVAILABLE differs from the derived status PARTIAL_BUFFER"), err)
+		assert.Equal(t, "APPROVED", got.BatchTestApprovals[0].Status)
+	})
+
+	t.Run("not approved", func(t *testing.T) {
+		got, err := convertLegacyBatch(LegacyBatch{BatchNo: "1/2", TotalQty: 750, DrumSizes: []LegacyDrumSize{{DrumSize: 250, Quantity: 750, UnapprovedQuantity: 750}}})
+		assert.NoError(t, err)
+		assert.Equal(t, []BatchTestApproval{}, got.BatchTestApprovals)
+		assert.Equal(t, "DOCS_PENDING_UPLOAD", got.Status)
+	})
+}
@@ -3,11 +3,13 @@ package main
 import (
 	"encoding/csv"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"regexp"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
@@ -15,46 +17,59 @@ import (
 )
 
 type UploadInventoryInput struct {
-	Contracts []Contracts `json:"contracts"`
+	SchemaVersion string      `json:"schema_version"`
+	Contracts     []Contracts `json:"contracts"`
 }
 
 type Contracts struct {
 	ContractNo string `json:"contract_no"`
+	Vendor     string `json:"vendor"`
 	LIs        []LI   `json:"lis"`
 }
 
 type LI struct {
-	MaterialCode    string  `json:"material_code"`
-	LiCode          string  `json:"li_code"`
-	LiNumber        string  `json:"li_number"`
-	Description     string  `json:"description"`
-	Batches         []Batch `json:"batches"`
-	HosApprovalDate string  `json:"hos_approval_date"`
-	Status          string  `json:"status"`
+	MaterialCode      string  `json:"material_code"`
+	LiCode            string  `json:"li_code"`
+	LiNumber          string  `json:"li_number"`
+	Description       string  `json:"description"`
+	Unit              string  `json:"unit"`
+	Batches           []Batch `json:"batches"`
+	HosApprovalDate   Date    `json:"hos_approval_date"`
+	OrderedQuantity   int     `json:"ordered_quantity"`
+	DeliveredQuantity int     `json:"delivered_quantity"`
+	Status            string  `json:"status"`
 }
 
 type Batch struct {
 	BatchNo            string              `json:"batch_no"`
 	TotalQuantity      int                 `json:"total_quantity"`
-	SubmissionDate     string              `json:"submission_date"`
+	SubmissionDate     Date                `json:"submission_date"`
 	DrumPartitions     []DrumPartition     `json:"drum_partition"`
 	BatchTestApprovals []BatchTestApproval `json:"batch_test_approvals"`
 	Remarks            string              `json:"remarks"`
+	RemarkFlags        []string            `json:"remark_flags"`
 	Status             string              `json:"status"`
 }
 
 type DrumPartition struct {
-	DrumSize             int           `json:"drum_size"`
-	Quantity             int           `json:"quantity"`
-	UnapprovedQuantity   int           `json:"unapproved_quantity"`
-	AvailableQuantity    int           `json:"available_quantity"`
-	AvailableDrumNumbers []int         `json:"available_drum_numbers"`
-	BufferQuantity       int           `json:"buffer_quantity"`
-	BufferDrumNumbers    []int         `json:"buffer_drum_numbers"`
-	TestQuantity         float64       `json:"test_quantity"`
-	TestDrumNumbers      []DrumDetails `json:"test_drum_numbers"`
-	ShortQuantity        float64       `json:"short_quantity"`
-	ShortDrumNumbers     []DrumDetails `json:"short_drum_numbers"`
+	DrumSize              int           `json:"drum_size"`
+	Unit                  string        `json:"unit"`
+	Quantity              int           `json:"quantity"`
+	UnapprovedQuantity    int           `json:"unapproved_quantity"`
+	UnapprovedDrumNumbers []int         `json:"unapproved_drum_numbers"`
+	AvailableQuantity     int           `json:"available_quantity"`
+	AvailableDrumNumbers  []int         `json:"available_drum_numbers"`
+	BufferQuantity        int           `json:"buffer_quantity"`
+	BufferDrumNumbers     []int         `json:"buffer_drum_numbers"`
+	TestQuantity          float64       `json:"test_quantity"`
+	TestDrumNumbers       []DrumDetails `json:"test_drum_numbers"`
+	ShortQuantity         float64       `json:"short_quantity"`
+	ShortDrumNumbers      []DrumDetails `json:"short_drum_numbers"`
+	PartialQuantity       float64       `json:"partial_quantity"`
+	PartialDrumNumbers    []DrumDetails `json:"partial_drum_numbers"`
+	Remarks               []string      `json:"remarks"`
+	RemarkFlags           []string      `json:"remark_flags"`
+	Sources               []RowSource   `json:"sources,omitempty"`
 }
 
 type DrumDetails struct {
@@ -63,11 +78,13 @@ type DrumDetails struct {
 }
 
 type BatchTestApproval struct {
-	ApprovalDate        string                 `json:"approval_date"`
+	ApprovalDate        Date                   `json:"approval_date"`
 	TestDrumNumbers     []BatchTestDrumNumbers `json:"test_drum_numbers"`
 	ApprovalDrumNumbers []ApprovalDrumNumber   `json:"approval_drum_numbers"`
 	Status              string                 `json:"status"`
 	ApprovalComment     string                 `json:"approval_comment"`
+	Remarks             []string               `json:"remarks"`
+	Superseded          bool                   `json:"superseded"`
 }
 
 type BatchTestDrumNumbers struct {
@@ -76,8 +93,9 @@ type BatchTestDrumNumbers struct {
 }
 
 type ApprovalDrumNumber struct {
-	DrumSize    int   `json:"drum_size"`
-	DrumNumbers []int `json:"drum_numbers"`
+	DrumSize    int         `json:"drum_size"`
+	DrumNumbers []int       `json:"drum_numbers"`
+	Sources     []RowSource `json:"sources,omitempty"`
 }
 
 type CSVRow struct {
@@ -100,7 +118,7 @@ type CSVRow struct {
 	BufferDrumNo            []int     `csv:"Buffer Drum No."`
 	BufferNoOfDrums         int       `csv:"Buffer No. of Drum"`
 	BufferQuantity          int       `csv:"Buffer Quantity"`
-	SampleDrum              string    `csv:"Sample Drum (Yes/No)"`
+	SampleDrum              bool      `csv:"Sample Drum (Yes/No)"`
 	SampleDrumNo            []int     `csv:"Sample Drum No."`
 	SampleLength            []float64 `csv:"Sample Length (m)"`
 	NoOfShortLengthDrums    int       `csv:"No of Short length Drums"`
@@ -109,6 +127,12 @@ type CSVRow struct {
 	BatchTestReportDate     string    `csv:"Batch Test Report Date"`
 	Remarks                 string    `csv:"Remarks"`
 	BatchTestReportFileName string    `csv:"Batch Test Report File Name"`
+	Unit                    string    `csv:"Unit"`
+	ShortLengths            []float64 `csv:"Short Length Qty per Drum"`
+	BatchTestStatus         string    `csv:"Batch Test Status"`
+	ApprovalComment         string    `csv:"Approval Comment"`
+	LIQuantity              int       `csv:"LI Quantity"`
+	DrumNos                 []int     `csv:"Drum Nos."`
 }
 
 type LIName struct {
@@ -121,7 +145,30 @@ type Error struct {
 	Err   error
 }
 
+// commands are run by their name as the first argument, the conversion of vendor files runs otherwise.
+var commands = map[string]func(args []string, stdout io.Writer) error{
+	"ageing":         runAgeing,
+	"buffer-check":   runBufferCheck,
+	"fix":            runFix,
+	"convert-legacy": runConvertLegacy,
+	"release":        runRelease,
+	"reserve":        runReserve,
+	"schema":         runSchema,
+	"summary":        runSummary,
+	"validate-json":  runValidateJSON,
+}
+
 func main() {
+	// Commands other than the conversion
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
 
 	// Create a log file, overwrites if it exists
 	logFile, err := os.OpenFile("VendorStockUpload.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
@@ -131,29 +178,99 @@ func main() {
 	defer logFile.Close() // Ensure the file is closed eventually
 
 	logger := log.New(logFile, "[Stock Upload] Error: ", log.Lmsgprefix|log.LstdFlags)
+	warnLogger := log.New(logFile, "[Stock Upload] Warning: ", log.Lmsgprefix|log.LstdFlags)
+	bufferLogger := log.New(logFile, "[Stock Upload] Buffer: ", log.Lmsgprefix|log.LstdFlags)
+	var warnings []Error
+	var bufferFindings []bufferFinding
 	var errors []Error
 
 	// Get the CSV file path from command-line arguments
-	csvFilePath := "sample3.csv"
+	csvFilePath := flag.String("in", "sample3.csv", "CSV file to convert")
+	jsonFilePath := flag.String("out", "output.json", "JSON file to write")
+	canonical := flag.Bool("canonical", false, "write canonical JSON (sorted keys, no whitespace) so identical data gives identical bytes")
+	dateFormat := flag.String("date-format", "iso", "layout of dates in the output, iso (yyyy-mm-dd) or legacy (dd-mm-yyyy)")
+	withProvenance := flag.Bool("with-provenance", false, "include the source file and row of every drum partition, approval and drum number in the output")
+	batchInput := flag.String("batch", "", "directory or glob of CSV files to convert in parallel, instead of -in")
+	outDir := flag.String("out-dir", ".", "directory for the JSON output and report of each file in batch mode")
+	scope := flag.String("overlap-scope", overlapScopeContract, "scope a drum number may only be approved once in: li, contract, material (across contracts) or vendor")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of files converted at once in batch mode")
+	bufferPolicyPath := flag.String("buffer-policy", "", "JSON file of buffer policies per contract and material to check the converted stock against")
+	flag.Parse()
+
+	dateLayout, err := parseDateLayout(*dateFormat)
+	if err != nil {
+		logger.Printf("Row %d: %s", 0, err)
+		return
+	}
+	output := outputOptions{canonical: *canonical, dateLayout: dateLayout}
+
+	parse := defaultParseOptions()
+	parse.withProvenance = *withProvenance
+	if parse.overlapScope, err = parseOverlapScope(*scope); err != nil {
+		logger.Printf("Row %d: %s", 0, err)
+		return
+	}
+
+	if *batchInput != "" {
+		var policies []bufferPolicy
+		if *bufferPolicyPath != "" {
+			if policies, err = loadBufferPolicies(*bufferPolicyPath); err != nil {
+				logger.Printf("Row %d: %s", 0, err)
+				return
+			}
+		}
+		summary, err := runBatch(*batchInput, *outDir, *workers, parse, output, policies)
+		if err != nil {
+			logger.Printf("Row %d: %s", 0, err)
+			return
+		}
+		for _, e := range summary.CrossFileErrors {
+			logger.Printf("Row %d: %s", e.RowNo, e.Err)
+		}
+		for _, result := range summary.Files {
+			for _, w := range result.Warnings {
+				warnLogger.Printf("%s row %d: %s", result.Path, w.RowNo, w.Err)
+			}
+			for _, finding := range result.BufferFindings {
+				bufferLogger.Printf("%s: %s", result.Path, finding)
+			}
+		}
+		fmt.Print(summary)
+		return
+	}
 
 	// Open the CSV file
-	file, _ := os.Open(csvFilePath)
+	file, err := os.Open(*csvFilePath)
 	if err != nil {
-		errors = append(errors, Error{RowNo: 0, Err: err})
+		logger.Printf("Row %d: %s", 0, err)
+		return
 	}
 	defer file.Close()
 
 	// Parse the CSV file
-	records, errorSlice := parseCSV(file)
+	records, errorSlice := parseNamedCSV(*csvFilePath, file, parse)
 	errors = append(errors, errorSlice...)
 
+	// check the vendor's remarks against the computed batch status
+	warnings = append(warnings, records.reconcileRemarks()...)
+	warnings = append(warnings, records.unlistedUnapprovedDrums()...)
+
+	// Check the buffer held against the contractual policies
+	if *bufferPolicyPath != "" {
+		policies, err := loadBufferPolicies(*bufferPolicyPath)
+		if err != nil {
+			errors = append(errors, Error{RowNo: 0, Err: err})
+		}
+		bufferFindings = evaluateBufferPolicies(records, policies)
+	}
+
 	// marshal the records to JSON
-	jsonData, err := recordsToJSON(records)
+	jsonData, err := marshalRecords(records, output)
 	if err != nil {
 		errors = append(errors, Error{RowNo: 0, Err: err})
 	}
 
-	jsonFile, err := os.Create("output.json") // or os.OpenFile for more configuration
+	jsonFile, err := os.Create(*jsonFilePath) // or os.OpenFile for more configuration
 	if err != nil {
 		errors = append(errors, Error{RowNo: 0, Err: err}) // Handle the error
 	}
@@ -168,40 +285,53 @@ func main() {
 	for _, e := range errors {
 		logger.Printf("Row %d: %s", e.RowNo, e.Err)
 	}
+	for _, w := range warnings {
+		warnLogger.Printf("Row %d: %s", w.RowNo, w.Err)
+	}
+	for _, finding := range bufferFindings {
+		bufferLogger.Print(finding)
+	}
 }
 
+// Column positions in the vendor CSV
+const (
+	VendorColumnIndex                  = 0
+	MaterialCodeColumnIndex            = 1
+	MaterialDescColumnIndex            = 2
+	ContractNoColumnIndex              = 3
+	PONumberColumnIndex                = 4
+	POLineItemColumnIndex              = 5
+	LINameColumnIndex                  = 6
+	LIDateColumnIndex                  = 7
+	BatchNoColumnIndex                 = 8
+	BatchDueDateColumnIndex            = 9
+	DrumSizeColumnIndex                = 10
+	TotalNoOfDrumsColumnIndex          = 11
+	AvailableDrumNosColumnIndex        = 12
+	AvailableFullDrumsColumnIndex      = 13
+	FullDrumTotalQuantityColumnIndex   = 14
+	BufferDrumNoColumnIndex            = 15
+	BufferNoOfDrumsColumnIndex         = 16
+	BufferQuantityColumnIndex          = 17
+	SampleDrumColumnIndex              = 18
+	SampleDrumNoColumnIndex            = 19
+	SampleLengthColumnIndex            = 20
+	NoOfShortLengthDrumsColumnIndex    = 21
+	ShortLengthTotalQtyColumnIndex     = 22
+	BatchTestReportDateColumnIndex     = 23
+	RemarksColumnIndex                 = 24
+	BatchTestReportFileNameColumnIndex = 25
+	UnitColumnIndex                    = 26
+	ShortLengthsColumnIndex            = 27
+	BatchTestStatusColumnIndex         = 28
+	ApprovalCommentColumnIndex         = 29
+	LIQuantityColumnIndex              = 30
+	DrumNosColumnIndex                 = 31
+)
+
 func (row *CSVRow) UnmarshalCSV(csv []string, rowIndex int) []Error {
 	errors := make([]Error, 0)
 
-	const (
-		VendorColumnIndex                  = 0
-		MaterialCodeColumnIndex            = 1
-		MaterialDescColumnIndex            = 2
-		ContractNoColumnIndex              = 3
-		PONumberColumnIndex                = 4
-		POLineItemColumnIndex              = 5
-		LINameColumnIndex                  = 6
-		LIDateColumnIndex                  = 7
-		BatchNoColumnIndex                 = 8
-		BatchDueDateColumnIndex            = 9
-		DrumSizeColumnIndex                = 10
-		TotalNoOfDrumsColumnIndex          = 11
-		AvailableDrumNosColumnIndex        = 12
-		AvailableFullDrumsColumnIndex      = 13
-		FullDrumTotalQuantityColumnIndex   = 14
-		BufferDrumNoColumnIndex            = 15
-		BufferNoOfDrumsColumnIndex         = 16
-		BufferQuantityColumnIndex          = 17
-		SampleDrumColumnIndex              = 18
-		SampleDrumNoColumnIndex            = 19
-		SampleLengthColumnIndex            = 20
-		NoOfShortLengthDrumsColumnIndex    = 21
-		ShortLengthTotalQtyColumnIndex     = 22
-		BatchTestReportDateColumnIndex     = 23
-		RemarksColumnIndex                 = 24
-		BatchTestReportFileNameColumnIndex = 25
-	)
-
 	// Parse Vendor
 	row.Vendor = strings.TrimSpace(csv[VendorColumnIndex])
 
@@ -308,7 +438,11 @@ func (row *CSVRow) UnmarshalCSV(csv []string, rowIndex int) []Error {
 	row.BufferQuantity = bufferQuantity
 
 	// Parse Sample Drum
-	row.SampleDrum = strings.TrimSpace(csv[SampleDrumColumnIndex])
+	sampleDrum, err := parseSampleDrum(csv[SampleDrumColumnIndex])
+	if err != nil {
+		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("failed to parse sample drum: %w", err)})
+	}
+	row.SampleDrum = sampleDrum
 
 	// Parse Sample Drum Nos
 	rawSampleDrumNos := strings.TrimSpace(csv[SampleDrumNoColumnIndex])
@@ -343,7 +477,7 @@ func (row *CSVRow) UnmarshalCSV(csv []string, rowIndex int) []Error {
 	row.ShortLengthTotalQty = shortLengthTotalQty
 
 	// Parse approved drum numbers
-	approvedDrumNumbers, err := combineSortAndCheckDuplicates(row.AvailableDrumNos, row.BufferDrumNo, row.SampleDrumNo)
+	approvedDrumNumbers, err := combineSortAndCheckDuplicates(row.AvailableDrumNos, row.BufferDrumNo, distinctDrumNumbers(row.SampleDrumNo))
 	if err != nil {
 		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("failed to combine, sort and check duplicates: %w", err)})
 	}
@@ -358,6 +492,61 @@ func (row *CSVRow) UnmarshalCSV(csv []string, rowIndex int) []Error {
 	// Parse Batch Test Report File Name
 	row.BatchTestReportFileName = strings.TrimSpace(csv[BatchTestReportFileNameColumnIndex])
 
+	// Parse Unit, older files without the column are in metres
+	var rawUnit string
+	if len(csv) > UnitColumnIndex {
+		rawUnit = csv[UnitColumnIndex]
+	}
+	// an unknown unit is kept as written and reported by validateRow
+	row.Unit = strings.TrimSpace(rawUnit)
+	if unit, err := parseUnit(rawUnit); err == nil {
+		row.Unit = unit
+	}
+
+	// Parse declared short length per sample drum, when absent the whole remainder of a sample drum is short
+	if len(csv) > ShortLengthsColumnIndex && strings.TrimSpace(csv[ShortLengthsColumnIndex]) != "" {
+		shortLengths, err := stringToFloat64Slice(strings.TrimSpace(csv[ShortLengthsColumnIndex]))
+		if err != nil {
+			errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("failed to parse short lengths: %w", err)})
+		}
+		row.ShortLengths = shortLengths
+	}
+
+	// Parse Batch Test Status, older files only carry approvals
+	var rawBatchTestStatus string
+	if len(csv) > BatchTestStatusColumnIndex {
+		rawBatchTestStatus = csv[BatchTestStatusColumnIndex]
+	}
+	batchTestStatus, err := parseApprovalStatus(rawBatchTestStatus)
+	if err != nil {
+		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("failed to parse batch test status: %w", err)})
+		batchTestStatus = strings.TrimSpace(rawBatchTestStatus)
+	}
+	row.BatchTestStatus = batchTestStatus
+
+	// Parse Approval Comment
+	if len(csv) > ApprovalCommentColumnIndex {
+		row.ApprovalComment = strings.TrimSpace(csv[ApprovalCommentColumnIndex])
+	}
+
+	// Parse LI Quantity, the ordered quantity of the LI, 0 when unknown
+	if len(csv) > LIQuantityColumnIndex && strings.TrimSpace(csv[LIQuantityColumnIndex]) != "" {
+		liQuantity, err := strconv.Atoi(strings.TrimSpace(csv[LIQuantityColumnIndex]))
+		if err != nil {
+			errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("failed to parse LI quantity: %w", err)})
+		}
+		row.LIQuantity = liQuantity
+	}
+
+	// Parse Drum Nos., the numbers of all drums of the row, inferred from the drums listed when not given
+	if len(csv) > DrumNosColumnIndex {
+		drumNos, err := unpackDrumNoRange(strings.TrimSpace(csv[DrumNosColumnIndex]))
+		if err != nil {
+			errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("failed to parse drum nos.: %w", err)})
+		}
+		row.DrumNos = drumNos
+	}
+
 	return errors
 }
 
@@ -404,8 +593,10 @@ func (row *CSVRow) validateRow(rowIndex int) []Error {
 		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("invalid batch due date format")})
 	}
 
-	// Validate DrumSize
-	if !validateDrumSize(row.DrumSize) {
+	// Validate Unit, and DrumSize against a known unit
+	if _, err := parseUnit(row.Unit); err != nil {
+		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("invalid unit")})
+	} else if !validateDrumSizeForUnit(row.DrumSize, row.Unit) {
 		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("invalid drum size")})
 	}
 
@@ -439,8 +630,17 @@ func (row *CSVRow) validateRow(rowIndex int) []Error {
 		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("buffer quantity does not match buffer no. of drums")})
 	}
 
-	// Validate SampleDrumNo
-	if len(row.SampleDrumNo) != row.NoOfShortLengthDrums {
+	// Validate SampleDrum, "No" has no sample drums and a "0" sample length, "Yes" has at least one sample drum
+	if !row.SampleDrum && (len(row.SampleDrumNo) > 0 || len(row.SampleLength) > 0) {
+		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("sample drum is No but sample drum nos. or sample length are given")})
+	}
+	if row.SampleDrum && len(row.SampleDrumNo) == 0 {
+		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("sample drum is Yes but no sample drum nos. are given")})
+	}
+
+	// Validate SampleDrumNo, a drum sampled more than once is listed once per sample
+	sampleDrumNumbers := distinctDrumNumbers(row.SampleDrumNo)
+	if len(sampleDrumNumbers) != row.NoOfShortLengthDrums {
 		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("sample drum nos. does not match no of short length drums")})
 	}
 
@@ -449,11 +649,27 @@ func (row *CSVRow) validateRow(rowIndex int) []Error {
 		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("sample length does not match sample drum nos")})
 	}
 
+	// Validate ShortLengths
+	if len(row.ShortLengths) > 0 && len(row.ShortLengths) != len(sampleDrumNumbers) {
+		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("short lengths does not match sample drum nos")})
+	}
+
 	// Validate ShortLengthTotalQty
-	if row.ShortLengthTotalQty != float64(row.DrumSize*row.NoOfShortLengthDrums)-sumFloat64Slice(row.SampleLength) {
+	if len(row.ShortLengths) > 0 {
+		if row.ShortLengthTotalQty != sumFloat64Slice(row.ShortLengths) {
+			errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("short length total qty does not match with sum of short lengths")})
+		}
+	} else if row.ShortLengthTotalQty != float64(row.DrumSize*row.NoOfShortLengthDrums)-sumFloat64Slice(row.SampleLength) {
 		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("short length total qty does not match with sample length total qty - short length total qty")})
 	}
 
+	// Validate the sample cuts and short length of every sample drum fit in the drum
+	if len(row.SampleLength) == len(row.SampleDrumNo) && (len(row.ShortLengths) == 0 || len(row.ShortLengths) == len(sampleDrumNumbers)) {
+		if err := validateSampleCuts(row.SampleDrumNo, row.SampleLength, row.ShortLengths, row.DrumSize); err != nil {
+			errors = append(errors, Error{RowNo: rowIndex + 1, Err: err})
+		}
+	}
+
 	// Validate ApprovedDrumNumbers
 	if len(row.ApprovedDrumNumbers) != row.AvailableFullDrums+row.BufferNoOfDrums+row.NoOfShortLengthDrums {
 		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("approved drum numbers does not match with available drum numbers, buffer drum numbers, sample drum numbers")})
@@ -464,6 +680,31 @@ func (row *CSVRow) validateRow(rowIndex int) []Error {
 		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("invalid batch test report date format")})
 	}
 
+	// Validate DrumNos
+	if len(row.DrumNos) > 0 && len(distinctDrumNumbers(row.DrumNos)) != row.TotalNoOfDrums {
+		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("drum nos. does not match total no. of drums")})
+	}
+
+	// Validate LIQuantity
+	if row.LIQuantity < 0 {
+		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("LI quantity must not be negative")})
+	}
+
+	// Validate BatchTestStatus
+	if _, err := parseApprovalStatus(row.BatchTestStatus); err != nil {
+		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("invalid batch test status")})
+	}
+
+	// Validate chronology, an LI comes before its batch test reports and batch due dates
+	if liDate, ok := parseLegacyDate(row.LIDate); ok {
+		if batchDueDate, ok := parseLegacyDate(row.BatchDueDate); ok && !batchDueDate.After(liDate) {
+			errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("batch due date must be after LI date")})
+		}
+		if reportDate, ok := parseLegacyDate(row.BatchTestReportDate); ok && reportDate.Before(liDate) {
+			errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("batch test report date must not be before LI date")})
+		}
+	}
+
 	// validate BatchTestReportFileName
 	if row.BatchTestReportDate != "" {
 		if row.BatchTestReportFileName == "" {
@@ -477,335 +718,328 @@ func (row *CSVRow) validateRow(rowIndex int) []Error {
 	}
 
 	// Validate total quantity
-	if row.BatchTestReportDate != "" && float64(row.TotalQty) != float64(row.FullDrumTotalQuantity)+float64(row.BufferQuantity)+row.ShortLengthTotalQty+sumFloat64Slice(row.SampleLength) {
-		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("total quantity does not match with full drum total qty, buffer drum total qty, short length total qty, sample length total qty")})
+	if row.BatchTestReportDate != "" && float64(row.TotalQty) != float64(row.FullDrumTotalQuantity)+float64(row.BufferQuantity)+row.ShortLengthTotalQty+sumFloat64Slice(row.SampleLength)+row.partialLengthTotalQty() {
+		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("total quantity does not match with full drum total qty, buffer drum total qty, short length total qty, sample length total qty, partial length total qty")})
 	}
 
 	return errors
 }
 
+// validateReportDate flags a batch test report dated after the day the file is read on.
+func (row *CSVRow) validateReportDate(rowIndex int, asOf Date) []Error {
+	if reportDate, ok := parseLegacyDate(row.BatchTestReportDate); ok && reportDate.After(asOf) {
+		return []Error{{RowNo: rowIndex + 1, Err: fmt.Errorf("batch test report date is in the future")}}
+	}
+	return nil
+}
+
+// parseOptions select how a vendor file is read.
+type parseOptions struct {
+	overlapScope   string // scope a drum number may only be approved once in
+	asOf           Date   // batch test reports dated after it are in the future
+	withProvenance bool   // record the source rows of every drum partition and approved drum
+}
+
+// defaultParseOptions checks overlaps per contract and report dates against today.
+func defaultParseOptions() parseOptions {
+	return parseOptions{overlapScope: overlapScopeContract, asOf: dateOf(time.Now())}
+}
+
 func parseCSV(reader io.Reader) (UploadInventoryInput, []Error) {
+	return parseNamedCSV("", reader, defaultParseOptions())
+}
+
+// parseNamedCSV reads the rows one at a time and holds them until the last one is read, so that they are placed by
+// contract, LI, batch and drum size whatever their order in the file. Past defaultMaxHeldRows rows they are held in
+// sorted runs on disk, see inventoryBuilder.addRow. With provenance, the name of the file is recorded in the source
+// of each row. The vendor's remarks are not checked, see reconcileRemarks.
+func parseNamedCSV(name string, reader io.Reader, options parseOptions) (UploadInventoryInput, []Error) {
 	var errors []Error
 	csvReader := csv.NewReader(reader)
-	rows, err := csvReader.ReadAll()
-	if err != nil {
+	csvReader.ReuseRecord = true
+
+	// Skip the header row
+	if _, err := csvReader.Read(); err != nil && err != io.EOF {
 		errors = append(errors, Error{RowNo: 0, Err: err})
 	}
-	fmt.Println("rows read:", len(rows)-1)
 
-	var records []CSVRow
-	for i, row := range rows[1:] { // Skip the header row
+	builder := newInventoryBuilder()
+	builder.file = name
+	builder.withProvenance = options.withProvenance
+	var rowsRead int
+	for i := 0; ; i++ {
+		row, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errors = append(errors, Error{RowNo: i + 1, Err: err})
+			if _, ok := err.(*csv.ParseError); ok {
+				continue
+			}
+			break
+		}
+		rowsRead++
+
 		var record CSVRow
 		errorSlice := record.UnmarshalCSV(row, i)
 		errors = append(errors, errorSlice...)
 		errorSlice = record.validateRow(i)
 		errors = append(errors, errorSlice...)
-		records = append(records, record)
+		errorSlice = record.validateReportDate(i, options.asOf)
+		errors = append(errors, errorSlice...)
+		builder.addRow(record, i)
 	}
+	// on standard error, it must not end up in a report written to standard output
+	fmt.Fprintln(os.Stderr, "rows read:", rowsRead)
 
-	res, errorSlice := processRows(records)
+	res, errorSlice := builder.finish()
 	errors = append(errors, errorSlice...)
 
 	// check for overlapping drum numbers
-	errorSlice = res.validateOverlappingDrumNumbers()
+	errorSlice = res.validateDrumOverlaps(options.overlapScope)
 	errors = append(errors, errorSlice...)
 
 	return res, errors
 }
 
 func processRows(rows []CSVRow) (UploadInventoryInput, []Error) {
-	var res UploadInventoryInput
-	var errors []Error
+	builder := newInventoryBuilder()
 	for rowIndex, row := range rows {
+		builder.addRow(row, rowIndex)
+	}
+	return builder.finish()
+}
 
-		if contractIndex := findContractIndex(res.Contracts, row.ContractNo); contractIndex == -1 {
-			// Add a new contract to the res
-			newContract := Contracts{
-				ContractNo: row.ContractNo,
-			}
+// placeRow places a row in the inventory being built, creating or updating its contract, LI, batch, drum partition
+// and batch test approval.
+func (b *inventoryBuilder) placeRow(row CSVRow, rowIndex int) {
+	if contractIndex, ok := b.index.contracts[row.ContractNo]; !ok {
+		// Add a new contract to the res
+		newContract := Contracts{
+			ContractNo: row.ContractNo,
+			Vendor:     row.Vendor,
+		}
+
+		newLi, err := createNewLI(row, rowIndex)
+		if err != nil {
+			b.errors = append(b.errors, err...)
+		}
 
+		newContract.LIs = append(newContract.LIs, newLi)
+		b.index.contracts[row.ContractNo] = len(b.res.Contracts)
+		b.index.lis[liKeyOf(row)] = 0
+		b.indexBatch(batchKeyOf(row), 0, newLi.Batches[0])
+		b.res.Contracts = append(b.res.Contracts, newContract)
+	} else {
+		// get the existing contract to update
+		contract := b.res.Contracts[contractIndex]
+
+		// verify all rows of the contract come from one vendor
+		if contract.Vendor != row.Vendor {
+			b.errors = append(b.errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("vendor does not match")})
+		}
+
+		// Check if LI exists, if not, add a new LI to the existing contract, else add new batch to existing LI
+		if liIndex, ok := b.index.lis[liKeyOf(row)]; !ok {
+			// Add a new LI to the existing contract
 			newLi, err := createNewLI(row, rowIndex)
 			if err != nil {
-				errors = append(errors, err...)
+				b.errors = append(b.errors, err...)
 			}
 
-			newContract.LIs = append(newContract.LIs, newLi)
-			res.Contracts = append(res.Contracts, newContract)
+			b.index.lis[liKeyOf(row)] = len(contract.LIs)
+			b.indexBatch(batchKeyOf(row), 0, newLi.Batches[0])
+			contract.LIs = append(contract.LIs, newLi)
 		} else {
-			// get the existing contract to update
-			contract := res.Contracts[contractIndex]
+			// get the existing LI to update
+			li := contract.LIs[liIndex]
 
-			// Check if LI exists, if not, add a new LI to the existing contract, else add new batch to existing LI
-			if liIndex := findLiIndex(contract.LIs, row.LIName); liIndex == -1 {
-				// Add a new LI to the existing contract
-				newLi, err := createNewLI(row, rowIndex)
-				if err != nil {
-					errors = append(errors, err...)
-				}
+			// verify the hos approval date
+			if !li.HosApprovalDate.Equal(mustParseDate(row.LIDate)) {
+				b.errors = append(b.errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("hos approval date does not match")})
+			}
 
-				contract.LIs = append(contract.LIs, newLi)
-			} else {
-				// get the existing LI to update
-				li := contract.LIs[liIndex]
+			// verify material
+			if li.MaterialCode != row.MaterialCode {
+				b.errors = append(b.errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("material code does not match")})
+			}
 
-				// verify the hos approval date
-				if li.HosApprovalDate != row.LIDate {
-					errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("hos approval date does not match")})
-				}
+			// verify material description
+			if li.Description != row.MaterialDesc {
+				b.errors = append(b.errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("material description does not match")})
+			}
 
-				// verify material
-				if li.MaterialCode != row.MaterialCode {
-					errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("material code does not match")})
-				}
+			// verify ordered quantity
+			if li.OrderedQuantity != row.LIQuantity {
+				b.errors = append(b.errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("LI quantity does not match")})
+			}
 
-				// verify material description
-				if li.Description != row.MaterialDesc {
-					errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("material description does not match")})
+			// verify all rows of the LI use one unit
+			if li.Unit != row.Unit {
+				b.errors = append(b.errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("unit %s does not match LI unit %s", row.Unit, li.Unit)})
+			}
+
+			// Check if batch exists, if not, add a new batch to the existing LI
+			if batchIndex, ok := b.index.batches[batchKeyOf(row)]; !ok {
+				newBatch, err := createNewBatch(row, rowIndex)
+				if err != nil {
+					b.errors = append(b.errors, err...)
+				}
+				b.indexBatch(batchKeyOf(row), len(li.Batches), newBatch)
+				li.Batches = append(li.Batches, newBatch)
+			} else { // update the existing batch
+
+				// Testcases:
+				// 1. same drum size, no batch test approval date
+				// 2. same drum size, same batch test approval date
+				// 3. same drum size, different batch test approval date
+				// 4. different drum size, no batch test approval date
+				// 5. different drum size, same batch test approval date
+				// 6. different drum size, different batch test approval date
+				//
+				// The drum partition of the row's drum size is updated in cases 1-3 and created in cases 4-6. The
+				// batch test approval of the row's date is skipped in cases 1 and 4, updated in cases 2 and 5 and
+				// created in cases 3 and 6.
+
+				// get the existing batch to update
+				batch := li.Batches[batchIndex]
+
+				// verify batch due date
+				if !batch.SubmissionDate.Equal(mustParseDate(row.BatchDueDate)) {
+					b.errors = append(b.errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("batch due date does not match")})
 				}
 
-				// Check if batch exists, if not, add a new batch to the existing LI
-				if batchIndex := findBatchIndex(li.Batches, row.BatchNo); batchIndex == -1 {
-					newBatch, err := createNewBatch(row, rowIndex)
+				dpKey := partitionKeyOf(row)
+				if dpIndex, drumSizeExists := b.index.partitions[dpKey]; drumSizeExists {
+					// update the existing drum partition
+					updatedDrumPartition, err := updateDrumPartition(batch.DrumPartitions[dpIndex], row, rowIndex, approvedDrumsOf(batch, row.DrumSize))
 					if err != nil {
-						errors = append(errors, err...)
+						b.errors = append(b.errors, err...)
 					}
-					li.Batches = append(li.Batches, newBatch)
-				} else { // update the existing batch
-
-					// Testcases:
-					// 1. same drum size, no batch test approval date
-					// 2. same drum size, same batch test approval date
-					// 3. same drum size, different batch test approval date
-					// 4. different drum size, same batch test approval date
-					// 5. different drum size, different batch test approval date
-					// 6. different drum size, no batch test approval date
-
-					// get the existing batch to update
-					batch := li.Batches[batchIndex]
-
-					// verify batch due date
-					if batch.SubmissionDate != row.BatchDueDate {
-						errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("batch due date does not match")})
+					b.putDrumPartition(&batch, dpKey, updatedDrumPartition)
+				} else {
+					// create new drum partition and append to the existing batch
+					newDrumPartition, err := createDrumPartition(row, rowIndex)
+					if err != nil {
+						b.errors = append(b.errors, err...)
 					}
+					b.putDrumPartition(&batch, dpKey, newDrumPartition)
+				}
 
-					// check if current row is new drum size, if yes, add a new drum partition to the existing batch
-					var dpMap = make(map[int]DrumPartition)
-					for _, dp := range batch.DrumPartitions {
-						dpMap[dp.DrumSize] = dp
-					}
-					dp, drumSizeExists := dpMap[row.DrumSize]
+				b.aggregateApproval(&batch, row, rowIndex)
 
-					// check if batch test approval date is duplicated
-					var btaMap = make(map[string]BatchTestApproval)
-					for _, bta := range batch.BatchTestApprovals {
-						btaMap[bta.ApprovalDate] = bta
-					}
-					bta, batchTestApprovalExists := btaMap[row.BatchTestReportDate]
-
-					switch {
-					case drumSizeExists:
-
-						// 1. same drum size, no batch test approval date
-						//		- update the existing drum partition
-						//		- skip creating a new batch test approval
-						// 2. same drum size, same batch test approval date
-						//		- update the existing drum partition
-						//		- update the existing batch test approval
-						// 3. same drum size, different batch test approval date
-						//		- update the existing drum partition
-						//		- create new batch test approval and append to newBatch
-
-						// update the existing drum partition
-						updatedDrumPartition, err := updateDrumPartition(dp, row, rowIndex)
-						if err != nil {
-							errors = append(errors, err...)
-						}
-
-						// update the existing drum partition
-						dpMap[row.DrumSize] = updatedDrumPartition
-
-						switch {
-						case row.BatchTestReportDate == "": // Case1: same drum size, no batch test approval date
-
-							// skip creating a new batch test approval
-
-						case batchTestApprovalExists: // Case2: same drum size, same batch test approval date
-
-							// update the existing batch test approval's test drum numbers
-							for testDrumNumberIndex, testDrumNumber := range bta.TestDrumNumbers {
-								if testDrumNumber.DrumSize == row.DrumSize {
-									if len(row.SampleDrumNo) > 0 {
-										for i, drumNo := range row.SampleDrumNo {
-											testDrumNumber.DrumNumbers = append(testDrumNumber.DrumNumbers, DrumDetails{
-												DrumNumber: drumNo,
-												Quantity:   row.SampleLength[i],
-											})
-										}
-									}
-									bta.TestDrumNumbers[testDrumNumberIndex] = testDrumNumber
-									break
-								}
-							}
-
-							// update the existing batch test approval's approval drum numbers
-							for approvalDrumNumberIndex, approvalDrumNumber := range bta.ApprovalDrumNumbers {
-								if approvalDrumNumber.DrumSize == row.DrumSize {
-									drumNumbers, err2 := combineSortAndCheckDuplicates(approvalDrumNumber.DrumNumbers, row.ApprovedDrumNumbers)
-									if err2 != nil {
-										errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("failed to combine, sort and check drum no. duplicates : %s", err2)})
-									}
-									approvalDrumNumber.DrumNumbers = drumNumbers
-									bta.ApprovalDrumNumbers[approvalDrumNumberIndex] = approvalDrumNumber
-									break
-								}
-							}
-
-							btaMap[row.BatchTestReportDate] = bta
-
-						case !batchTestApprovalExists: // Case3: same drum size, different batch test approval date
-
-							// create new batch test approval
-							newBatchTestApproval := createBatchTestApproval(row)
-
-							// add to btaMap
-							btaMap[row.BatchTestReportDate] = newBatchTestApproval
-						}
-
-					case !drumSizeExists:
-						// 4. different drum size, no batch test approval date
-						//		- create new drum partition and append to the existing batch
-						//		- skip creating a new batch test approval
-						// 5. different drum size, same batch test approval date
-						//		- create new drum partition and append to the existing batch
-						//		- update the existing batch test approval
-						// 6. different drum size, different batch test approval date
-						//		- create new drum partition and append to the existing batch
-						//		- create new batch test approval and append to the existing batch
-
-						newDrumPartition, err := createDrumPartition(row, rowIndex)
-						if err != nil {
-							errors = append(errors, err...)
-						}
-
-						// add to dpMap
-						dpMap[row.DrumSize] = newDrumPartition
-
-						switch {
-						case row.BatchTestReportDate == "": // Case4: different drum size, no batch test approval date
-
-							// skip creating a new batch test approval
-
-						case batchTestApprovalExists: // Case5: different drum size, same batch test approval date
-
-							// update the existing batch test approval's test drum numbers
-							testDrumNumberDetails, _, _, _ := unpackSampleDrumNos(row.SampleDrumNo, row.SampleLength, row.DrumSize)
-
-							bta.TestDrumNumbers = append(bta.TestDrumNumbers, BatchTestDrumNumbers{
-								DrumSize:    row.DrumSize,
-								DrumNumbers: testDrumNumberDetails,
-							})
-
-							// update the existing batch test approval's approval drum numbers
-							bta.ApprovalDrumNumbers = append(bta.ApprovalDrumNumbers, ApprovalDrumNumber{
-								DrumSize:    row.DrumSize,
-								DrumNumbers: row.ApprovedDrumNumbers,
-							})
-
-							btaMap[row.BatchTestReportDate] = bta
-
-						case !batchTestApprovalExists: // Case6: different drum size, different batch test approval date
-
-							// create new batch test approval
-							newBatchTestApproval := createBatchTestApproval(row)
-
-							// add to btaMap
-							btaMap[row.BatchTestReportDate] = newBatchTestApproval
-						}
+				// update batch total qty and remarks
+				batch.TotalQuantity += row.TotalQty
+				batch = aggregateRemarks(batch)
 
-					}
+				// update batch status
+				batch.Status = determineBatchStatus(batch)
 
-					// update the existing batch with the new drum partition map
-					var updatedDrumPartitions []DrumPartition
-					for _, drumPartition := range dpMap {
-						updatedDrumPartitions = append(updatedDrumPartitions, drumPartition)
-						// sort drum partitions by drum size
-						sort.Slice(updatedDrumPartitions, func(i, j int) bool {
-							return updatedDrumPartitions[i].DrumSize < updatedDrumPartitions[j].DrumSize
-						})
-					}
-					batch.DrumPartitions = updatedDrumPartitions
-
-					// update the existing batch with the new batch test approval map
-					var updatedBatchTestApprovals []BatchTestApproval
-					for _, batchTestApproval := range btaMap {
-						updatedBatchTestApprovals = append(updatedBatchTestApprovals, batchTestApproval)
-						// sort batch test approvals by approval date
-						sort.Slice(updatedBatchTestApprovals, func(i, j int) bool {
-							return updatedBatchTestApprovals[i].ApprovalDate < updatedBatchTestApprovals[j].ApprovalDate
-						})
-					}
-					batch.BatchTestApprovals = updatedBatchTestApprovals
+				if batch.BatchTestApprovals == nil {
+					batch.BatchTestApprovals = []BatchTestApproval{}
+				}
 
-					// update batch total qty
-					batch.TotalQuantity += row.TotalQty
+				li.Batches[batchIndex] = batch
+			}
 
-					// update batch status
-					batch.Status = determineBatchStatus(batch)
+			// update the existing LI
+			contract.LIs[liIndex] = li
+		}
 
-					if batch.BatchTestApprovals == nil {
-						batch.BatchTestApprovals = []BatchTestApproval{}
-					}
+		b.res.Contracts[contractIndex] = contract
+	}
+}
 
-					li.Batches[batchIndex] = batch
-				}
+// finish applies what can only be decided once every row is known and returns the inventory in canonical order.
+func (b *inventoryBuilder) finish() (UploadInventoryInput, []Error) {
+	b.placeRows()
 
-				// update the existing LI
-				contract.LIs[liIndex] = li
+	// apply rejections and retests now that every approval of each batch is known, then derive the LI status
+	for _, contract := range b.res.Contracts {
+		for liIndex, li := range contract.LIs {
+			for batchIndex, batch := range li.Batches {
+				li.Batches[batchIndex] = resolveApprovalHistory(batch)
 			}
-
-			res.Contracts[contractIndex] = contract
+			li.DeliveredQuantity = deliveredQuantity(li)
+			li.Status = determineLIStatus(li)
+			contract.LIs[liIndex] = li
 		}
 	}
-	return res, errors
+
+	return sortCanonical(b.res), b.errors
 }
 
-func determineBatchStatus(batch Batch) string {
-	var totalBatchBufferQty int
-	var totalBatchTestQty float64
-	var totalBatchShortQty float64
-	var totalAvailableQty int
+// liStatuses are the statuses determineLIStatus derives, in lifecycle order.
+var liStatuses = []string{"VENDOR_ACKNOWLEDGED", "PARTIALLY_DELIVERED", "FULLY_DELIVERED", "CLOSED"}
 
-	for _, drumPartition := range batch.DrumPartitions {
-		totalBatchBufferQty += drumPartition.BufferQuantity
-		totalBatchTestQty += drumPartition.TestQuantity
-		totalBatchShortQty += drumPartition.ShortQuantity
-		totalAvailableQty += drumPartition.AvailableQuantity
+// determineLIStatus derives the lifecycle status of an LI from its batches, moving forward as stock is delivered:
+//
+//	VENDOR_ACKNOWLEDGED  no quantity of the LI has an approved batch test yet
+//	PARTIALLY_DELIVERED  some quantity is approved, but less than ordered, or the ordered quantity is unknown
+//	FULLY_DELIVERED      the approved quantity covers the ordered quantity
+//	CLOSED               fully delivered, every batch has settled and no full or partial drum is left in vendor stock
+//
+// A batch has settled when it is neither waiting for documents, rejected nor waiting for a retest.
+func determineLIStatus(li LI) string {
+	if li.DeliveredQuantity <= 0 {
+		return "VENDOR_ACKNOWLEDGED"
 	}
 
-	if float64(batch.TotalQuantity) == float64(totalBatchBufferQty)+totalBatchTestQty+totalBatchShortQty {
-		return "BUFFER"
+	if li.OrderedQuantity <= 0 || li.DeliveredQuantity < li.OrderedQuantity {
+		return "PARTIALLY_DELIVERED"
 	}
 
-	if totalBatchBufferQty > 0 && totalBatchBufferQty < batch.TotalQuantity && totalAvailableQty > 0 {
-		return "PARTIAL_BUFFER"
+	for _, batch := range li.Batches {
+		switch batch.Status {
+		case "DOCS_PENDING_UPLOAD", "REJECTED", "RETEST_PENDING":
+			return "FULLY_DELIVERED"
+		}
+		for _, drumPartition := range batch.DrumPartitions {
+			if drumPartition.AvailableQuantity > 0 || drumPartition.BufferQuantity > 0 || drumPartition.PartialQuantity > 0 {
+				return "FULLY_DELIVERED"
+			}
+		}
 	}
 
-	if totalAvailableQty > 0 {
-		return "AVAILABLE"
-	}
+	return "CLOSED"
+}
 
-	return "DOCS_PENDING_UPLOAD"
+// deliveredQuantity sums the quantity of the LI's batches that has an approved batch test.
+func deliveredQuantity(li LI) int {
+	var delivered int
+	for _, batch := range li.Batches {
+		for _, drumPartition := range batch.DrumPartitions {
+			delivered += drumPartition.Quantity - drumPartition.UnapprovedQuantity
+		}
+	}
+	return delivered
 }
 
-func updateDrumPartition(dp DrumPartition, row CSVRow, rowIndex int) (DrumPartition, []Error) {
+func updateDrumPartition(dp DrumPartition, row CSVRow, rowIndex int, approvedBefore map[int]bool) (DrumPartition, []Error) {
 	// Drum size partition exists, update the existing drum partition
 	var errors []Error
 	var err error
 
 	dp.Quantity += row.TotalQty
+	dp.Remarks = addRemark(dp.Remarks, row.Remarks)
+	dp.RemarkFlags = remarkFlags(dp.Remarks)
+
+	// a drum rejected more than once is listed once per outcome until the approval history is resolved
+	unapproved, unapprovedErrors := unapprovedDrums(row, rowIndex)
+	errors = append(errors, unapprovedErrors...)
+	dp.UnapprovedDrumNumbers = append(append([]int{}, dp.UnapprovedDrumNumbers...), unapproved...)
+
+	// drums of a rejected or retest row stay unapproved
+	if row.rejectsDrums() {
+		dp.UnapprovedQuantity += row.TotalQty
+		return dp, errors
+	}
 
-	dp.AvailableQuantity += row.FullDrumTotalQuantity
-	dp.AvailableDrumNumbers, err = combineSortAndCheckDuplicates(dp.AvailableDrumNumbers, row.AvailableDrumNos)
+	available, availableErrors := availableDrums(row, rowIndex, approvedBefore)
+	errors = append(errors, availableErrors...)
+	dp.AvailableQuantity += dp.DrumSize * len(available)
+	dp.AvailableDrumNumbers, err = combineSortAndCheckDuplicates(dp.AvailableDrumNumbers, available)
 	if err != nil {
 		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("failed to combine, sort and check duplicates: %s", err)})
 	}
@@ -815,23 +1049,23 @@ func updateDrumPartition(dp DrumPartition, row CSVRow, rowIndex int) (DrumPartit
 		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("failed to combine, sort and check duplicates: %s", err)})
 	}
 
-	TestDrumNumbers, TestQuantity, ShortDrumNumbers, ShortQuantity := unpackSampleDrumNos(row.SampleDrumNo, row.SampleLength, dp.DrumSize)
-	if err != nil {
-		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("failed to unpack sample drum nos: %s", err)})
-	}
+	TestDrumNumbers, TestQuantity, ShortDrumNumbers, ShortQuantity := unpackSampleDrumNos(row.SampleDrumNo, row.SampleLength, row.ShortLengths, dp.DrumSize)
+	PartialDrumNumbers, PartialQuantity := unpackPartialDrums(row.SampleDrumNo, row.SampleLength, row.ShortLengths, dp.DrumSize)
 
 	dp.TestQuantity += TestQuantity
 	dp.TestDrumNumbers = append(dp.TestDrumNumbers, TestDrumNumbers...)
 	dp.ShortQuantity += ShortQuantity
 	dp.ShortDrumNumbers = append(dp.ShortDrumNumbers, ShortDrumNumbers...)
+	dp.PartialQuantity += PartialQuantity
+	dp.PartialDrumNumbers = append(dp.PartialDrumNumbers, PartialDrumNumbers...)
 
 	// recalculate unapproved quantity after updating the drum partition
-	unapprovedQty := float64(dp.Quantity) - float64(dp.AvailableQuantity) - float64(dp.BufferQuantity) - dp.TestQuantity - dp.ShortQuantity
+	unapprovedQty := float64(dp.Quantity) - float64(dp.AvailableQuantity) - float64(dp.BufferQuantity) - dp.TestQuantity - dp.ShortQuantity - dp.PartialQuantity
 	dp.UnapprovedQuantity = int(unapprovedQty)
 
 	// validate the updated drum partition
-	if float64(dp.Quantity) != float64(dp.AvailableQuantity)+float64(dp.BufferQuantity)+dp.TestQuantity+dp.ShortQuantity+float64(dp.UnapprovedQuantity) {
-		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("total quantity does not match with available quantity, buffer quantity, test quantity, short quantity, unapproved quantity")})
+	if float64(dp.Quantity) != float64(dp.AvailableQuantity)+float64(dp.BufferQuantity)+dp.TestQuantity+dp.ShortQuantity+dp.PartialQuantity+float64(dp.UnapprovedQuantity) {
+		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("total quantity does not match with available quantity, buffer quantity, test quantity, short quantity, partial quantity, unapproved quantity")})
 	}
 
 	return dp, errors
@@ -844,7 +1078,9 @@ func createNewLI(row CSVRow, rowIndex int) (LI, []Error) {
 		LiCode:          row.LIName.LICode,
 		LiNumber:        row.LIName.LINumber,
 		Description:     row.MaterialDesc,
-		HosApprovalDate: row.LIDate,
+		Unit:            row.Unit,
+		HosApprovalDate: mustParseDate(row.LIDate),
+		OrderedQuantity: row.LIQuantity,
 		Status:          "VENDOR_ACKNOWLEDGED",
 	}
 
@@ -859,8 +1095,7 @@ func createNewBatch(row CSVRow, rowIndex int) (Batch, []Error) {
 	// Add the batch to the new LI
 	newBatch := Batch{
 		BatchNo:        row.BatchNo,
-		SubmissionDate: row.BatchDueDate,
-		Remarks:        row.Remarks,
+		SubmissionDate: mustParseDate(row.BatchDueDate),
 	}
 
 	newBatch.TotalQuantity = row.TotalQty
@@ -871,82 +1106,42 @@ func createNewBatch(row CSVRow, rowIndex int) (Batch, []Error) {
 		errors = append(errors, err...)
 	}
 
-	// if batch test approval date is empty, skip creating a new batch test approval and set the batch test status to "DOCS_PENDING_UPLOAD"
+	// if batch test approval date is empty, skip creating a new batch test approval, the batch waits for its documents
 	if row.BatchTestReportDate == "" {
 		newBatch.BatchTestApprovals = []BatchTestApproval{}
-		newBatch.Status = "DOCS_PENDING_UPLOAD"
 		newBatch.DrumPartitions = append(newBatch.DrumPartitions, newDrumPartition)
-		return newBatch, errors
+		newBatch.Status = determineBatchStatus(newBatch)
+		return aggregateRemarks(newBatch), errors
 	}
 
 	// create new batch test approval and append to newBatch
 	newBatchTestApproval := createBatchTestApproval(row)
 
-	// determine batch status
-	if newDrumPartition.BufferQuantity == 0 && newDrumPartition.AvailableQuantity > 0 {
-		newBatch.Status = "AVAILABLE"
-	} else if float64(newBatch.TotalQuantity)-(float64(newDrumPartition.BufferQuantity)+newDrumPartition.TestQuantity+newDrumPartition.ShortQuantity) == 0 {
-		newBatch.Status = "BUFFER"
-	} else {
-		newBatch.Status = "PARTIAL_BUFFER"
-	}
-	if newDrumPartition.UnapprovedQuantity == newBatch.TotalQuantity {
-		newBatch.Status = "DOCS_PENDING_UPLOAD"
-	}
-
 	newBatch.BatchTestApprovals = append(newBatch.BatchTestApprovals, newBatchTestApproval)
 	newBatch.DrumPartitions = append(newBatch.DrumPartitions, newDrumPartition)
-	return newBatch, errors
+	newBatch.Status = determineBatchStatus(newBatch)
+	return aggregateRemarks(newBatch), errors
 }
 
 func createBatchTestApproval(row CSVRow) BatchTestApproval {
-
-	res := BatchTestApproval{
-		ApprovalDate:    row.BatchTestReportDate,
-		Status:          "APPROVED",
-		ApprovalComment: "Batch Test report uploaded on Go-live phase 1",
-	}
-
-	newBatchTestDrumNumbers := BatchTestDrumNumbers{
-		DrumSize: row.DrumSize,
-	}
-
-	if len(row.SampleDrumNo) > 0 {
-		for i, drumNo := range row.SampleDrumNo {
-			newBatchTestDrumNumbers.DrumNumbers = append(newBatchTestDrumNumbers.DrumNumbers, DrumDetails{
-				DrumNumber: drumNo,
-				Quantity:   row.SampleLength[i],
-			})
-		}
-	}
-
-	res.TestDrumNumbers = append(res.TestDrumNumbers, newBatchTestDrumNumbers)
-
-	newApprovalDrumNumbers := ApprovalDrumNumber{
-		DrumSize:    row.DrumSize,
-		DrumNumbers: row.ApprovedDrumNumbers,
-	}
-
-	if len(row.ApprovedDrumNumbers) == 0 {
-		newApprovalDrumNumbers.DrumNumbers = []int{}
-	}
-
-	res.ApprovalDrumNumbers = append(res.ApprovalDrumNumbers, newApprovalDrumNumbers)
-
+	// a new approval has no drums yet, so adding the row's drums cannot fail
+	res, _ := addToApproval(newBatchTestApproval(row), row)
 	return res
-
 }
 
 func createDrumPartition(row CSVRow, rowIndex int) (DrumPartition, []Error) {
 	var res DrumPartition
 	var errors []Error
 
+	if row.rejectsDrums() {
+		return createUnapprovedDrumPartition(row, rowIndex)
+	}
+
 	res.DrumSize = row.DrumSize
+	res.Unit = row.Unit
+	res.Remarks = addRemark(nil, row.Remarks)
+	res.RemarkFlags = remarkFlags(res.Remarks)
 	res.Quantity = row.TotalQty
-	res.AvailableDrumNumbers = row.ApprovedDrumNumbers
-	if len(row.ApprovedDrumNumbers) == 0 {
-		res.AvailableDrumNumbers = []int{}
-	}
 	res.BufferDrumNumbers = row.BufferDrumNo
 	if len(row.BufferDrumNo) == 0 {
 		res.BufferDrumNumbers = []int{}
@@ -954,24 +1149,32 @@ func createDrumPartition(row CSVRow, rowIndex int) (DrumPartition, []Error) {
 	res.BufferQuantity = res.DrumSize * len(row.BufferDrumNo)
 
 	// unpack sample drum numbers and sample length into test drum numbers, test quantity, short drum numbers and short quantity
-	res.TestDrumNumbers, res.TestQuantity, res.ShortDrumNumbers, res.ShortQuantity = unpackSampleDrumNos(row.SampleDrumNo, row.SampleLength, row.DrumSize)
+	res.TestDrumNumbers, res.TestQuantity, res.ShortDrumNumbers, res.ShortQuantity = unpackSampleDrumNos(row.SampleDrumNo, row.SampleLength, row.ShortLengths, row.DrumSize)
+	res.PartialDrumNumbers, res.PartialQuantity = unpackPartialDrums(row.SampleDrumNo, row.SampleLength, row.ShortLengths, row.DrumSize)
 
-	res.AvailableDrumNumbers = removeDuplicateDrumNumbers(res.AvailableDrumNumbers, row.SampleDrumNo)
-	res.AvailableDrumNumbers = removeDuplicateDrumNumbers(res.AvailableDrumNumbers, row.BufferDrumNo)
+	var availableErrors []Error
+	res.AvailableDrumNumbers, availableErrors = availableDrums(row, rowIndex, nil)
+	errors = append(errors, availableErrors...)
 	res.AvailableQuantity = res.DrumSize * len(res.AvailableDrumNumbers)
 	if len(res.AvailableDrumNumbers) == 0 {
 		res.AvailableDrumNumbers = []int{}
 	}
-	UnapprovedQty := float64(res.Quantity) - float64(res.AvailableQuantity) - float64(res.BufferQuantity) - res.TestQuantity - res.ShortQuantity
+	var unapprovedErrors []Error
+	res.UnapprovedDrumNumbers, unapprovedErrors = unapprovedDrums(row, rowIndex)
+	errors = append(errors, unapprovedErrors...)
+	UnapprovedQty := float64(res.Quantity) - float64(res.AvailableQuantity) - float64(res.BufferQuantity) - res.TestQuantity - res.ShortQuantity - res.PartialQuantity
 	res.UnapprovedQuantity = int(UnapprovedQty)
 
-	if float64(res.Quantity)-float64(res.UnapprovedQuantity)-float64(res.AvailableQuantity)-float64(res.BufferQuantity)-res.TestQuantity-res.ShortQuantity != 0 {
-		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("drum partition total quantity does not match sum of available quantity, buffer quantity, test quantity, short quantity, unapproved quantity")})
+	if float64(res.Quantity)-float64(res.UnapprovedQuantity)-float64(res.AvailableQuantity)-float64(res.BufferQuantity)-res.TestQuantity-res.ShortQuantity-res.PartialQuantity != 0 {
+		errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("drum partition total quantity does not match sum of available quantity, buffer quantity, test quantity, short quantity, partial quantity, unapproved quantity")})
 	}
 	return res, errors
 }
 
-func unpackSampleDrumNos(sampleDrumNumbers []int, sampleLength []float64, drumSize int) ([]DrumDetails, float64, []DrumDetails, float64) {
+// unpackSampleDrumNos turns the sample cuts of a row into test and short drum details. A drum may be listed once
+// per sample cut; every cut is a test piece. The short length of each sampled drum is taken from shortLengths
+// when declared, otherwise everything left after the sample cuts is short.
+func unpackSampleDrumNos(sampleDrumNumbers []int, sampleLength []float64, shortLengths []float64, drumSize int) ([]DrumDetails, float64, []DrumDetails, float64) {
 
 	testDrumNumbers := make([]DrumDetails, 0)
 	var testQuantity float64
@@ -985,53 +1188,85 @@ func unpackSampleDrumNos(sampleDrumNumbers []int, sampleLength []float64, drumSi
 				Quantity:   sampleLength[i],
 			})
 			testQuantity += sampleLength[i]
+		}
 
-			// unpack sample drum numbers and sample length into short drum numbers and short quantity
+		// unpack sample drum numbers and sample length into short drum numbers and short quantity
+		sampleCuts := sumSampleCutsPerDrum(sampleDrumNumbers, sampleLength)
+		for i, drumNo := range distinctDrumNumbers(sampleDrumNumbers) {
+			shortLength := float64(drumSize) - sampleCuts[drumNo]
+			if len(shortLengths) > 0 && i < len(shortLengths) {
+				shortLength = shortLengths[i]
+			}
 			shortDrumNumbers = append(shortDrumNumbers, DrumDetails{
 				DrumNumber: drumNo,
-				Quantity:   float64(drumSize) - sampleLength[i],
+				Quantity:   shortLength,
 			})
-			shortQuantity += float64(drumSize) - sampleLength[i]
+			shortQuantity += shortLength
 		}
 	}
 	return testDrumNumbers, testQuantity, shortDrumNumbers, shortQuantity
 }
 
-func findContractIndex(slice []Contracts, contractNo string) int {
-	for i, value := range slice {
-		if value.ContractNo == contractNo {
-			return i
-		}
+// unpackPartialDrums returns what is left of each sample drum after its sample cuts and declared short length.
+// The remainder stays available, it is only non-zero when the short lengths are declared per drum.
+func unpackPartialDrums(sampleDrumNumbers []int, sampleLength []float64, shortLengths []float64, drumSize int) ([]DrumDetails, float64) {
+	partialDrumNumbers := make([]DrumDetails, 0)
+	var partialQuantity float64
+
+	if len(shortLengths) == 0 {
+		return partialDrumNumbers, partialQuantity
 	}
-	return -1
-}
 
-func findLiIndex(lis []LI, liNo LIName) int {
-	liName := liNo.LICode + liNo.LINumber
-	for i, li := range lis {
-		if li.LiCode+li.LiNumber == liName {
-			return i
+	sampleCuts := sumSampleCutsPerDrum(sampleDrumNumbers, sampleLength)
+	for i, drumNo := range distinctDrumNumbers(sampleDrumNumbers) {
+		if i >= len(shortLengths) {
+			break
+		}
+		remainder := float64(drumSize) - sampleCuts[drumNo] - shortLengths[i]
+		if remainder > 0 {
+			partialDrumNumbers = append(partialDrumNumbers, DrumDetails{
+				DrumNumber: drumNo,
+				Quantity:   remainder,
+			})
+			partialQuantity += remainder
 		}
 	}
-	return -1
+	return partialDrumNumbers, partialQuantity
 }
 
-func findBatchIndex(batches []Batch, no string) int {
-	for i, value := range batches {
-		if value.BatchNo == no {
-			return i
+// validateSampleCuts checks that the pieces of every sample drum, sample cuts, short length and the remainder
+// that stays available, add up to the drum size.
+func validateSampleCuts(sampleDrumNumbers []int, sampleLength []float64, shortLengths []float64, drumSize int) error {
+	sampleCuts := sumSampleCutsPerDrum(sampleDrumNumbers, sampleLength)
+	for i, drumNo := range distinctDrumNumbers(sampleDrumNumbers) {
+		var shortLength float64
+		if len(shortLengths) > 0 {
+			shortLength = shortLengths[i]
+		}
+		if sampleCuts[drumNo] <= 0 || shortLength < 0 {
+			return fmt.Errorf("sample and short lengths of drum %d must be positive", drumNo)
+		}
+		if sampleCuts[drumNo]+shortLength > float64(drumSize) {
+			return fmt.Errorf("sample and short lengths of drum %d exceed drum size", drumNo)
 		}
 	}
-	return -1
+	return nil
 }
 
-func findBatchTestApprovalIndex(bta []BatchTestApproval, date string) int {
-	for i, value := range bta {
-		if value.ApprovalDate == date {
-			return i
+func sumSampleCutsPerDrum(sampleDrumNumbers []int, sampleLength []float64) map[int]float64 {
+	res := make(map[int]float64)
+	for i, drumNo := range sampleDrumNumbers {
+		if i < len(sampleLength) {
+			res[drumNo] += sampleLength[i]
 		}
 	}
-	return -1
+	return res
+}
+
+// partialLengthTotalQty is the quantity left on the row's sample drums after sample cuts and declared short lengths.
+func (row *CSVRow) partialLengthTotalQty() float64 {
+	_, partialQuantity := unpackPartialDrums(row.SampleDrumNo, row.SampleLength, row.ShortLengths, row.DrumSize)
+	return partialQuantity
 }
 
 func unpackDrumNoRange(str string) ([]int, error) {
@@ -1097,6 +1332,19 @@ func combineSortAndCheckDuplicates(slices ...[]int) ([]int, error) {
 	return combined, nil
 }
 
+// distinctDrumNumbers returns the drum numbers without repeats, in order of first appearance.
+func distinctDrumNumbers(drumNumbers []int) []int {
+	seen := make(map[int]bool)
+	result := make([]int, 0)
+	for _, num := range drumNumbers {
+		if !seen[num] {
+			seen[num] = true
+			result = append(result, num)
+		}
+	}
+	return result
+}
+
 func removeDuplicateDrumNumbers(slice []int, dupSlice []int) []int {
 	dupMap := make(map[int]bool)
 	for _, num := range dupSlice {
@@ -1113,6 +1361,17 @@ func removeDuplicateDrumNumbers(slice []int, dupSlice []int) []int {
 	return result
 }
 
+// parseSampleDrum reads the Sample Drum (Yes/No) flag in any case.
+func parseSampleDrum(raw string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "yes", "y":
+		return true, nil
+	case "no", "n", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("unknown value %q", raw)
+}
+
 func stringToFloat64Slice(str string) ([]float64, error) {
 	// If the string is empty or contains only spaces, return an empty slice and nil error
 	if strings.TrimSpace(str) == "0" {
@@ -1139,6 +1398,24 @@ func sumFloat64Slice(length []float64) float64 {
 	return sum
 }
 
+// outputOptions select how the records are written.
+type outputOptions struct {
+	canonical  bool
+	dateLayout string // ISO-8601 when empty
+}
+
+// marshalRecords converts the records to indented JSON, or to canonical JSON when asked for.
+func marshalRecords(records UploadInventoryInput, options outputOptions) ([]byte, error) {
+	records.SchemaVersion = inventorySchemaVersion
+	if options.dateLayout != "" {
+		records = withDateLayout(records, options.dateLayout)
+	}
+	if options.canonical {
+		return recordsToCanonicalJSON(records)
+	}
+	return recordsToJSON(records)
+}
+
 // recordsToJSON converts a slice of records to JSON format
 func recordsToJSON(records UploadInventoryInput) ([]byte, error) {
 	// Marshal the records to JSON
@@ -1151,13 +1428,14 @@ func recordsToJSON(records UploadInventoryInput) ([]byte, error) {
 }
 
 func validateDateFormat(date string) bool {
-	_, err := time.Parse("02-01-2006", date)
+	_, err := time.Parse(legacyDateLayout, date)
 	return err == nil
 }
 
+var batchNoPattern = regexp.MustCompile(`^\d{1,2}/\d{1,2}$`)
+
 func validateBatchNoFormat(batchNo string) bool {
-	re := regexp.MustCompile(`^\d{1,2}/\d{1,2}$`)
-	return re.MatchString(batchNo)
+	return batchNoPattern.MatchString(batchNo)
 }
 
 func validateDrumSize(drumSize int) bool {
@@ -1173,31 +1451,9 @@ func validateDrumSize(drumSize int) bool {
 	return false
 }
 
+// validateOverlappingDrumNumbers checks that no drum number is approved twice for a material code within a contract.
 func (u UploadInventoryInput) validateOverlappingDrumNumbers() []Error {
-
-	errors := make([]Error, 0)
-	for _, contract := range u.Contracts {
-
-		matCodeMap := make(map[string][][]int)
-
-		for _, li := range contract.LIs {
-			if _, ok := matCodeMap[li.MaterialCode]; !ok {
-				matCodeMap[li.MaterialCode] = collectApprovedDrumNumbers(li, li.MaterialCode)
-			} else {
-				matCodeMap[li.MaterialCode] = append(matCodeMap[li.MaterialCode], collectApprovedDrumNumbers(li, li.MaterialCode)...)
-			}
-		}
-
-		for matCode, collatedDrumNos := range matCodeMap {
-
-			_, err := combineSortAndCheckDuplicates(collatedDrumNos...)
-			if err != nil {
-				errors = append(errors, Error{RowNo: 0, Err: fmt.Errorf("overlapping drum numbers found for material code: %s, %v", matCode, err)})
-			}
-		}
-
-	}
-	return errors
+	return u.validateDrumOverlaps(overlapScopeContract)
 }
 
 func collectApprovedDrumNumbers(li LI, materialCode string) [][]int {
@@ -1205,8 +1461,8 @@ func collectApprovedDrumNumbers(li LI, materialCode string) [][]int {
 
 	if li.MaterialCode == materialCode {
 		for _, batch := range li.Batches {
-			for _, batchTestApproval := range batch.BatchTestApprovals {
-				for _, approvalDrumNumber := range batchTestApproval.ApprovalDrumNumbers {
+			for batchTestApprovalIndex := range batch.BatchTestApprovals {
+				for _, approvalDrumNumber := range approvedDrumNumbersOf(batch, batchTestApprovalIndex) {
 					approvedDrumNumbers = append(approvedDrumNumbers, approvalDrumNumber.DrumNumbers)
 				}
 			}
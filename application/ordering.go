@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sortCanonical puts the inventory in its canonical order so that the same data always produces the same output,
+// whatever the order of the rows it was read from:
+//   - contracts by contract no.
+//   - LIs by LI code, then LI number
+//   - batches by batch number, compared numerically ("9/11" before "10/11")
+//   - drum partitions by drum size
+//   - batch test approvals by approval date, compared as dates, then by status
+//   - drum numbers and drum details by drum number
+func sortCanonical(u UploadInventoryInput) UploadInventoryInput {
+	sort.SliceStable(u.Contracts, func(i, j int) bool {
+		return compareNumericStrings(u.Contracts[i].ContractNo, u.Contracts[j].ContractNo) < 0
+	})
+
+	for _, contract := range u.Contracts {
+		sort.SliceStable(contract.LIs, func(i, j int) bool {
+			if c := compareNumericStrings(contract.LIs[i].LiCode, contract.LIs[j].LiCode); c != 0 {
+				return c < 0
+			}
+			return compareNumericStrings(contract.LIs[i].LiNumber, contract.LIs[j].LiNumber) < 0
+		})
+
+		for _, li := range contract.LIs {
+			sort.SliceStable(li.Batches, func(i, j int) bool {
+				return compareBatchNo(li.Batches[i].BatchNo, li.Batches[j].BatchNo) < 0
+			})
+
+			for _, batch := range li.Batches {
+				sortBatchCanonical(batch)
+			}
+		}
+	}
+	return u
+}
+
+func sortBatchCanonical(batch Batch) {
+	sort.SliceStable(batch.DrumPartitions, func(i, j int) bool {
+		return batch.DrumPartitions[i].DrumSize < batch.DrumPartitions[j].DrumSize
+	})
+	for _, dp := range batch.DrumPartitions {
+		sort.Ints(dp.AvailableDrumNumbers)
+		sort.Ints(dp.BufferDrumNumbers)
+		sort.Ints(dp.UnapprovedDrumNumbers)
+		sortDrumDetails(dp.TestDrumNumbers)
+		sortDrumDetails(dp.ShortDrumNumbers)
+		sortDrumDetails(dp.PartialDrumNumbers)
+	}
+
+	sort.SliceStable(batch.BatchTestApprovals, func(i, j int) bool {
+		a, b := batch.BatchTestApprovals[i], batch.BatchTestApprovals[j]
+		if !a.ApprovalDate.Equal(b.ApprovalDate) {
+			return a.ApprovalDate.Before(b.ApprovalDate)
+		}
+		return a.Status < b.Status
+	})
+	for _, bta := range batch.BatchTestApprovals {
+		sort.SliceStable(bta.TestDrumNumbers, func(i, j int) bool {
+			return bta.TestDrumNumbers[i].DrumSize < bta.TestDrumNumbers[j].DrumSize
+		})
+		for _, testDrumNumber := range bta.TestDrumNumbers {
+			sortDrumDetails(testDrumNumber.DrumNumbers)
+		}
+		sort.SliceStable(bta.ApprovalDrumNumbers, func(i, j int) bool {
+			return bta.ApprovalDrumNumbers[i].DrumSize < bta.ApprovalDrumNumbers[j].DrumSize
+		})
+		for _, approvalDrumNumber := range bta.ApprovalDrumNumbers {
+			sort.Ints(approvalDrumNumber.DrumNumbers)
+		}
+	}
+}
+
+// sortDrumDetails orders drum details by drum number, the sample cuts of one drum keep their order.
+func sortDrumDetails(details []DrumDetails) {
+	sort.SliceStable(details, func(i, j int) bool {
+		return details[i].DrumNumber < details[j].DrumNumber
+	})
+}
+
+// compareBatchNo compares batch numbers such as "6/11" part by part, numerically where both parts are numbers.
+func compareBatchNo(a, b string) int {
+	aParts := strings.Split(a, "/")
+	bParts := strings.Split(b, "/")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		if c := compareNumericStrings(aParts[i], bParts[i]); c != 0 {
+			return c
+		}
+	}
+	return len(aParts) - len(bParts)
+}
+
+// compareNumericStrings compares two strings as integers when both are integers, as strings otherwise.
+func compareNumericStrings(a, b string) int {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	if !isInteger(a) || !isInteger(b) {
+		return strings.Compare(a, b)
+	}
+	aNum, aErr := strconv.Atoi(a)
+	bNum, bErr := strconv.Atoi(b)
+	if aErr == nil && bErr == nil {
+		switch {
+		case aNum < bNum:
+			return -1
+		case aNum > bNum:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// isInteger reports whether str is written as an integer, without parsing it, so that comparing the many strings
+// that are not costs no error.
+func isInteger(str string) bool {
+	str = strings.TrimPrefix(strings.TrimPrefix(str, "-"), "+")
+	if str == "" {
+		return false
+	}
+	for _, r := range str {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// recordsToCanonicalJSON converts the records to canonical JSON: canonical order, object keys sorted, no
+// insignificant whitespace and no HTML escaping. Two runs on the same data give byte-identical output.
+func recordsToCanonicalJSON(records UploadInventoryInput) ([]byte, error) {
+	jsonData, err := json.Marshal(sortCanonical(records))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal records to JSON: %w", err)
+	}
+	return canonicalizeJSON(jsonData)
+}
+
+// canonicalizeJSON rewrites a JSON document with its object keys sorted and without insignificant whitespace.
+// Numbers are kept exactly as they were written.
+func canonicalizeJSON(jsonData []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, fmt.Errorf("failed to encode canonical JSON: %w", err)
+	}
+	return buf.Bytes(), nil
+}
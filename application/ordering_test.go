@@ -0,0 +1,105 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_compareBatchNo(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want int
+	}{
+		{name: "numeric batch before", a: "9/11", b: "10/11", want: -1},
+		{name: "numeric batch after", a: "10/11", b: "9/11", want: 1},
+		{name: "same batch", a: "6/11", b: "6/11", want: 0},
+		{name: "same batch, different total", a: "6/9", b: "6/11", want: -1},
+		{name: "non numeric batch", a: "B001", b: "B002", want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, compareBatchNo(tt.a, tt.b))
+		})
+	}
+}
+
+func Test_sortCanonical(t *testing.T) {
+	input := UploadInventoryInput{
+		Contracts: []Contracts{
+			{
+				ContractNo: "9190370",
+				LIs: []LI{
+					{LiCode: "Li", LiNumber: "10"},
+					{
+						LiCode:   "Li",
+						LiNumber: "2",
+						Batches: []Batch{
+							{
+								BatchNo: "10/11",
+								BatchTestApprovals: []BatchTestApproval{
+									{ApprovalDate: testDate("14-11-2025")},
+									{ApprovalDate: testDate("30-12-2024")},
+								},
+							},
+							{
+								BatchNo: "9/11",
+								DrumPartitions: []DrumPartition{
+									{DrumSize: 300},
+									{
+										DrumSize:          250,
+										BufferDrumNumbers: []int{5, 4},
+										TestDrumNumbers:   []DrumDetails{{DrumNumber: 7, Quantity: 2.5}, {DrumNumber: 6, Quantity: 1}, {DrumNumber: 6, Quantity: 2}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			{ContractNo: "9190369"},
+		},
+	}
+
+	got := sortCanonical(input)
+
+	assert.Equal(t, "9190369", got.Contracts[0].ContractNo)
+	lis := got.Contracts[1].LIs
+	assert.Equal(t, []string{"2", "10"}, []string{lis[0].LiNumber, lis[1].LiNumber})
+	assert.Equal(t, []string{"9/11", "10/11"}, []string{lis[0].Batches[0].BatchNo, lis[0].Batches[1].BatchNo})
+	assert.Equal(t, testDate("30-12-2024"), lis[0].Batches[1].BatchTestApprovals[0].ApprovalDate)
+	assert.Equal(t, 250, lis[0].Batches[0].DrumPartitions[0].DrumSize)
+	assert.Equal(t, []int{4, 5}, lis[0].Batches[0].DrumPartitions[0].BufferDrumNumbers)
+	assert.Equal(t, []DrumDetails{{DrumNumber: 6, Quantity: 1}, {DrumNumber: 6, Quantity: 2}, {DrumNumber: 7, Quantity: 2.5}}, lis[0].Batches[0].DrumPartitions[0].TestDrumNumbers)
+}
+
+func Test_recordsToCanonicalJSON(t *testing.T) {
+	rows := []CSVRow{
+		approvalTestRow("14-11-2025", "APPROVED", []int{3, 4}),
+		approvalTestRow("30-12-2024", "APPROVED", []int{1, 2}),
+	}
+	rows[1].BatchNo = "10/11"
+	rows[1].ContractNo = "B123"
+
+	first, errs := processRows(rows)
+	assert.Empty(t, errs)
+	second, errs := processRows([]CSVRow{rows[1], rows[0]})
+	assert.Empty(t, errs)
+
+	firstJSON, err := recordsToCanonicalJSON(first)
+	assert.NoError(t, err)
+	secondJSON, err := recordsToCanonicalJSON(second)
+	assert.NoError(t, err)
+
+	assert.Equal(t, string(firstJSON), string(secondJSON))
+	assert.Equal(t, byte('\n'), firstJSON[len(firstJSON)-1])
+	assert.NotContains(t, string(firstJSON), "\n  ")
+}
+
+func Test_canonicalizeJSON(t *testing.T) {
+	got, err := canonicalizeJSON([]byte(`{"b": [2, 1.50], "a": {"d": "<x>", "c": 1e3}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"a\":{\"c\":1e3,\"d\":\"<x>\"},\"b\":[2,1.50]}\n", string(got))
+}
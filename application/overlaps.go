@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Overlap scopes, the group of drums within which a drum number may only be approved once.
+const (
+	overlapScopeLI       = "li"       // per LI
+	overlapScopeContract = "contract" // per material code within a contract
+	overlapScopeMaterial = "material" // per material code across contracts
+	overlapScopeVendor   = "vendor"   // per vendor and material code across contracts
+)
+
+// drumClaim is one approval of a drum number by a batch.
+type drumClaim struct {
+	Vendor       string
+	ContractNo   string
+	LiName       string
+	MaterialCode string
+	BatchNo      string
+	DrumSize     int
+	ApprovalDate Date
+	File         string // vendor file the claim was read from, in batch mode
+	Sources      []RowSource
+}
+
+func (c drumClaim) String() string {
+	str := fmt.Sprintf("contract %s LI %s batch %s drum size %d approved %s", c.ContractNo, c.LiName, c.BatchNo, c.DrumSize, c.ApprovalDate)
+	if len(c.Sources) > 0 {
+		refs := make([]string, 0, len(c.Sources))
+		for _, source := range c.Sources {
+			refs = append(refs, source.String())
+		}
+		str += " (" + strings.Join(refs, ", ") + ")"
+	} else if c.File != "" {
+		str += " (" + c.File + ")"
+	}
+	return str
+}
+
+// parseOverlapScope selects the overlap scope by name.
+func parseOverlapScope(name string) (string, error) {
+	switch scope := strings.ToLower(strings.TrimSpace(name)); scope {
+	case overlapScopeLI, overlapScopeContract, overlapScopeMaterial, overlapScopeVendor:
+		return scope, nil
+	}
+	return "", fmt.Errorf("unknown overlap scope: %s", name)
+}
+
+// scopeOf names the group a claim belongs to in the scope.
+func scopeOf(scope string, claim drumClaim) string {
+	switch scope {
+	case overlapScopeLI:
+		return fmt.Sprintf("contract %s LI %s", claim.ContractNo, claim.LiName)
+	case overlapScopeMaterial:
+		return fmt.Sprintf("material code %s", claim.MaterialCode)
+	case overlapScopeVendor:
+		return fmt.Sprintf("vendor %s material code %s", claim.Vendor, claim.MaterialCode)
+	}
+	return fmt.Sprintf("contract %s material code %s", claim.ContractNo, claim.MaterialCode)
+}
+
+// collectDrumClaims lists the drums each batch still holds approved, per drum number, with the rows that approved
+// them when the sources of the rows are known.
+func (u UploadInventoryInput) collectDrumClaims() map[int][]drumClaim {
+	claims := make(map[int][]drumClaim)
+	for _, contract := range u.Contracts {
+		for _, li := range contract.LIs {
+			for _, batch := range li.Batches {
+				for approvalIndex, bta := range batch.BatchTestApprovals {
+					for _, approvalDrumNumber := range approvedDrumNumbersOf(batch, approvalIndex) {
+						for _, drumNo := range approvalDrumNumber.DrumNumbers {
+							claims[drumNo] = append(claims[drumNo], drumClaim{
+								Vendor:       contract.Vendor,
+								ContractNo:   contract.ContractNo,
+								LiName:       li.LiCode + "-" + li.LiNumber,
+								MaterialCode: li.MaterialCode,
+								BatchNo:      batch.BatchNo,
+								DrumSize:     approvalDrumNumber.DrumSize,
+								ApprovalDate: bta.ApprovalDate,
+								Sources:      sourcesListing(approvalDrumNumber.Sources, drumNo),
+							})
+						}
+					}
+				}
+			}
+		}
+	}
+	return claims
+}
+
+// validateDrumOverlaps reports each drum number approved more than once within a group of the scope, naming every
+// contract, LI and batch that claims it.
+func (u UploadInventoryInput) validateDrumOverlaps(scope string) []Error {
+	return drumOverlaps(u.collectDrumClaims(), scope, func(claims []drumClaim) bool {
+		return len(claims) > 1
+	})
+}
+
+// drumOverlaps reports the drum numbers whose claims within a group of the scope overlap, naming every claim.
+func drumOverlaps(claims map[int][]drumClaim, scope string, overlapping func(claims []drumClaim) bool) []Error {
+	drumNumbers := make([]int, 0, len(claims))
+	for drumNo := range claims {
+		drumNumbers = append(drumNumbers, drumNo)
+	}
+	sort.Ints(drumNumbers)
+
+	// overlapping claims per group, groups in order of their first overlap
+	var groups []string
+	overlaps := make(map[string][]string)
+	for _, drumNo := range drumNumbers {
+		byGroup := make(map[string][]drumClaim)
+		var groupOrder []string
+		for _, claim := range claims[drumNo] {
+			group := scopeOf(scope, claim)
+			if _, ok := byGroup[group]; !ok {
+				groupOrder = append(groupOrder, group)
+			}
+			byGroup[group] = append(byGroup[group], claim)
+		}
+
+		for _, group := range groupOrder {
+			if !overlapping(byGroup[group]) {
+				continue
+			}
+			if _, ok := overlaps[group]; !ok {
+				groups = append(groups, group)
+			}
+			claimedBy := make([]string, 0, len(byGroup[group]))
+			for _, claim := range byGroup[group] {
+				claimedBy = append(claimedBy, claim.String())
+			}
+			overlaps[group] = append(overlaps[group], fmt.Sprintf("drum %d claimed by %s", drumNo, strings.Join(claimedBy, "; ")))
+		}
+	}
+
+	sort.Strings(groups)
+	errors := make([]Error, 0)
+	for _, group := range groups {
+		for _, overlap := range overlaps[group] {
+			errors = append(errors, Error{RowNo: 0, Err: fmt.Errorf("overlapping drum numbers found for %s: %s", group, overlap)})
+		}
+	}
+	return errors
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadInventoryInput_validateDrumOverlaps(t *testing.T) {
+	approved := func(drumNumbers ...int) []BatchTestApproval {
+		return []BatchTestApproval{testApproval("30-12-2024", "APPROVED", 250, drumNumbers...)}
+	}
+	u := testInventory(
+		testContract("9190369", "ABC",
+			testLI("1", "101642", "", testBatch("6/11", approved(1, 2)), testBatch("7/11", approved(2))),
+			testLI("2", "101642", "", testBatch("1/11", approved(3))),
+		),
+		testContract("9190370", "ABC", testLI("1", "101642", "", testBatch("1/11", approved(3)))),
+		testContract("9190371", "XYZ", testLI("1", "101642", "", testBatch("1/11", approved(1)))),
+	)
+
+	drum2 := "drum 2 claimed by contract 9190369 LI Li-1 batch 6/11 drum size 250 approved 2024-12-30; contract 9190369 LI Li-1 batch 7/11 drum size 250 approved 2024-12-30"
+	tests := []struct {
+		scope string
+		want  []string
+	}{
+		{
+			scope: overlapScopeLI,
+			want:  []string{"overlapping drum numbers found for contract 9190369 LI Li-1: " + drum2},
+		},
+		{
+			scope: overlapScopeContract,
+			want:  []string{"overlapping drum numbers found for contract 9190369 material code 101642: " + drum2},
+		},
+		{
+			scope: overlapScopeVendor,
+			want: []string{
+				"overlapping drum numbers found for vendor ABC material code 101642: " + drum2,
+				"overlapping drum numbers found for vendor ABC material code 101642: drum 3 claimed by contract 9190369 LI Li-2 batch 1/11 drum size 250 approved 2024-12-30; contract 9190370 LI Li-1 batch 1/11 drum size 250 approved 2024-12-30",
+			},
+		},
+		{
+			scope: overlapScopeMaterial,
+			want: []string{
+				"overlapping drum numbers found for material code 101642: drum 1 claimed by contract 9190369 LI Li-1 batch 6/11 drum size 250 approved 2024-12-30; contract 9190371 LI Li-1 batch 1/11 drum size 250 approved 2024-12-30",
+				"overlapping drum numbers found for material code 101642: " + drum2,
+				"overlapping drum numbers found for material code 101642: drum 3 claimed by contract 9190369 LI Li-2 batch 1/11 drum size 250 approved 2024-12-30; contract 9190370 LI Li-1 batch 1/11 drum size 250 approved 2024-12-30",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			var got []string
+			for _, e := range u.validateDrumOverlaps(tt.scope) {
+				assert.Equal(t, 0, e.RowNo)
+				got = append(got, e.Err.Error())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_parseOverlapScope(t *testing.T) {
+	scope, err := parseOverlapScope("Vendor")
+	assert.NoError(t, err)
+	assert.Equal(t, overlapScopeVendor, scope)
+
+	_, err = parseOverlapScope("batch")
+	assert.Error(t, err)
+}
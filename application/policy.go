@@ -0,0 +1,294 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+)
+
+// Outcomes of checking the buffer of an LI or batch against its policy
+const (
+	bufferShortfall = "SHORTFALL"
+	bufferExcess    = "EXCESS"
+)
+
+// bufferPolicy is the buffer a vendor has to hold under a contract, for a material or for all materials of the
+// contract. An empty contract or material code matches any. A policy asks either for at least a percentage of the
+// quantity, of the LI and of each batch, or for at least a number of buffer drums of each drum size in each batch.
+// It may also cap the buffer at a higher percentage or number of drums; without a cap any buffer above the minimum
+// is fine.
+type bufferPolicy struct {
+	ContractNo           string  `json:"contract_no,omitempty"`
+	MaterialCode         string  `json:"material_code,omitempty"`
+	PercentOfQuantity    float64 `json:"percent_of_quantity,omitempty"`
+	MaxPercentOfQuantity float64 `json:"max_percent_of_quantity,omitempty"`
+	DrumsPerSize         int     `json:"drums_per_size,omitempty"`
+	MaxDrumsPerSize      int     `json:"max_drums_per_size,omitempty"`
+}
+
+// String describes the rule of the policy.
+func (p bufferPolicy) String() string {
+	if p.DrumsPerSize > 0 {
+		if p.MaxDrumsPerSize > 0 {
+			return fmt.Sprintf("%d to %d drums per size", p.DrumsPerSize, p.MaxDrumsPerSize)
+		}
+		return fmt.Sprintf("at least %d drums per size", p.DrumsPerSize)
+	}
+	percent := strconv.FormatFloat(p.PercentOfQuantity, 'f', -1, 64)
+	if p.MaxPercentOfQuantity > 0 {
+		return fmt.Sprintf("%s%% to %s%% of quantity", percent, strconv.FormatFloat(p.MaxPercentOfQuantity, 'f', -1, 64))
+	}
+	return fmt.Sprintf("at least %s%% of quantity", percent)
+}
+
+// specificity ranks the policies matching an LI, a policy for the contract and material is the most specific.
+func (p bufferPolicy) specificity() int {
+	rank := 0
+	if p.MaterialCode != "" {
+		rank += 2
+	}
+	if p.ContractNo != "" {
+		rank++
+	}
+	return rank
+}
+
+func (p bufferPolicy) matches(contractNo, materialCode string) bool {
+	return (p.ContractNo == "" || p.ContractNo == contractNo) && (p.MaterialCode == "" || p.MaterialCode == materialCode)
+}
+
+// readBufferPolicies reads a list of buffer policies as JSON. Every policy has exactly one rule, capped only by a
+// maximum of the same kind that is not below it, and no two policies are for the same contract and material.
+func readBufferPolicies(reader io.Reader) ([]bufferPolicy, error) {
+	var policies []bufferPolicy
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&policies); err != nil {
+		return nil, fmt.Errorf("failed to read buffer policies: %w", err)
+	}
+
+	seen := make(map[[2]string]bool)
+	for i, p := range policies {
+		switch {
+		case p.PercentOfQuantity < 0 || p.PercentOfQuantity > 100:
+			return nil, fmt.Errorf("buffer policy %d: percent of quantity %g is not between 0 and 100", i+1, p.PercentOfQuantity)
+		case p.DrumsPerSize < 0:
+			return nil, fmt.Errorf("buffer policy %d: drums per size %d is negative", i+1, p.DrumsPerSize)
+		case (p.PercentOfQuantity > 0) == (p.DrumsPerSize > 0):
+			return nil, fmt.Errorf("buffer policy %d: set either percent_of_quantity or drums_per_size", i+1)
+		case (p.MaxPercentOfQuantity != 0 && p.PercentOfQuantity == 0) || (p.MaxDrumsPerSize != 0 && p.DrumsPerSize == 0):
+			return nil, fmt.Errorf("buffer policy %d: cap percent_of_quantity with max_percent_of_quantity and drums_per_size with max_drums_per_size", i+1)
+		case p.MaxPercentOfQuantity != 0 && (p.MaxPercentOfQuantity < p.PercentOfQuantity || p.MaxPercentOfQuantity > 100):
+			return nil, fmt.Errorf("buffer policy %d: max percent of quantity %g is not between %g and 100", i+1, p.MaxPercentOfQuantity, p.PercentOfQuantity)
+		case p.MaxDrumsPerSize != 0 && p.MaxDrumsPerSize < p.DrumsPerSize:
+			return nil, fmt.Errorf("buffer policy %d: max drums per size %d is below drums per size %d", i+1, p.MaxDrumsPerSize, p.DrumsPerSize)
+		}
+		scope := [2]string{p.ContractNo, p.MaterialCode}
+		if seen[scope] {
+			return nil, fmt.Errorf("buffer policy %d: more than one policy for contract %q and material code %q", i+1, p.ContractNo, p.MaterialCode)
+		}
+		seen[scope] = true
+	}
+	return policies, nil
+}
+
+// policyFor finds the most specific policy for the material of a contract.
+func policyFor(policies []bufferPolicy, contractNo, materialCode string) (bufferPolicy, bool) {
+	var found bufferPolicy
+	ok := false
+	for _, p := range policies {
+		if p.matches(contractNo, materialCode) && (!ok || p.specificity() > found.specificity()) {
+			found, ok = p, true
+		}
+	}
+	return found, ok
+}
+
+// bufferFinding is an LI, a batch or a drum size of a batch holding less buffer than its policy requires, or more
+// than it allows. Maximum is zero when the policy sets no cap.
+type bufferFinding struct {
+	Level        string
+	ContractNo   string
+	MaterialCode string
+	LI           string
+	BatchNo      string
+	DrumSize     int
+	Unit         string
+	Policy       string
+	Required     int
+	Maximum      int
+	Buffer       int
+}
+
+// Status tells a shortfall from an excess.
+func (f bufferFinding) Status() string {
+	if f.Buffer < f.Required {
+		return bufferShortfall
+	}
+	return bufferExcess
+}
+
+// outOfPolicy tells whether the buffer is below the minimum or above the maximum.
+func (f bufferFinding) outOfPolicy() bool {
+	return f.Buffer < f.Required || (f.Maximum > 0 && f.Buffer > f.Maximum)
+}
+
+// Difference is how far the buffer is past the bound it breaks, negative for a shortfall.
+func (f bufferFinding) Difference() int {
+	if f.Buffer < f.Required {
+		return f.Buffer - f.Required
+	}
+	return f.Buffer - f.Maximum
+}
+
+// Error describes the finding for the conversion log.
+func (f bufferFinding) Error() string {
+	where := fmt.Sprintf("LI %s of contract %s", f.LI, f.ContractNo)
+	if f.BatchNo != "" {
+		where = fmt.Sprintf("batch %s of %s", f.BatchNo, where)
+	}
+	if f.DrumSize > 0 {
+		where = fmt.Sprintf("drum size %d of %s", f.DrumSize, where)
+	}
+	if f.Status() == bufferShortfall {
+		return fmt.Sprintf("buffer shortfall of %d %s for %s: %d held, at least %d required by %s",
+			absInt(f.Difference()), f.Unit, where, f.Buffer, f.Required, f.Policy)
+	}
+	return fmt.Sprintf("buffer excess of %d %s for %s: %d held, at most %d allowed by %s",
+		f.Difference(), f.Unit, where, f.Buffer, f.Maximum, f.Policy)
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// percentOf is the buffer a percentage of a quantity requires or allows. It is rounded up for the minimum and the cap
+// alike, so a cap is never below the minimum.
+func percentOf(percent float64, quantity int) int {
+	return int(math.Ceil(percent * float64(quantity) / 100))
+}
+
+// evaluateBufferPolicies checks the buffer held for each LI and batch against the policy for its contract and
+// material, and returns where it falls short of the minimum or exceeds the maximum. The LI quantity is its ordered
+// quantity, or what has been delivered when the order is not known. LIs without a policy are not checked.
+func evaluateBufferPolicies(records UploadInventoryInput, policies []bufferPolicy) []bufferFinding {
+	findings := make([]bufferFinding, 0)
+	for _, contract := range records.Contracts {
+		for _, li := range contract.LIs {
+			policy, ok := policyFor(policies, contract.ContractNo, li.MaterialCode)
+			if !ok {
+				continue
+			}
+			base := bufferFinding{ContractNo: contract.ContractNo, MaterialCode: li.MaterialCode, LI: li.LiCode + "-" + li.LiNumber, Unit: li.Unit, Policy: policy.String()}
+
+			liFinding := base
+			liFinding.Level = summaryLevelLI
+			var batchFindings []bufferFinding
+			for _, batch := range li.Batches {
+				for _, dp := range batch.DrumPartitions {
+					liFinding.Buffer += dp.BufferQuantity
+				}
+				if policy.DrumsPerSize > 0 {
+					for _, dp := range batch.DrumPartitions {
+						finding := base
+						finding.Level, finding.BatchNo, finding.DrumSize = summaryLevelDrumSize, batch.BatchNo, dp.DrumSize
+						finding.Required, finding.Maximum, finding.Buffer = policy.DrumsPerSize*dp.DrumSize, policy.MaxDrumsPerSize*dp.DrumSize, dp.BufferQuantity
+						liFinding.Required += finding.Required
+						liFinding.Maximum += finding.Maximum
+						batchFindings = append(batchFindings, finding)
+					}
+					continue
+				}
+				finding := base
+				finding.Level, finding.BatchNo = summaryLevelBatch, batch.BatchNo
+				finding.Required = percentOf(policy.PercentOfQuantity, batch.TotalQuantity)
+				finding.Maximum = percentOf(policy.MaxPercentOfQuantity, batch.TotalQuantity)
+				for _, dp := range batch.DrumPartitions {
+					finding.Buffer += dp.BufferQuantity
+				}
+				batchFindings = append(batchFindings, finding)
+			}
+			if policy.PercentOfQuantity > 0 {
+				quantity := li.OrderedQuantity
+				if quantity == 0 {
+					quantity = deliveredQuantity(li)
+				}
+				liFinding.Required = percentOf(policy.PercentOfQuantity, quantity)
+				liFinding.Maximum = percentOf(policy.MaxPercentOfQuantity, quantity)
+			}
+
+			for _, finding := range append(batchFindings, liFinding) {
+				if finding.outOfPolicy() {
+					findings = append(findings, finding)
+				}
+			}
+		}
+	}
+	return findings
+}
+
+var bufferFindingHeader = []string{"Status", "Level", "Contract", "Material", "LI", "Batch", "Drum size", "Unit", "Policy", "Required", "Maximum", "Buffer", "Difference"}
+
+// record is the finding as the cells of bufferFindingHeader.
+func (f bufferFinding) record() []string {
+	drumSize := ""
+	if f.DrumSize > 0 {
+		drumSize = strconv.Itoa(f.DrumSize)
+	}
+	maximum := ""
+	if f.Maximum > 0 {
+		maximum = strconv.Itoa(f.Maximum)
+	}
+	return []string{
+		f.Status(), f.Level, f.ContractNo, f.MaterialCode, f.LI, f.BatchNo, drumSize, f.Unit, f.Policy,
+		strconv.Itoa(f.Required), maximum, strconv.Itoa(f.Buffer), strconv.Itoa(f.Difference()),
+	}
+}
+
+// writeBufferFindings writes the buffer findings in one of the formats of the summary report, LI lines in bold.
+func writeBufferFindings(w io.Writer, findings []bufferFinding, format string) error {
+	records := make([][]string, 0, len(findings))
+	rollUps := make([]bool, 0, len(findings))
+	for _, finding := range findings {
+		records = append(records, finding.record())
+		rollUps = append(rollUps, finding.Level == summaryLevelLI)
+	}
+	return writeTable(w, bufferFindingHeader, records, format, 9, rollUps)
+}
+
+// loadBufferPolicies reads the buffer policies of a file.
+func loadBufferPolicies(path string) ([]bufferPolicy, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return readBufferPolicies(file)
+}
+
+// runBufferCheck is the buffer-check command: it reports the LIs and batches of a converted inventory, or of a
+// vendor file, holding less buffer than their policy requires or more than it allows.
+func runBufferCheck(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("buffer-check", flag.ContinueOnError)
+	in := flags.String("in", "output.json", "JSON inventory, or vendor CSV file to convert, to check")
+	policyPath := flags.String("policy", "buffer-policy.json", "JSON file of the buffer policies per contract and material")
+	out := flags.String("out", "", "file to write the report to, standard output by default")
+	format := flags.String("format", summaryFormatText, "format of the report: text, csv or markdown")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	policies, err := loadBufferPolicies(*policyPath)
+	if err != nil {
+		return err
+	}
+	return writeReport(*in, *out, *format, stdout, func(w io.Writer, records UploadInventoryInput) error {
+		return writeBufferFindings(w, evaluateBufferPolicies(records, policies), *format)
+	})
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_readBufferPolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    []bufferPolicy
+		wantErr string
+	}{
+		{
+			name: "valid",
+			json: `[{"percent_of_quantity": 10}, {"contract_no": "1", "material_code": "M", "drums_per_size": 2}]`,
+			want: []bufferPolicy{{PercentOfQuantity: 10}, {ContractNo: "1", MaterialCode: "M", DrumsPerSize: 2}},
+		},
+		{name: "no rule", json: `[{"contract_no": "1"}]`, wantErr: "buffer policy 1: set either percent_of_quantity or drums_per_size"},
+		{name: "two rules", json: `[{"percent_of_quantity": 10, "drums_per_size": 1}]`, wantErr: "buffer policy 1: set either percent_of_quantity or drums_per_size"},
+		{name: "percent above 100", json: `[{"percent_of_quantity": 110}]`, wantErr: "buffer policy 1: percent of quantity 110 is not between 0 and 100"},
+		{name: "negative drums", json: `[{"drums_per_size": -1}]`, wantErr: "buffer policy 1: drums per size -1 is negative"},
+		{
+			name:    "same scope twice",
+			json:    `[{"contract_no": "1", "percent_of_quantity": 10}, {"contract_no": "1", "drums_per_size": 1}]`,
+			wantErr: `buffer policy 2: more than one policy for contract "1" and material code ""`,
+		},
+		{
+			name: "capped",
+			json: `[{"percent_of_quantity": 10, "max_percent_of_quantity": 20}, {"contract_no": "1", "drums_per_size": 1, "max_drums_per_size": 2}]`,
+			want: []bufferPolicy{{PercentOfQuantity: 10, MaxPercentOfQuantity: 20}, {ContractNo: "1", DrumsPerSize: 1, MaxDrumsPerSize: 2}},
+		},
+		{name: "cap below minimum", json: `[{"percent_of_quantity": 10, "max_percent_of_quantity": 5}]`, wantErr: "buffer policy 1: max percent of quantity 5 is not between 10 and 100"},
+		{name: "cap of another rule", json: `[{"percent_of_quantity": 10, "max_drums_per_size": 2}]`, wantErr: "buffer policy 1: cap percent_of_quantity with max_percent_of_quantity and drums_per_size with max_drums_per_size"},
+		{name: "drum cap below minimum", json: `[{"drums_per_size": 2, "max_drums_per_size": 1}]`, wantErr: "buffer policy 1: max drums per size 1 is below drums per size 2"},
+		{name: "unknown field", json: `[{"percent": 10}]`, wantErr: `failed to read buffer policies: json: unknown field "percent"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readBufferPolicies(strings.NewReader(tt.json))
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_policyFor(t *testing.T) {
+	policies := []bufferPolicy{
+		{ContractNo: "1", MaterialCode: "M", DrumsPerSize: 1},
+		{MaterialCode: "M", DrumsPerSize: 2},
+		{ContractNo: "1", DrumsPerSize: 3},
+		{DrumsPerSize: 4},
+	}
+
+	tests := []struct {
+		contractNo   string
+		materialCode string
+		want         int
+	}{
+		{contractNo: "1", materialCode: "M", want: 1},
+		{contractNo: "2", materialCode: "M", want: 2},
+		{contractNo: "1", materialCode: "N", want: 3},
+		{contractNo: "2", materialCode: "N", want: 4},
+	}
+	for _, tt := range tests {
+		got, ok := policyFor(policies, tt.contractNo, tt.materialCode)
+		assert.True(t, ok)
+		assert.Equal(t, tt.want, got.DrumsPerSize, "contract %s material %s", tt.contractNo, tt.materialCode)
+	}
+
+	_, ok := policyFor(policies[:1], "2", "M")
+	assert.False(t, ok)
+}
+
+func Test_evaluateBufferPolicies(t *testing.T) {
+	base := bufferFinding{ContractNo: "1", MaterialCode: "M", LI: "Li-1", Unit: UnitMetre}
+	finding := func(level, batchNo string, drumSize int, policy string, required, maximum, buffer int) bufferFinding {
+		f := base
+		f.Level, f.BatchNo, f.DrumSize, f.Policy, f.Required, f.Maximum, f.Buffer = level, batchNo, drumSize, policy, required, maximum, buffer
+		return f
+	}
+
+	li := testLI("1", "M", UnitMetre,
+		testBatch("1/50", nil,
+			DrumPartition{DrumSize: 250, Quantity: 500, BufferQuantity: 250, BufferDrumNumbers: []int{1}},
+			DrumPartition{DrumSize: 500, Quantity: 500},
+		),
+		testBatch("2/50", nil, DrumPartition{DrumSize: 500, Quantity: 2500, BufferQuantity: 1000, BufferDrumNumbers: []int{1, 2}}),
+	)
+	li.OrderedQuantity = 5000
+	records := testInventory(testContract("1", "", li))
+
+	tests := []struct {
+		name     string
+		policies []bufferPolicy
+		want     []bufferFinding
+	}{
+		{
+			name:     "percent met by the LI and batches",
+			policies: []bufferPolicy{{PercentOfQuantity: 10}},
+			want:     []bufferFinding{},
+		},
+		{
+			name:     "percent of LI and batch quantity, rounded up",
+			policies: []bufferPolicy{{PercentOfQuantity: 25.01}},
+			want: []bufferFinding{
+				finding(summaryLevelBatch, "1/50", 0, "at least 25.01% of quantity", 251, 0, 250),
+				finding(summaryLevelLI, "", 0, "at least 25.01% of quantity", 1251, 0, 1250),
+			},
+		},
+		{
+			name:     "percent with a cap",
+			policies: []bufferPolicy{{PercentOfQuantity: 10, MaxPercentOfQuantity: 25}},
+			want: []bufferFinding{
+				finding(summaryLevelBatch, "2/50", 0, "10% to 25% of quantity", 250, 625, 1000),
+			},
+		},
+		{
+			name:     "drums per size",
+			policies: []bufferPolicy{{ContractNo: "1", DrumsPerSize: 1}},
+			want: []bufferFinding{
+				finding(summaryLevelDrumSize, "1/50", 500, "at least 1 drums per size", 500, 0, 0),
+			},
+		},
+		{
+			name:     "drums per size with a cap",
+			policies: []bufferPolicy{{DrumsPerSize: 1, MaxDrumsPerSize: 1}},
+			want: []bufferFinding{
+				finding(summaryLevelDrumSize, "1/50", 500, "1 to 1 drums per size", 500, 500, 0),
+				finding(summaryLevelDrumSize, "2/50", 500, "1 to 1 drums per size", 500, 500, 1000),
+			},
+		},
+		{
+			name:     "other contract",
+			policies: []bufferPolicy{{ContractNo: "2", DrumsPerSize: 1}},
+			want:     []bufferFinding{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, evaluateBufferPolicies(records, tt.policies))
+		})
+	}
+}
+
+func Test_bufferFinding_Error(t *testing.T) {
+	shortfall := bufferFinding{
+		Level: summaryLevelDrumSize, ContractNo: "1", MaterialCode: "M", LI: "Li-1", BatchNo: "1/50", DrumSize: 500, Unit: UnitMetre,
+		Policy: "1 to 1 drums per size", Required: 500, Maximum: 500, Buffer: 0,
+	}
+	excess := shortfall
+	excess.BatchNo, excess.Buffer = "2/50", 1000
+
+	var err error = shortfall
+	assert.EqualError(t, err, "buffer shortfall of 500 m for drum size 500 of batch 1/50 of LI Li-1 of contract 1: 0 held, at least 500 required by 1 to 1 drums per size")
+	assert.True(t, errors.As(err, new(bufferFinding)))
+	assert.Equal(t, bufferExcess, excess.Status())
+	assert.EqualError(t, excess, "buffer excess of 500 m for drum size 500 of batch 2/50 of LI Li-1 of contract 1: 1000 held, at most 500 allowed by 1 to 1 drums per size")
+}
+
+func Test_runBufferCheck(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "inventory.json")
+	policyPath := filepath.Join(dir, "policy.json")
+	records := testInventory(testContract("1", "", testLI("1", "M", UnitMetre,
+		testBatch("2/50", nil, DrumPartition{DrumSize: 500, Quantity: 2500, BufferQuantity: 1000, BufferDrumNumbers: []int{1, 2}}),
+	)))
+	jsonData, err := marshalRecords(records, outputOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(jsonPath, jsonData, 0644))
+	assert.NoError(t, os.WriteFile(policyPath, []byte(`[{"material_code": "M", "percent_of_quantity": 10, "max_percent_of_quantity": 25}]`), 0644))
+
+	var stdout bytes.Buffer
+	assert.NoError(t, runBufferCheck([]string{"-in", jsonPath, "-policy", policyPath, "-format", summaryFormatCSV}, &stdout))
+	assert.Equal(t, "Status,Level,Contract,Material,LI,Batch,Drum size,Unit,Policy,Required,Maximum,Buffer,Difference\n"+
+		"EXCESS,batch,1,M,Li-1,2/50,,m,10% to 25% of quantity,250,625,1000,375\n"+
+		"EXCESS,LI,1,M,Li-1,,,m,10% to 25% of quantity,250,625,1000,375\n", stdout.String())
+
+	assert.Error(t, runBufferCheck([]string{"-in", jsonPath, "-policy", filepath.Join(dir, "missing.json")}, &stdout))
+	assert.Error(t, runBufferCheck([]string{"-in", jsonPath, "-policy", policyPath, "-format", "xlsx"}, &stdout))
+}
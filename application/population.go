@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// drumPopulation returns the numbers of all drums of a row: the drum nos. when the vendor gave them, otherwise the
+// drums the row lists when they are as many as its total no. of drums, otherwise the consecutive drum numbers from
+// the lowest drum the row lists. It is not known when the row lists no drum, or when the drums it lists do not fit
+// in consecutive drum numbers.
+func drumPopulation(row CSVRow) ([]int, bool) {
+	if len(row.DrumNos) > 0 {
+		population := distinctDrumNumbers(row.DrumNos)
+		sort.Ints(population)
+		return population, true
+	}
+
+	listed := listedDrums(row)
+	if len(listed) == 0 || len(listed) > row.TotalNoOfDrums {
+		return nil, false
+	}
+	if len(listed) == row.TotalNoOfDrums {
+		return listed, true
+	}
+
+	first := listed[0]
+	if listed[len(listed)-1] >= first+row.TotalNoOfDrums {
+		return nil, false
+	}
+	population := make([]int, 0, row.TotalNoOfDrums)
+	for drumNo := first; drumNo < first+row.TotalNoOfDrums; drumNo++ {
+		population = append(population, drumNo)
+	}
+	return population, true
+}
+
+// listedDrums are the distinct drums a row lists as available, buffer or sample drums, in order.
+func listedDrums(row CSVRow) []int {
+	listed := distinctDrumNumbers(append(append(append([]int{}, row.AvailableDrumNos...), row.BufferDrumNo...), row.SampleDrumNo...))
+	sort.Ints(listed)
+	return listed
+}
+
+// unapprovedDrums lists the drums of a row still awaiting a test report: every drum of a row whose batch test
+// rejected its drums or asked for a retest, otherwise the drums that are neither available, buffer nor sample drums.
+// The drums a row without a batch test report date declares available are not, see availableDrums.
+// It reports the drums listed as both buffer and sample drums, availableDrums those also declared available, and
+// the listed drums that are not among the drum nos. When the population is not known the unapproved drums are not
+// known either and none are listed, see unlistedUnapprovedDrums.
+func unapprovedDrums(row CSVRow, rowIndex int) ([]int, []Error) {
+	var errors []Error
+
+	isSample := drumSet(row.SampleDrumNo)
+	for _, drumNo := range distinctDrumNumbers(row.BufferDrumNo) {
+		if isSample[drumNo] {
+			errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("drum number %d of drum size %d is both a buffer and a sample drum", drumNo, row.DrumSize)})
+		}
+	}
+
+	listed := listedDrums(row)
+	population, known := drumPopulation(row)
+	if !known {
+		return []int{}, errors
+	}
+
+	// a population of the listed drums holds them all
+	if len(row.DrumNos) > 0 {
+		inPopulation := drumSet(population)
+		for _, drumNo := range listed {
+			if !inPopulation[drumNo] {
+				errors = append(errors, Error{RowNo: rowIndex + 1, Err: fmt.Errorf("drum number %d of drum size %d is not one of the drum nos. %v", drumNo, row.DrumSize, population)})
+			}
+		}
+	}
+
+	if row.rejectsDrums() {
+		return population, errors
+	}
+
+	approved := drumSet(listed)
+	if row.BatchTestReportDate == "" {
+		approved = drumSet(append(append([]int{}, row.BufferDrumNo...), row.SampleDrumNo...))
+	}
+	unapproved := make([]int, 0)
+	for _, drumNo := range population {
+		if !approved[drumNo] {
+			unapproved = append(unapproved, drumNo)
+		}
+	}
+	return unapproved, errors
+}
+
+// unlistedUnapprovedDrums warns about every drum partition with fewer unapproved drum numbers than unapproved drums,
+// which is the case for rows whose drum numbers are not known, see drumPopulation. Sheets without the drum nos.
+// column convert all the same, the warnings are kept apart from the errors of the file.
+func (u UploadInventoryInput) unlistedUnapprovedDrums() []Error {
+	warnings := make([]Error, 0)
+	for _, contract := range u.Contracts {
+		for _, li := range contract.LIs {
+			for _, batch := range li.Batches {
+				for _, dp := range batch.DrumPartitions {
+					if dp.DrumSize <= 0 {
+						continue
+					}
+					unapproved := dp.UnapprovedQuantity / dp.DrumSize
+					if len(dp.UnapprovedDrumNumbers) >= unapproved {
+						continue
+					}
+
+					msg := fmt.Sprintf("%d of the %d unapproved drums of drum size %d of contract %s LI %s-%s batch %s are not listed, the drum nos. are needed to list them",
+						unapproved-len(dp.UnapprovedDrumNumbers), unapproved, dp.DrumSize, contract.ContractNo, li.LiCode, li.LiNumber, batch.BatchNo)
+					if refs := batchSourceRefs(batch); len(refs) > 0 {
+						msg += " (" + strings.Join(refs, ", ") + ")"
+					}
+					warnings = append(warnings, Error{RowNo: 0, Err: fmt.Errorf("%s", msg)})
+				}
+			}
+		}
+	}
+	return warnings
+}
+
+// removeDrumNumber removes the first occurrence of a drum number.
+func removeDrumNumber(drumNumbers []int, drumNo int) []int {
+	for i, n := range drumNumbers {
+		if n == drumNo {
+			return append(append([]int{}, drumNumbers[:i]...), drumNumbers[i+1:]...)
+		}
+	}
+	return drumNumbers
+}
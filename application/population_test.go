@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_drumPopulation(t *testing.T) {
+	tests := []struct {
+		name      string
+		row       CSVRow
+		want      []int
+		wantKnown bool
+	}{
+		{name: "drum nos. given", row: CSVRow{TotalNoOfDrums: 3, DrumNos: []int{7, 5, 6}, AvailableDrumNos: []int{5}}, want: []int{5, 6, 7}, wantKnown: true},
+		{name: "every drum listed", row: CSVRow{TotalNoOfDrums: 3, AvailableDrumNos: []int{1, 9}, BufferDrumNo: []int{4}}, want: []int{1, 4, 9}, wantKnown: true},
+		{name: "inferred from the lowest drum", row: CSVRow{TotalNoOfDrums: 4, AvailableDrumNos: []int{11}, SampleDrumNo: []int{13, 13}}, want: []int{11, 12, 13, 14}, wantKnown: true},
+		{name: "no drum listed", row: CSVRow{TotalNoOfDrums: 2}},
+		{name: "listed drums not consecutive", row: CSVRow{TotalNoOfDrums: 3, AvailableDrumNos: []int{1, 5}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, known := drumPopulation(tt.row)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantKnown, known)
+		})
+	}
+}
+
+func Test_unapprovedDrums(t *testing.T) {
+	tests := []struct {
+		name       string
+		row        CSVRow
+		want       []int
+		wantErrors []Error
+	}{
+		{
+			name: "drums not listed are unapproved",
+			row:  CSVRow{DrumSize: 250, TotalNoOfDrums: 5, AvailableDrumNos: []int{1}, BufferDrumNo: []int{2}, SampleDrumNo: []int{3}, BatchTestReportDate: "01-03-2024"},
+			want: []int{4, 5},
+		},
+		{
+			name: "drums declared available without a batch test report are unapproved",
+			row:  CSVRow{DrumSize: 250, TotalNoOfDrums: 3, DrumNos: []int{1, 2, 3}, AvailableDrumNos: []int{1}, BufferDrumNo: []int{2}},
+			want: []int{1, 3},
+		},
+		{
+			name: "rejected drums are unapproved",
+			row:  CSVRow{DrumSize: 250, TotalNoOfDrums: 2, AvailableDrumNos: []int{1, 2}, BatchTestReportDate: "01-03-2024", BatchTestStatus: "REJECTED"},
+			want: []int{1, 2},
+		},
+		{
+			name: "no drum is unapproved",
+			row:  CSVRow{DrumSize: 250, TotalNoOfDrums: 2, DrumNos: []int{1, 2}, AvailableDrumNos: []int{1, 2}, BatchTestReportDate: "01-03-2024"},
+			want: []int{},
+		},
+		{
+			name:       "listed drum outside the drum nos.",
+			row:        CSVRow{DrumSize: 250, TotalNoOfDrums: 2, DrumNos: []int{1, 2}, AvailableDrumNos: []int{1, 3}, BatchTestReportDate: "01-03-2024"},
+			want:       []int{2},
+			wantErrors: []Error{{RowNo: 1, Err: fmt.Errorf("drum number 3 of drum size 250 is not one of the drum nos. [1 2]")}},
+		},
+		{
+			name: "drums not consecutive",
+			row:  CSVRow{DrumSize: 250, TotalNoOfDrums: 3, AvailableDrumNos: []int{1, 5}, BatchTestReportDate: "01-03-2024"},
+			want: []int{},
+		},
+		{
+			name: "no drum listed",
+			row:  CSVRow{DrumSize: 250, TotalNoOfDrums: 2},
+			want: []int{},
+		},
+		{
+			name:       "buffer drum also a sample drum",
+			row:        CSVRow{DrumSize: 250, TotalNoOfDrums: 3, DrumNos: []int{1, 2, 3}, AvailableDrumNos: []int{1}, BufferDrumNo: []int{2}, SampleDrumNo: []int{2}, BatchTestReportDate: "01-03-2024"},
+			want:       []int{3},
+			wantErrors: []Error{{RowNo: 1, Err: fmt.Errorf("drum number 2 of drum size 250 is both a buffer and a sample drum")}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := unapprovedDrums(tt.row, 0)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantErrors, errs)
+		})
+	}
+}
+
+func TestProcessRows_UnapprovedDrumNumbers(t *testing.T) {
+	partlyApproved := approvalTestRow("01-03-2024", "APPROVED", []int{1, 2})
+	partlyApproved.TotalNoOfDrums = 4
+	partlyApproved.TotalQty = 1000
+	rejected := approvalTestRow("01-03-2024", "REJECTED", []int{5, 6})
+	approvedLater := approvalTestRow("01-04-2024", "APPROVED", []int{5})
+
+	got, errs := processRows([]CSVRow{partlyApproved, rejected, approvedLater})
+	assert.Empty(t, errs)
+
+	dp := got.Contracts[0].LIs[0].Batches[0].DrumPartitions[0]
+	assert.Equal(t, []int{1, 2, 5}, dp.AvailableDrumNumbers)
+	assert.Equal(t, []int{3, 4, 6}, dp.UnapprovedDrumNumbers)
+	assert.Equal(t, 3*250, dp.UnapprovedQuantity)
+}
+
+func TestUploadInventoryInput_unlistedUnapprovedDrums(t *testing.T) {
+	listed := approvalTestRow("01-03-2024", "APPROVED", []int{1, 2})
+	listed.TotalNoOfDrums = 4
+	listed.TotalQty = 1000
+	unlisted := approvalTestRow("01-03-2024", "APPROVED", []int{})
+	unlisted.BatchNo = "2/2"
+	unlisted.TotalNoOfDrums = 2
+	unlisted.TotalQty = 500
+
+	got, errs := processRows([]CSVRow{listed, unlisted})
+	assert.Empty(t, errs)
+	assert.Empty(t, got.Contracts[0].LIs[0].Batches[1].DrumPartitions[0].UnapprovedDrumNumbers)
+
+	assert.Equal(t, []Error{
+		{RowNo: 0, Err: fmt.Errorf("2 of the 2 unapproved drums of drum size 250 of contract C123 LI LI001-1 batch 2/2 are not listed, the drum nos. are needed to list them")},
+	}, got.unlistedUnapprovedDrums())
+}
+
+func TestCSVRow_UnmarshalCSV_DrumNos(t *testing.T) {
+	record := fixTestRecord(nil)
+	for len(record) <= DrumNosColumnIndex {
+		record = append(record, "")
+	}
+	record[DrumNosColumnIndex] = "1-3"
+
+	var row CSVRow
+	assert.Empty(t, row.UnmarshalCSV(record, 0))
+	assert.Equal(t, []int{1, 2, 3}, row.DrumNos)
+	assert.Empty(t, row.validateRow(0))
+
+	row.DrumNos = []int{1, 2}
+	assert.Equal(t, []Error{{RowNo: 1, Err: fmt.Errorf("drum nos. does not match total no. of drums")}}, row.validateRow(0))
+}
@@ -37,7 +37,7 @@ func TestProcessRows(t *testing.T) {
 					BufferDrumNo:            []int{2},
 					BufferNoOfDrums:         1,
 					BufferQuantity:          250,
-					SampleDrum:              "Yes",
+					SampleDrum:              true,
 					SampleDrumNo:            []int{3},
 					SampleLength:            []float64{2.5},
 					NoOfShortLengthDrums:    1,
@@ -52,24 +52,29 @@ func TestProcessRows(t *testing.T) {
 				Contracts: []Contracts{
 					{
 						ContractNo: "C123",
+						Vendor:     "MyVendor",
 						LIs: []LI{
 							{
-								MaterialCode:    "101642",
-								LiCode:          "LI001",
-								LiNumber:        "1",
-								Description:     "Example Material",
-								HosApprovalDate: "10-02-2024",
-								Status:          "VENDOR_ACKNOWLEDGED",
+								MaterialCode:      "101642",
+								LiCode:            "LI001",
+								LiNumber:          "1",
+								Description:       "Example Material",
+								Unit:              "m",
+								HosApprovalDate:   testDate("10-02-2024"),
+								DeliveredQuantity: 750,
+								Status:            "PARTIALLY_DELIVERED",
 								Batches: []Batch{
 									{
 										BatchNo:        "1/10",
-										SubmissionDate: "11-06-2025",
+										SubmissionDate: testDate("11-06-2025"),
 										TotalQuantity:  750,
 										Status:         "PARTIAL_BUFFER",
 										Remarks:        "Partial Buffer",
+										RemarkFlags:    []string{"BUFFER", "PARTIAL"},
 										BatchTestApprovals: []BatchTestApproval{
 											{
-												ApprovalDate: "10-06-2024",
+												ApprovalDate: testDate("10-06-2024"),
+												Remarks:      []string{"Partial Buffer"},
 												TestDrumNumbers: []BatchTestDrumNumbers{
 													{
 														DrumSize: 250,
@@ -84,20 +89,21 @@ func TestProcessRows(t *testing.T) {
 														DrumNumbers: []int{1, 2, 3},
 													},
 												},
-												Status:          "APPROVED",
-												ApprovalComment: "Batch Test report uploaded on Go-live phase 1",
+												Status: "APPROVED",
 											},
 										},
 										DrumPartitions: []DrumPartition{
 											{
-												DrumSize:             250,
-												Quantity:             750,
-												UnapprovedQuantity:   0,
-												AvailableQuantity:    250,
-												AvailableDrumNumbers: []int{1},
-												BufferQuantity:       250,
-												BufferDrumNumbers:    []int{2},
-												TestQuantity:         2.5,
+												DrumSize:              250,
+												Unit:                  "m",
+												Quantity:              750,
+												UnapprovedQuantity:    0,
+												UnapprovedDrumNumbers: []int{},
+												AvailableQuantity:     250,
+												AvailableDrumNumbers:  []int{1},
+												BufferQuantity:        250,
+												BufferDrumNumbers:     []int{2},
+												TestQuantity:          2.5,
 												TestDrumNumbers: []DrumDetails{
 													{DrumNumber: 3, Quantity: 2.5},
 												},
@@ -105,6 +111,9 @@ func TestProcessRows(t *testing.T) {
 												ShortDrumNumbers: []DrumDetails{
 													{DrumNumber: 3, Quantity: 247.5},
 												},
+												PartialDrumNumbers: []DrumDetails{},
+												Remarks:            []string{"Partial Buffer"},
+												RemarkFlags:        []string{"BUFFER", "PARTIAL"},
 											},
 										},
 									},
@@ -140,7 +149,7 @@ func TestProcessRows(t *testing.T) {
 					BufferDrumNo:            []int{104},
 					BufferNoOfDrums:         1,
 					BufferQuantity:          200,
-					SampleDrum:              "Yes",
+					SampleDrum:              true,
 					SampleDrumNo:            []int{105},
 					SampleLength:            []float64{2.5},
 					NoOfShortLengthDrums:    1,
@@ -170,7 +179,7 @@ func TestProcessRows(t *testing.T) {
 					BufferDrumNo:            []int{104},
 					BufferNoOfDrums:         1,
 					BufferQuantity:          200,
-					SampleDrum:              "Yes",
+					SampleDrum:              true,
 					SampleDrumNo:            []int{105},
 					SampleLength:            []float64{2.5},
 					NoOfShortLengthDrums:    1,
@@ -184,25 +193,30 @@ func TestProcessRows(t *testing.T) {
 			wantOutput: UploadInventoryInput{
 				Contracts: []Contracts{
 					{ // Contract 1
-						ContractNo: "C123",
+						ContractNo: "B123",
+						Vendor:     "Supplier A",
 						LIs: []LI{
 							{
-								LiCode:          "LI001",
-								LiNumber:        "1",
-								MaterialCode:    "MAT100",
-								Description:     "Material A",
-								HosApprovalDate: "2024-02-12",
-								Status:          "VENDOR_ACKNOWLEDGED",
+								LiCode:            "LI001",
+								LiNumber:          "1",
+								MaterialCode:      "MAT100",
+								Description:       "Material A",
+								Unit:              "m",
+								HosApprovalDate:   testDate("2024-02-12"),
+								DeliveredQuantity: 1000,
+								Status:            "PARTIALLY_DELIVERED",
 								Batches: []Batch{
 									{
 										BatchNo:        "B001",
 										TotalQuantity:  1000,
-										SubmissionDate: "2024-10-10",
+										SubmissionDate: testDate("2024-10-10"),
 										Remarks:        "Some Remarks",
+										RemarkFlags:    []string{},
 										Status:         "PARTIAL_BUFFER", // Update based on your logic
 										BatchTestApprovals: []BatchTestApproval{
 											{
-												ApprovalDate: "2024-05-01",
+												ApprovalDate: testDate("2024-05-01"),
+												Remarks:      []string{"Some Remarks"},
 												TestDrumNumbers: []BatchTestDrumNumbers{
 													{
 														DrumSize:    200,
@@ -215,23 +229,27 @@ func TestProcessRows(t *testing.T) {
 														DrumNumbers: []int{101, 102, 103, 104, 105},
 													},
 												},
-												Status:          "APPROVED",
-												ApprovalComment: "Batch Test report uploaded on Go-live phase 1",
+												Status: "APPROVED",
 											},
 										},
 										DrumPartitions: []DrumPartition{
 											{
-												DrumSize:             200,
-												Quantity:             1000,
-												UnapprovedQuantity:   0,
-												AvailableQuantity:    600,
-												AvailableDrumNumbers: []int{101, 102, 103},
-												BufferQuantity:       200,
-												BufferDrumNumbers:    []int{104},
-												TestQuantity:         2.5,
-												TestDrumNumbers:      []DrumDetails{{DrumNumber: 105, Quantity: 2.5}},
-												ShortQuantity:        197.5,
-												ShortDrumNumbers:     []DrumDetails{{DrumNumber: 105, Quantity: 197.5}},
+												DrumSize:              200,
+												Unit:                  "m",
+												Quantity:              1000,
+												UnapprovedQuantity:    0,
+												UnapprovedDrumNumbers: []int{},
+												AvailableQuantity:     600,
+												AvailableDrumNumbers:  []int{101, 102, 103},
+												BufferQuantity:        200,
+												BufferDrumNumbers:     []int{104},
+												TestQuantity:          2.5,
+												TestDrumNumbers:       []DrumDetails{{DrumNumber: 105, Quantity: 2.5}},
+												ShortQuantity:         197.5,
+												ShortDrumNumbers:      []DrumDetails{{DrumNumber: 105, Quantity: 197.5}},
+												PartialDrumNumbers:    []DrumDetails{},
+												Remarks:               []string{"Some Remarks"},
+												RemarkFlags:           []string{},
 											},
 										},
 									},
@@ -240,25 +258,30 @@ func TestProcessRows(t *testing.T) {
 						},
 					},
 					{ // Contract 1
-						ContractNo: "B123",
+						ContractNo: "C123",
+						Vendor:     "Supplier A",
 						LIs: []LI{
 							{
-								LiCode:          "LI001",
-								LiNumber:        "1",
-								MaterialCode:    "MAT100",
-								Description:     "Material A",
-								HosApprovalDate: "2024-02-12",
-								Status:          "VENDOR_ACKNOWLEDGED",
+								LiCode:            "LI001",
+								LiNumber:          "1",
+								MaterialCode:      "MAT100",
+								Description:       "Material A",
+								Unit:              "m",
+								HosApprovalDate:   testDate("2024-02-12"),
+								DeliveredQuantity: 1000,
+								Status:            "PARTIALLY_DELIVERED",
 								Batches: []Batch{
 									{
 										BatchNo:        "B001",
 										TotalQuantity:  1000,
-										SubmissionDate: "2024-10-10",
+										SubmissionDate: testDate("2024-10-10"),
 										Remarks:        "Some Remarks",
+										RemarkFlags:    []string{},
 										Status:         "PARTIAL_BUFFER", // Update based on your logic
 										BatchTestApprovals: []BatchTestApproval{
 											{
-												ApprovalDate: "2024-05-01",
+												ApprovalDate: testDate("2024-05-01"),
+												Remarks:      []string{"Some Remarks"},
 												TestDrumNumbers: []BatchTestDrumNumbers{
 													{
 														DrumSize:    200,
@@ -271,23 +294,27 @@ func TestProcessRows(t *testing.T) {
 														DrumNumbers: []int{101, 102, 103, 104, 105},
 													},
 												},
-												Status:          "APPROVED",
-												ApprovalComment: "Batch Test report uploaded on Go-live phase 1",
+												Status: "APPROVED",
 											},
 										},
 										DrumPartitions: []DrumPartition{
 											{
-												DrumSize:             200,
-												Quantity:             1000,
-												UnapprovedQuantity:   0,
-												AvailableQuantity:    600,
-												AvailableDrumNumbers: []int{101, 102, 103},
-												BufferQuantity:       200,
-												BufferDrumNumbers:    []int{104},
-												TestQuantity:         2.5,
-												TestDrumNumbers:      []DrumDetails{{DrumNumber: 105, Quantity: 2.5}},
-												ShortQuantity:        197.5,
-												ShortDrumNumbers:     []DrumDetails{{DrumNumber: 105, Quantity: 197.5}},
+												DrumSize:              200,
+												Unit:                  "m",
+												Quantity:              1000,
+												UnapprovedQuantity:    0,
+												UnapprovedDrumNumbers: []int{},
+												AvailableQuantity:     600,
+												AvailableDrumNumbers:  []int{101, 102, 103},
+												BufferQuantity:        200,
+												BufferDrumNumbers:     []int{104},
+												TestQuantity:          2.5,
+												TestDrumNumbers:       []DrumDetails{{DrumNumber: 105, Quantity: 2.5}},
+												ShortQuantity:         197.5,
+												ShortDrumNumbers:      []DrumDetails{{DrumNumber: 105, Quantity: 197.5}},
+												PartialDrumNumbers:    []DrumDetails{},
+												Remarks:               []string{"Some Remarks"},
+												RemarkFlags:           []string{},
 											},
 										},
 									},
@@ -323,7 +350,7 @@ func TestProcessRows(t *testing.T) {
 					BufferDrumNo:            []int{104},
 					BufferNoOfDrums:         1,
 					BufferQuantity:          200,
-					SampleDrum:              "Yes",
+					SampleDrum:              true,
 					SampleDrumNo:            []int{105},
 					SampleLength:            []float64{5.0},
 					NoOfShortLengthDrums:    1,
@@ -353,7 +380,7 @@ func TestProcessRows(t *testing.T) {
 					BufferDrumNo:            []int{104},
 					BufferNoOfDrums:         1,
 					BufferQuantity:          200,
-					SampleDrum:              "Yes",
+					SampleDrum:              true,
 					SampleDrumNo:            []int{105},
 					SampleLength:            []float64{5.0},
 					NoOfShortLengthDrums:    1,
@@ -368,24 +395,29 @@ func TestProcessRows(t *testing.T) {
 				Contracts: []Contracts{
 					{ // Contract 1
 						ContractNo: "C123",
+						Vendor:     "Supplier A",
 						LIs: []LI{
 							{
-								LiCode:          "LI001",
-								LiNumber:        "1",
-								MaterialCode:    "MAT100",
-								Description:     "Material A",
-								HosApprovalDate: "01-01-2024",
-								Status:          "VENDOR_ACKNOWLEDGED",
+								LiCode:            "LI001",
+								LiNumber:          "1",
+								MaterialCode:      "MAT100",
+								Description:       "Material A",
+								Unit:              "m",
+								HosApprovalDate:   testDate("01-01-2024"),
+								DeliveredQuantity: 1000,
+								Status:            "PARTIALLY_DELIVERED",
 								Batches: []Batch{
 									{
 										BatchNo:        "B001",
 										TotalQuantity:  1000,
-										SubmissionDate: "01-01-2025",
+										SubmissionDate: testDate("01-01-2025"),
 										Remarks:        "Initial LI",
+										RemarkFlags:    []string{},
 										Status:         "PARTIAL_BUFFER", // Update based on your logic
 										BatchTestApprovals: []BatchTestApproval{
 											{
-												ApprovalDate: "01-02-2024",
+												ApprovalDate: testDate("01-02-2024"),
+												Remarks:      []string{"Initial LI"},
 												TestDrumNumbers: []BatchTestDrumNumbers{
 													{
 														DrumSize:    200,
@@ -398,45 +430,53 @@ func TestProcessRows(t *testing.T) {
 														DrumNumbers: []int{101, 102, 103, 104, 105},
 													},
 												},
-												Status:          "APPROVED",
-												ApprovalComment: "Batch Test report uploaded on Go-live phase 1",
+												Status: "APPROVED",
 											},
 										},
 										DrumPartitions: []DrumPartition{
 											{
-												DrumSize:             200,
-												Quantity:             1000,
-												UnapprovedQuantity:   0,
-												AvailableQuantity:    600,
-												AvailableDrumNumbers: []int{101, 102, 103},
-												BufferQuantity:       200,
-												BufferDrumNumbers:    []int{104},
-												TestQuantity:         5,
-												TestDrumNumbers:      []DrumDetails{{DrumNumber: 105, Quantity: 5}},
-												ShortQuantity:        195,
-												ShortDrumNumbers:     []DrumDetails{{DrumNumber: 105, Quantity: 195}},
+												DrumSize:              200,
+												Unit:                  "m",
+												Quantity:              1000,
+												UnapprovedQuantity:    0,
+												UnapprovedDrumNumbers: []int{},
+												AvailableQuantity:     600,
+												AvailableDrumNumbers:  []int{101, 102, 103},
+												BufferQuantity:        200,
+												BufferDrumNumbers:     []int{104},
+												TestQuantity:          5,
+												TestDrumNumbers:       []DrumDetails{{DrumNumber: 105, Quantity: 5}},
+												ShortQuantity:         195,
+												ShortDrumNumbers:      []DrumDetails{{DrumNumber: 105, Quantity: 195}},
+												PartialDrumNumbers:    []DrumDetails{},
+												Remarks:               []string{"Initial LI"},
+												RemarkFlags:           []string{},
 											},
 										},
 									},
 								},
 							},
 							{
-								LiCode:          "LI001",
-								LiNumber:        "2",
-								MaterialCode:    "MAT101",
-								Description:     "Material B",
-								HosApprovalDate: "01-01-2024",
-								Status:          "VENDOR_ACKNOWLEDGED",
+								LiCode:            "LI001",
+								LiNumber:          "2",
+								MaterialCode:      "MAT101",
+								Description:       "Material B",
+								Unit:              "m",
+								HosApprovalDate:   testDate("01-01-2024"),
+								DeliveredQuantity: 1000,
+								Status:            "PARTIALLY_DELIVERED",
 								Batches: []Batch{
 									{
 										BatchNo:        "B001",
 										TotalQuantity:  1000,
-										SubmissionDate: "01-01-2025",
+										SubmissionDate: testDate("01-01-2025"),
 										Remarks:        "Initial LI",
+										RemarkFlags:    []string{},
 										Status:         "PARTIAL_BUFFER", // Update based on your logic
 										BatchTestApprovals: []BatchTestApproval{
 											{
-												ApprovalDate: "01-02-2024",
+												ApprovalDate: testDate("01-02-2024"),
+												Remarks:      []string{"Initial LI"},
 												TestDrumNumbers: []BatchTestDrumNumbers{
 													{
 														DrumSize:    200,
@@ -449,23 +489,27 @@ func TestProcessRows(t *testing.T) {
 														DrumNumbers: []int{101, 102, 103, 104, 105},
 													},
 												},
-												Status:          "APPROVED",
-												ApprovalComment: "Batch Test report uploaded on Go-live phase 1",
+												Status: "APPROVED",
 											},
 										},
 										DrumPartitions: []DrumPartition{
 											{
-												DrumSize:             200,
-												Quantity:             1000,
-												UnapprovedQuantity:   0,
-												AvailableQuantity:    600,
-												AvailableDrumNumbers: []int{101, 102, 103},
-												BufferQuantity:       200,
-												BufferDrumNumbers:    []int{104},
-												TestQuantity:         5,
-												TestDrumNumbers:      []DrumDetails{{DrumNumber: 105, Quantity: 5}},
-												ShortQuantity:        195,
-												ShortDrumNumbers:     []DrumDetails{{DrumNumber: 105, Quantity: 195}},
+												DrumSize:              200,
+												Unit:                  "m",
+												Quantity:              1000,
+												UnapprovedQuantity:    0,
+												UnapprovedDrumNumbers: []int{},
+												AvailableQuantity:     600,
+												AvailableDrumNumbers:  []int{101, 102, 103},
+												BufferQuantity:        200,
+												BufferDrumNumbers:     []int{104},
+												TestQuantity:          5,
+												TestDrumNumbers:       []DrumDetails{{DrumNumber: 105, Quantity: 5}},
+												ShortQuantity:         195,
+												ShortDrumNumbers:      []DrumDetails{{DrumNumber: 105, Quantity: 195}},
+												PartialDrumNumbers:    []DrumDetails{},
+												Remarks:               []string{"Initial LI"},
+												RemarkFlags:           []string{},
 											},
 										},
 									},
@@ -501,7 +545,7 @@ func TestProcessRows(t *testing.T) {
 					BufferDrumNo:            []int{104},
 					BufferNoOfDrums:         1,
 					BufferQuantity:          200,
-					SampleDrum:              "Yes",
+					SampleDrum:              true,
 					SampleDrumNo:            []int{105},
 					SampleLength:            []float64{2.5},
 					NoOfShortLengthDrums:    1,
@@ -531,7 +575,7 @@ func TestProcessRows(t *testing.T) {
 					BufferDrumNo:            []int{},
 					BufferNoOfDrums:         0,
 					BufferQuantity:          0,
-					SampleDrum:              "Yes",
+					SampleDrum:              true,
 					SampleDrumNo:            []int{107},
 					SampleLength:            []float64{2.5},
 					NoOfShortLengthDrums:    1,
@@ -546,24 +590,29 @@ func TestProcessRows(t *testing.T) {
 				Contracts: []Contracts{
 					{ // Contract 1
 						ContractNo: "C123",
+						Vendor:     "Supplier A",
 						LIs: []LI{
 							{
-								LiCode:          "LI001",
-								LiNumber:        "1",
-								MaterialCode:    "MAT100",
-								Description:     "Material A",
-								HosApprovalDate: "2024-02-12",
-								Status:          "VENDOR_ACKNOWLEDGED",
+								LiCode:            "LI001",
+								LiNumber:          "1",
+								MaterialCode:      "MAT100",
+								Description:       "Material A",
+								Unit:              "m",
+								HosApprovalDate:   testDate("2024-02-12"),
+								DeliveredQuantity: 1400,
+								Status:            "PARTIALLY_DELIVERED",
 								Batches: []Batch{
 									{
 										BatchNo:        "1/2",
 										TotalQuantity:  1400,
-										SubmissionDate: "2024-10-10",
+										SubmissionDate: testDate("2024-10-10"),
 										Remarks:        "Some Remarks",
+										RemarkFlags:    []string{},
 										Status:         "PARTIAL_BUFFER", // Update based on your logic
 										BatchTestApprovals: []BatchTestApproval{
 											{
-												ApprovalDate: "2024-05-01",
+												ApprovalDate: testDate("2024-05-01"),
+												Remarks:      []string{"Some Remarks"},
 												TestDrumNumbers: []BatchTestDrumNumbers{
 													{
 														DrumSize:    200,
@@ -576,23 +625,27 @@ func TestProcessRows(t *testing.T) {
 														DrumNumbers: []int{101, 102, 103, 104, 105, 106, 107},
 													},
 												},
-												Status:          "APPROVED",
-												ApprovalComment: "Batch Test report uploaded on Go-live phase 1",
+												Status: "APPROVED",
 											},
 										},
 										DrumPartitions: []DrumPartition{
 											{
-												DrumSize:             200,
-												Quantity:             1400,
-												UnapprovedQuantity:   0,
-												AvailableQuantity:    800,
-												AvailableDrumNumbers: []int{101, 102, 103, 106},
-												BufferQuantity:       200,
-												BufferDrumNumbers:    []int{104},
-												TestQuantity:         5,
-												TestDrumNumbers:      []DrumDetails{{DrumNumber: 105, Quantity: 2.5}, {DrumNumber: 107, Quantity: 2.5}},
-												ShortQuantity:        395,
-												ShortDrumNumbers:     []DrumDetails{{DrumNumber: 105, Quantity: 197.5}, {DrumNumber: 107, Quantity: 197.5}},
+												DrumSize:              200,
+												Unit:                  "m",
+												Quantity:              1400,
+												UnapprovedQuantity:    0,
+												UnapprovedDrumNumbers: []int{},
+												AvailableQuantity:     800,
+												AvailableDrumNumbers:  []int{101, 102, 103, 106},
+												BufferQuantity:        200,
+												BufferDrumNumbers:     []int{104},
+												TestQuantity:          5,
+												TestDrumNumbers:       []DrumDetails{{DrumNumber: 105, Quantity: 2.5}, {DrumNumber: 107, Quantity: 2.5}},
+												ShortQuantity:         395,
+												ShortDrumNumbers:      []DrumDetails{{DrumNumber: 105, Quantity: 197.5}, {DrumNumber: 107, Quantity: 197.5}},
+												PartialDrumNumbers:    []DrumDetails{},
+												Remarks:               []string{"Some Remarks"},
+												RemarkFlags:           []string{},
 											},
 										},
 									},
@@ -628,7 +681,7 @@ func TestProcessRows(t *testing.T) {
 					BufferDrumNo:            []int{104},
 					BufferNoOfDrums:         1,
 					BufferQuantity:          200,
-					SampleDrum:              "Yes",
+					SampleDrum:              true,
 					SampleDrumNo:            []int{105},
 					SampleLength:            []float64{2.5},
 					NoOfShortLengthDrums:    1,
@@ -658,7 +711,7 @@ func TestProcessRows(t *testing.T) {
 					BufferDrumNo:            []int{},
 					BufferNoOfDrums:         0,
 					BufferQuantity:          0,
-					SampleDrum:              "Yes",
+					SampleDrum:              true,
 					SampleDrumNo:            []int{107},
 					SampleLength:            []float64{2.5},
 					NoOfShortLengthDrums:    1,
@@ -673,24 +726,29 @@ func TestProcessRows(t *testing.T) {
 				Contracts: []Contracts{
 					{ // Contract 1
 						ContractNo: "C123",
+						Vendor:     "Supplier A",
 						LIs: []LI{
 							{
-								LiCode:          "LI001",
-								LiNumber:        "1",
-								MaterialCode:    "MAT100",
-								Description:     "Material A",
-								HosApprovalDate: "2024-02-12",
-								Status:          "VENDOR_ACKNOWLEDGED",
+								LiCode:            "LI001",
+								LiNumber:          "1",
+								MaterialCode:      "MAT100",
+								Description:       "Material A",
+								Unit:              "m",
+								HosApprovalDate:   testDate("2024-02-12"),
+								DeliveredQuantity: 1400,
+								Status:            "PARTIALLY_DELIVERED",
 								Batches: []Batch{
 									{
 										BatchNo:        "1/2",
 										TotalQuantity:  1400,
-										SubmissionDate: "2024-10-10",
+										SubmissionDate: testDate("2024-10-10"),
 										Remarks:        "Some Remarks",
+										RemarkFlags:    []string{},
 										Status:         "PARTIAL_BUFFER", // Update based on your logic
 										BatchTestApprovals: []BatchTestApproval{
 											{
-												ApprovalDate: "2024-05-01",
+												ApprovalDate: testDate("2024-05-01"),
+												Remarks:      []string{"Some Remarks"},
 												TestDrumNumbers: []BatchTestDrumNumbers{
 													{
 														DrumSize:    200,
@@ -703,11 +761,11 @@ func TestProcessRows(t *testing.T) {
 														DrumNumbers: []int{101, 102, 103, 104, 105},
 													},
 												},
-												Status:          "APPROVED",
-												ApprovalComment: "Batch Test report uploaded on Go-live phase 1",
+												Status: "APPROVED",
 											},
 											{
-												ApprovalDate: "2024-05-02",
+												ApprovalDate: testDate("2024-05-02"),
+												Remarks:      []string{"Some Remarks"},
 												TestDrumNumbers: []BatchTestDrumNumbers{
 													{
 														DrumSize:    200,
@@ -720,23 +778,27 @@ func TestProcessRows(t *testing.T) {
 														DrumNumbers: []int{106, 107},
 													},
 												},
-												Status:          "APPROVED",
-												ApprovalComment: "Batch Test report uploaded on Go-live phase 1",
+												Status: "APPROVED",
 											},
 										},
 										DrumPartitions: []DrumPartition{
 											{
-												DrumSize:             200,
-												Quantity:             1400,
-												UnapprovedQuantity:   0,
-												AvailableQuantity:    800,
-												AvailableDrumNumbers: []int{101, 102, 103, 106},
-												BufferQuantity:       200,
-												BufferDrumNumbers:    []int{104},
-												TestQuantity:         5,
-												TestDrumNumbers:      []DrumDetails{{DrumNumber: 105, Quantity: 2.5}, {DrumNumber: 107, Quantity: 2.5}},
-												ShortQuantity:        395,
-												ShortDrumNumbers:     []DrumDetails{{DrumNumber: 105, Quantity: 197.5}, {DrumNumber: 107, Quantity: 197.5}},
+												DrumSize:              200,
+												Unit:                  "m",
+												Quantity:              1400,
+												UnapprovedQuantity:    0,
+												UnapprovedDrumNumbers: []int{},
+												AvailableQuantity:     800,
+												AvailableDrumNumbers:  []int{101, 102, 103, 106},
+												BufferQuantity:        200,
+												BufferDrumNumbers:     []int{104},
+												TestQuantity:          5,
+												TestDrumNumbers:       []DrumDetails{{DrumNumber: 105, Quantity: 2.5}, {DrumNumber: 107, Quantity: 2.5}},
+												ShortQuantity:         395,
+												ShortDrumNumbers:      []DrumDetails{{DrumNumber: 105, Quantity: 197.5}, {DrumNumber: 107, Quantity: 197.5}},
+												PartialDrumNumbers:    []DrumDetails{},
+												Remarks:               []string{"Some Remarks"},
+												RemarkFlags:           []string{},
 											},
 										},
 									},
@@ -772,7 +834,7 @@ func TestProcessRows(t *testing.T) {
 					BufferDrumNo:            []int{104},
 					BufferNoOfDrums:         1,
 					BufferQuantity:          200,
-					SampleDrum:              "Yes",
+					SampleDrum:              true,
 					SampleDrumNo:            []int{105},
 					SampleLength:            []float64{2.5},
 					NoOfShortLengthDrums:    1,
@@ -802,7 +864,7 @@ func TestProcessRows(t *testing.T) {
 					BufferDrumNo:            []int{},
 					BufferNoOfDrums:         0,
 					BufferQuantity:          0,
-					SampleDrum:              "Yes",
+					SampleDrum:              true,
 					SampleDrumNo:            []int{},
 					SampleLength:            []float64{},
 					NoOfShortLengthDrums:    0,
@@ -817,24 +879,29 @@ func TestProcessRows(t *testing.T) {
 				Contracts: []Contracts{
 					{ // Contract 1
 						ContractNo: "C123",
+						Vendor:     "Supplier A",
 						LIs: []LI{
 							{
-								LiCode:          "LI001",
-								LiNumber:        "1",
-								MaterialCode:    "MAT100",
-								Description:     "Material A",
-								HosApprovalDate: "2024-02-12",
-								Status:          "VENDOR_ACKNOWLEDGED",
+								LiCode:            "LI001",
+								LiNumber:          "1",
+								MaterialCode:      "MAT100",
+								Description:       "Material A",
+								Unit:              "m",
+								HosApprovalDate:   testDate("2024-02-12"),
+								DeliveredQuantity: 1000,
+								Status:            "PARTIALLY_DELIVERED",
 								Batches: []Batch{
 									{
 										BatchNo:        "1/2",
 										TotalQuantity:  1400,
-										SubmissionDate: "2024-10-10",
+										SubmissionDate: testDate("2024-10-10"),
 										Remarks:        "Some Remarks",
+										RemarkFlags:    []string{},
 										Status:         "PARTIAL_BUFFER", // Update based on your logic
 										BatchTestApprovals: []BatchTestApproval{
 											{
-												ApprovalDate: "2024-05-01",
+												ApprovalDate: testDate("2024-05-01"),
+												Remarks:      []string{"Some Remarks"},
 												TestDrumNumbers: []BatchTestDrumNumbers{
 													{
 														DrumSize:    200,
@@ -847,23 +914,27 @@ func TestProcessRows(t *testing.T) {
 														DrumNumbers: []int{101, 102, 103, 104, 105},
 													},
 												},
-												Status:          "APPROVED",
-												ApprovalComment: "Batch Test report uploaded on Go-live phase 1",
+												Status: "APPROVED",
 											},
 										},
 										DrumPartitions: []DrumPartition{
 											{
-												DrumSize:             200,
-												Quantity:             1400,
-												UnapprovedQuantity:   400,
-												AvailableQuantity:    600,
-												AvailableDrumNumbers: []int{101, 102, 103},
-												BufferQuantity:       200,
-												BufferDrumNumbers:    []int{104},
-												TestQuantity:         2.5,
-												TestDrumNumbers:      []DrumDetails{{DrumNumber: 105, Quantity: 2.5}},
-												ShortQuantity:        197.5,
-												ShortDrumNumbers:     []DrumDetails{{DrumNumber: 105, Quantity: 197.5}},
+												DrumSize:              200,
+												Unit:                  "m",
+												Quantity:              1400,
+												UnapprovedQuantity:    400,
+												UnapprovedDrumNumbers: []int{},
+												AvailableQuantity:     600,
+												AvailableDrumNumbers:  []int{101, 102, 103},
+												BufferQuantity:        200,
+												BufferDrumNumbers:     []int{104},
+												TestQuantity:          2.5,
+												TestDrumNumbers:       []DrumDetails{{DrumNumber: 105, Quantity: 2.5}},
+												ShortQuantity:         197.5,
+												ShortDrumNumbers:      []DrumDetails{{DrumNumber: 105, Quantity: 197.5}},
+												PartialDrumNumbers:    []DrumDetails{},
+												Remarks:               []string{"Some Remarks"},
+												RemarkFlags:           []string{},
 											},
 										},
 									},
@@ -899,7 +970,7 @@ func TestProcessRows(t *testing.T) {
 					BufferDrumNo:            []int{4},
 					BufferNoOfDrums:         1,
 					BufferQuantity:          200,
-					SampleDrum:              "Yes",
+					SampleDrum:              true,
 					SampleDrumNo:            []int{5},
 					SampleLength:            []float64{2.5},
 					NoOfShortLengthDrums:    1,
@@ -929,7 +1000,7 @@ func TestProcessRows(t *testing.T) {
 					BufferDrumNo:            []int{},
 					BufferNoOfDrums:         0,
 					BufferQuantity:          0,
-					SampleDrum:              "Yes",
+					SampleDrum:              true,
 					SampleDrumNo:            []int{7},
 					SampleLength:            []float64{2.5},
 					NoOfShortLengthDrums:    1,
@@ -944,24 +1015,29 @@ func TestProcessRows(t *testing.T) {
 				Contracts: []Contracts{
 					{ // Contract 1
 						ContractNo: "C123",
+						Vendor:     "Supplier A",
 						LIs: []LI{
 							{
-								LiCode:          "LI001",
-								LiNumber:        "1",
-								MaterialCode:    "MAT100",
-								Description:     "Material A",
-								HosApprovalDate: "2024-02-12",
-								Status:          "VENDOR_ACKNOWLEDGED",
+								LiCode:            "LI001",
+								LiNumber:          "1",
+								MaterialCode:      "MAT100",
+								Description:       "Material A",
+								Unit:              "m",
+								HosApprovalDate:   testDate("2024-02-12"),
+								DeliveredQuantity: 1600,
+								Status:            "PARTIALLY_DELIVERED",
 								Batches: []Batch{
 									{
 										BatchNo:        "1/2",
 										TotalQuantity:  1600,
-										SubmissionDate: "2024-10-10",
+										SubmissionDate: testDate("2024-10-10"),
 										Remarks:        "Some Remarks",
+										RemarkFlags:    []string{},
 										Status:         "PARTIAL_BUFFER",
 										BatchTestApprovals: []BatchTestApproval{
 											{
-												ApprovalDate: "2024-05-01",
+												ApprovalDate: testDate("2024-05-01"),
+												Remarks:      []string{"Some Remarks"},
 												TestDrumNumbers: []BatchTestDrumNumbers{
 													{
 														DrumSize:    200,
@@ -982,36 +1058,45 @@ func TestProcessRows(t *testing.T) {
 														DrumNumbers: []int{6, 7},
 													},
 												},
-												Status:          "APPROVED",
-												ApprovalComment: "Batch Test report uploaded on Go-live phase 1",
+												Status: "APPROVED",
 											},
 										},
 										DrumPartitions: []DrumPartition{
 											{
-												DrumSize:             200,
-												Quantity:             1000,
-												UnapprovedQuantity:   0,
-												AvailableQuantity:    600,
-												AvailableDrumNumbers: []int{1, 2, 3},
-												BufferQuantity:       200,
-												BufferDrumNumbers:    []int{4},
-												TestQuantity:         2.5,
-												TestDrumNumbers:      []DrumDetails{{DrumNumber: 5, Quantity: 2.5}},
-												ShortQuantity:        197.5,
-												ShortDrumNumbers:     []DrumDetails{{DrumNumber: 5, Quantity: 197.5}},
+												DrumSize:              200,
+												Unit:                  "m",
+												Quantity:              1000,
+												UnapprovedQuantity:    0,
+												UnapprovedDrumNumbers: []int{},
+												AvailableQuantity:     600,
+												AvailableDrumNumbers:  []int{1, 2, 3},
+												BufferQuantity:        200,
+												BufferDrumNumbers:     []int{4},
+												TestQuantity:          2.5,
+												TestDrumNumbers:       []DrumDetails{{DrumNumber: 5, Quantity: 2.5}},
+												ShortQuantity:         197.5,
+												ShortDrumNumbers:      []DrumDetails{{DrumNumber: 5, Quantity: 197.5}},
+												PartialDrumNumbers:    []DrumDetails{},
+												Remarks:               []string{"Some Remarks"},
+												RemarkFlags:           []string{},
 											},
 											{
-												DrumSize:             300,
-												Quantity:             600,
-												UnapprovedQuantity:   0,
-												AvailableQuantity:    300,
-												AvailableDrumNumbers: []int{6},
-												BufferQuantity:       0,
-												BufferDrumNumbers:    []int{},
-												TestQuantity:         2.5,
-												TestDrumNumbers:      []DrumDetails{{DrumNumber: 7, Quantity: 2.5}},
-												ShortQuantity:        297.5,
-												ShortDrumNumbers:     []DrumDetails{{DrumNumber: 7, Quantity: 297.5}},
+												DrumSize:              300,
+												Unit:                  "m",
+												Quantity:              600,
+												UnapprovedQuantity:    0,
+												UnapprovedDrumNumbers: []int{},
+												AvailableQuantity:     300,
+												AvailableDrumNumbers:  []int{6},
+												BufferQuantity:        0,
+												BufferDrumNumbers:     []int{},
+												TestQuantity:          2.5,
+												TestDrumNumbers:       []DrumDetails{{DrumNumber: 7, Quantity: 2.5}},
+												ShortQuantity:         297.5,
+												ShortDrumNumbers:      []DrumDetails{{DrumNumber: 7, Quantity: 297.5}},
+												PartialDrumNumbers:    []DrumDetails{},
+												Remarks:               []string{"Some Remarks"},
+												RemarkFlags:           []string{},
 											},
 										},
 									},
@@ -1047,7 +1132,7 @@ func TestProcessRows(t *testing.T) {
 					BufferDrumNo:            []int{4},
 					BufferNoOfDrums:         1,
 					BufferQuantity:          200,
-					SampleDrum:              "Yes",
+					SampleDrum:              true,
 					SampleDrumNo:            []int{5},
 					SampleLength:            []float64{2.5},
 					NoOfShortLengthDrums:    1,
@@ -1077,7 +1162,7 @@ func TestProcessRows(t *testing.T) {
 					BufferDrumNo:            []int{},
 					BufferNoOfDrums:         0,
 					BufferQuantity:          0,
-					SampleDrum:              "Yes",
+					SampleDrum:              true,
 					SampleDrumNo:            []int{7},
 					SampleLength:            []float64{2.5},
 					NoOfShortLengthDrums:    1,
@@ -1092,24 +1177,29 @@ func TestProcessRows(t *testing.T) {
 				Contracts: []Contracts{
 					{ // Contract 1
 						ContractNo: "C123",
+						Vendor:     "Supplier A",
 						LIs: []LI{
 							{
-								LiCode:          "LI001",
-								LiNumber:        "1",
-								MaterialCode:    "MAT100",
-								Description:     "Material A",
-								HosApprovalDate: "2024-02-12",
-								Status:          "VENDOR_ACKNOWLEDGED",
+								LiCode:            "LI001",
+								LiNumber:          "1",
+								MaterialCode:      "MAT100",
+								Description:       "Material A",
+								Unit:              "m",
+								HosApprovalDate:   testDate("2024-02-12"),
+								DeliveredQuantity: 1600,
+								Status:            "PARTIALLY_DELIVERED",
 								Batches: []Batch{
 									{
 										BatchNo:        "1/2",
 										TotalQuantity:  1600,
-										SubmissionDate: "2024-10-10",
+										SubmissionDate: testDate("2024-10-10"),
 										Remarks:        "Some Remarks",
+										RemarkFlags:    []string{},
 										Status:         "PARTIAL_BUFFER",
 										BatchTestApprovals: []BatchTestApproval{
 											{
-												ApprovalDate: "2024-05-01",
+												ApprovalDate: testDate("2024-05-01"),
+												Remarks:      []string{"Some Remarks"},
 												TestDrumNumbers: []BatchTestDrumNumbers{
 													{
 														DrumSize:    200,
@@ -1122,11 +1212,11 @@ func TestProcessRows(t *testing.T) {
 														DrumNumbers: []int{1, 2, 3, 4, 5},
 													},
 												},
-												Status:          "APPROVED",
-												ApprovalComment: "Batch Test report uploaded on Go-live phase 1",
+												Status: "APPROVED",
 											},
 											{
-												ApprovalDate: "2024-05-02",
+												ApprovalDate: testDate("2024-05-02"),
+												Remarks:      []string{"Some Remarks"},
 												TestDrumNumbers: []BatchTestDrumNumbers{
 													{
 														DrumSize:    300,
@@ -1139,36 +1229,45 @@ func TestProcessRows(t *testing.T) {
 														DrumNumbers: []int{6, 7},
 													},
 												},
-												Status:          "APPROVED",
-												ApprovalComment: "Batch Test report uploaded on Go-live phase 1",
+												Status: "APPROVED",
 											},
 										},
 										DrumPartitions: []DrumPartition{
 											{
-												DrumSize:             200,
-												Quantity:             1000,
-												UnapprovedQuantity:   0,
-												AvailableQuantity:    600,
-												AvailableDrumNumbers: []int{1, 2, 3},
-												BufferQuantity:       200,
-												BufferDrumNumbers:    []int{4},
-												TestQuantity:         2.5,
-												TestDrumNumbers:      []DrumDetails{{DrumNumber: 5, Quantity: 2.5}},
-												ShortQuantity:        197.5,
-												ShortDrumNumbers:     []DrumDetails{{DrumNumber: 5, Quantity: 197.5}},
+												DrumSize:              200,
+												Unit:                  "m",
+												Quantity:              1000,
+												UnapprovedQuantity:    0,
+												UnapprovedDrumNumbers: []int{},
+												AvailableQuantity:     600,
+												AvailableDrumNumbers:  []int{1, 2, 3},
+												BufferQuantity:        200,
+												BufferDrumNumbers:     []int{4},
+												TestQuantity:          2.5,
+												TestDrumNumbers:       []DrumDetails{{DrumNumber: 5, Quantity: 2.5}},
+												ShortQuantity:         197.5,
+												ShortDrumNumbers:      []DrumDetails{{DrumNumber: 5, Quantity: 197.5}},
+												PartialDrumNumbers:    []DrumDetails{},
+												Remarks:               []string{"Some Remarks"},
+												RemarkFlags:           []string{},
 											},
 											{
-												DrumSize:             300,
-												Quantity:             600,
-												UnapprovedQuantity:   0,
-												AvailableQuantity:    300,
-												AvailableDrumNumbers: []int{6},
-												BufferQuantity:       0,
-												BufferDrumNumbers:    []int{},
-												TestQuantity:         2.5,
-												TestDrumNumbers:      []DrumDetails{{DrumNumber: 7, Quantity: 2.5}},
-												ShortQuantity:        297.5,
-												ShortDrumNumbers:     []DrumDetails{{DrumNumber: 7, Quantity: 297.5}},
+												DrumSize:              300,
+												Unit:                  "m",
+												Quantity:              600,
+												UnapprovedQuantity:    0,
+												UnapprovedDrumNumbers: []int{},
+												AvailableQuantity:     300,
+												AvailableDrumNumbers:  []int{6},
+												BufferQuantity:        0,
+												BufferDrumNumbers:     []int{},
+												TestQuantity:          2.5,
+												TestDrumNumbers:       []DrumDetails{{DrumNumber: 7, Quantity: 2.5}},
+												ShortQuantity:         297.5,
+												ShortDrumNumbers:      []DrumDetails{{DrumNumber: 7, Quantity: 297.5}},
+												PartialDrumNumbers:    []DrumDetails{},
+												Remarks:               []string{"Some Remarks"},
+												RemarkFlags:           []string{},
 											},
 										},
 									},
@@ -1204,7 +1303,7 @@ func TestProcessRows(t *testing.T) {
 					BufferDrumNo:            []int{4},
 					BufferNoOfDrums:         1,
 					BufferQuantity:          200,
-					SampleDrum:              "Yes",
+					SampleDrum:              true,
 					SampleDrumNo:            []int{5},
 					SampleLength:            []float64{2.5},
 					NoOfShortLengthDrums:    1,
@@ -1234,7 +1333,7 @@ func TestProcessRows(t *testing.T) {
 					BufferDrumNo:            []int{},
 					BufferNoOfDrums:         0,
 					BufferQuantity:          0,
-					SampleDrum:              "Yes",
+					SampleDrum:              true,
 					SampleDrumNo:            []int{},
 					SampleLength:            []float64{},
 					NoOfShortLengthDrums:    0,
@@ -1249,24 +1348,29 @@ func TestProcessRows(t *testing.T) {
 				Contracts: []Contracts{
 					{ // Contract 1
 						ContractNo: "C123",
+						Vendor:     "Supplier A",
 						LIs: []LI{
 							{
-								LiCode:          "LI001",
-								LiNumber:        "1",
-								MaterialCode:    "MAT100",
-								Description:     "Material A",
-								HosApprovalDate: "2024-02-12",
-								Status:          "VENDOR_ACKNOWLEDGED",
+								LiCode:            "LI001",
+								LiNumber:          "1",
+								MaterialCode:      "MAT100",
+								Description:       "Material A",
+								Unit:              "m",
+								HosApprovalDate:   testDate("2024-02-12"),
+								DeliveredQuantity: 1000,
+								Status:            "PARTIALLY_DELIVERED",
 								Batches: []Batch{
 									{
 										BatchNo:        "1/2",
 										TotalQuantity:  1600,
-										SubmissionDate: "2024-10-10",
+										SubmissionDate: testDate("2024-10-10"),
 										Remarks:        "Some Remarks",
+										RemarkFlags:    []string{},
 										Status:         "PARTIAL_BUFFER",
 										BatchTestApprovals: []BatchTestApproval{
 											{
-												ApprovalDate: "2024-05-01",
+												ApprovalDate: testDate("2024-05-01"),
+												Remarks:      []string{"Some Remarks"},
 												TestDrumNumbers: []BatchTestDrumNumbers{
 													{
 														DrumSize:    200,
@@ -1279,36 +1383,45 @@ func TestProcessRows(t *testing.T) {
 														DrumNumbers: []int{1, 2, 3, 4, 5},
 													},
 												},
-												Status:          "APPROVED",
-												ApprovalComment: "Batch Test report uploaded on Go-live phase 1",
+												Status: "APPROVED",
 											},
 										},
 										DrumPartitions: []DrumPartition{
 											{
-												DrumSize:             200,
-												Quantity:             1000,
-												UnapprovedQuantity:   0,
-												AvailableQuantity:    600,
-												AvailableDrumNumbers: []int{1, 2, 3},
-												BufferQuantity:       200,
-												BufferDrumNumbers:    []int{4},
-												TestQuantity:         2.5,
-												TestDrumNumbers:      []DrumDetails{{DrumNumber: 5, Quantity: 2.5}},
-												ShortQuantity:        197.5,
-												ShortDrumNumbers:     []DrumDetails{{DrumNumber: 5, Quantity: 197.5}},
+												DrumSize:              200,
+												Unit:                  "m",
+												Quantity:              1000,
+												UnapprovedQuantity:    0,
+												UnapprovedDrumNumbers: []int{},
+												AvailableQuantity:     600,
+												AvailableDrumNumbers:  []int{1, 2, 3},
+												BufferQuantity:        200,
+												BufferDrumNumbers:     []int{4},
+												TestQuantity:          2.5,
+												TestDrumNumbers:       []DrumDetails{{DrumNumber: 5, Quantity: 2.5}},
+												ShortQuantity:         197.5,
+												ShortDrumNumbers:      []DrumDetails{{DrumNumber: 5, Quantity: 197.5}},
+												PartialDrumNumbers:    []DrumDetails{},
+												Remarks:               []string{"Some Remarks"},
+												RemarkFlags:           []string{},
 											},
 											{
-												DrumSize:             300,
-												Quantity:             600,
-												UnapprovedQuantity:   600,
-												AvailableQuantity:    0,
-												AvailableDrumNumbers: []int{},
-												BufferQuantity:       0,
-												BufferDrumNumbers:    []int{},
-												TestQuantity:         0,
-												TestDrumNumbers:      []DrumDetails{},
-												ShortQuantity:        0,
-												ShortDrumNumbers:     []DrumDetails{},
+												DrumSize:              300,
+												Unit:                  "m",
+												Quantity:              600,
+												UnapprovedQuantity:    600,
+												UnapprovedDrumNumbers: []int{},
+												AvailableQuantity:     0,
+												AvailableDrumNumbers:  []int{},
+												BufferQuantity:        0,
+												BufferDrumNumbers:     []int{},
+												TestQuantity:          0,
+												TestDrumNumbers:       []DrumDetails{},
+												ShortQuantity:         0,
+												ShortDrumNumbers:      []DrumDetails{},
+												PartialDrumNumbers:    []DrumDetails{},
+												Remarks:               []string{"Some Remarks"},
+												RemarkFlags:           []string{},
 											},
 										},
 									},
@@ -1344,7 +1457,7 @@ func TestProcessRows(t *testing.T) {
 					BufferDrumNo:            []int{104},
 					BufferNoOfDrums:         1,
 					BufferQuantity:          200,
-					SampleDrum:              "Yes",
+					SampleDrum:              true,
 					SampleDrumNo:            []int{105},
 					SampleLength:            []float64{2.5},
 					NoOfShortLengthDrums:    1,
@@ -1374,7 +1487,7 @@ func TestProcessRows(t *testing.T) {
 					BufferDrumNo:            []int{},
 					BufferNoOfDrums:         0,
 					BufferQuantity:          0,
-					SampleDrum:              "Yes",
+					SampleDrum:              true,
 					SampleDrumNo:            []int{},
 					SampleLength:            []float64{},
 					NoOfShortLengthDrums:    0,
@@ -1389,24 +1502,29 @@ func TestProcessRows(t *testing.T) {
 				Contracts: []Contracts{
 					{ // Contract 1
 						ContractNo: "C123",
+						Vendor:     "Supplier A",
 						LIs: []LI{
 							{
-								LiCode:          "LI001",
-								LiNumber:        "1",
-								MaterialCode:    "MAT100",
-								Description:     "Material A",
-								HosApprovalDate: "2024-02-12",
-								Status:          "VENDOR_ACKNOWLEDGED",
+								LiCode:            "LI001",
+								LiNumber:          "1",
+								MaterialCode:      "MAT100",
+								Description:       "Material A",
+								Unit:              "m",
+								HosApprovalDate:   testDate("2024-02-12"),
+								DeliveredQuantity: 1000,
+								Status:            "PARTIALLY_DELIVERED",
 								Batches: []Batch{
 									{
 										BatchNo:        "1/2",
 										TotalQuantity:  1000,
-										SubmissionDate: "2024-10-10",
+										SubmissionDate: testDate("2024-10-10"),
 										Remarks:        "Some Remarks",
+										RemarkFlags:    []string{},
 										Status:         "PARTIAL_BUFFER",
 										BatchTestApprovals: []BatchTestApproval{
 											{
-												ApprovalDate: "2024-05-01",
+												ApprovalDate: testDate("2024-05-01"),
+												Remarks:      []string{"Some Remarks"},
 												TestDrumNumbers: []BatchTestDrumNumbers{
 													{
 														DrumSize:    200,
@@ -1419,46 +1537,56 @@ func TestProcessRows(t *testing.T) {
 														DrumNumbers: []int{101, 102, 103, 104, 105},
 													},
 												},
-												Status:          "APPROVED",
-												ApprovalComment: "Batch Test report uploaded on Go-live phase 1",
+												Status: "APPROVED",
 											},
 										},
 										DrumPartitions: []DrumPartition{
 											{
-												DrumSize:             200,
-												Quantity:             1000,
-												UnapprovedQuantity:   0,
-												AvailableQuantity:    600,
-												AvailableDrumNumbers: []int{101, 102, 103},
-												BufferQuantity:       200,
-												BufferDrumNumbers:    []int{104},
-												TestQuantity:         2.5,
-												TestDrumNumbers:      []DrumDetails{{DrumNumber: 105, Quantity: 2.5}},
-												ShortQuantity:        197.5,
-												ShortDrumNumbers:     []DrumDetails{{DrumNumber: 105, Quantity: 197.5}},
+												DrumSize:              200,
+												Unit:                  "m",
+												Quantity:              1000,
+												UnapprovedQuantity:    0,
+												UnapprovedDrumNumbers: []int{},
+												AvailableQuantity:     600,
+												AvailableDrumNumbers:  []int{101, 102, 103},
+												BufferQuantity:        200,
+												BufferDrumNumbers:     []int{104},
+												TestQuantity:          2.5,
+												TestDrumNumbers:       []DrumDetails{{DrumNumber: 105, Quantity: 2.5}},
+												ShortQuantity:         197.5,
+												ShortDrumNumbers:      []DrumDetails{{DrumNumber: 105, Quantity: 197.5}},
+												PartialDrumNumbers:    []DrumDetails{},
+												Remarks:               []string{"Some Remarks"},
+												RemarkFlags:           []string{},
 											},
 										},
 									},
 									{
 										BatchNo:            "2/2",
 										TotalQuantity:      400,
-										SubmissionDate:     "2024-12-10",
+										SubmissionDate:     testDate("2024-12-10"),
 										Remarks:            "Some Remarks",
+										RemarkFlags:        []string{},
 										Status:             "DOCS_PENDING_UPLOAD",
 										BatchTestApprovals: []BatchTestApproval{},
 										DrumPartitions: []DrumPartition{
 											{
-												DrumSize:             200,
-												Quantity:             400,
-												UnapprovedQuantity:   400,
-												AvailableQuantity:    0,
-												AvailableDrumNumbers: []int{},
-												BufferQuantity:       0,
-												BufferDrumNumbers:    []int{},
-												TestQuantity:         0,
-												TestDrumNumbers:      []DrumDetails{},
-												ShortQuantity:        0,
-												ShortDrumNumbers:     []DrumDetails{},
+												DrumSize:              200,
+												Unit:                  "m",
+												Quantity:              400,
+												UnapprovedQuantity:    400,
+												UnapprovedDrumNumbers: []int{},
+												AvailableQuantity:     0,
+												AvailableDrumNumbers:  []int{},
+												BufferQuantity:        0,
+												BufferDrumNumbers:     []int{},
+												TestQuantity:          0,
+												TestDrumNumbers:       []DrumDetails{},
+												ShortQuantity:         0,
+												ShortDrumNumbers:      []DrumDetails{},
+												PartialDrumNumbers:    []DrumDetails{},
+												Remarks:               []string{"Some Remarks"},
+												RemarkFlags:           []string{},
 											},
 										},
 									},
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RowSource is a row of a vendor file that a drum partition or the approved drums of a drum size were read from, with
+// the drum numbers the row listed for them.
+type RowSource struct {
+	File        string `json:"file,omitempty"`
+	RowNo       int    `json:"row_no"`
+	DrumNumbers []int  `json:"drum_numbers"`
+}
+
+// sourceRef names a row for error messages, with its file when known.
+func sourceRef(file string, rowNo int) string {
+	if file == "" {
+		return fmt.Sprintf("row %d", rowNo)
+	}
+	return fmt.Sprintf("%s row %d", file, rowNo)
+}
+
+func (s RowSource) String() string {
+	return sourceRef(s.File, s.RowNo)
+}
+
+// sourceOf records the drums a row listed.
+func sourceOf(file string, rowIndex int, drumNumbers []int) RowSource {
+	return RowSource{File: file, RowNo: rowIndex + 1, DrumNumbers: drumNumbers}
+}
+
+// recordSource adds a placed row to the sources of its drum partition, with the drums it lists, and to the sources
+// of the approved drums of its drum size in its batch test approval, with the drums it approves.
+func (b *inventoryBuilder) recordSource(key partitionKey, row CSVRow, rowIndex int) {
+	contract := b.res.Contracts[b.index.contracts[key.contractNo]]
+	li := contract.LIs[b.index.lis[key.liKey]]
+	batch := &li.Batches[b.index.batches[key.batchKey]]
+
+	dp := &batch.DrumPartitions[b.index.partitions[key]]
+	dp.Sources = append(dp.Sources, sourceOf(b.file, rowIndex, listedDrums(row)))
+
+	if row.BatchTestReportDate == "" {
+		return
+	}
+	bta := newBatchTestApproval(row)
+	i, ok := b.index.approvals[approvalKey{batchKey: key.batchKey, approvalDate: bta.ApprovalDate, status: bta.Status}]
+	if !ok {
+		return
+	}
+	approved := distinctDrumNumbers(row.ApprovedDrumNumbers)
+	sort.Ints(approved)
+	approvalDrumNumbers := batch.BatchTestApprovals[i].ApprovalDrumNumbers
+	for j := range approvalDrumNumbers {
+		if approvalDrumNumbers[j].DrumSize == key.drumSize {
+			approvalDrumNumbers[j].Sources = append(approvalDrumNumbers[j].Sources, sourceOf(b.file, rowIndex, approved))
+		}
+	}
+}
+
+// sourcesListing are the sources that listed a drum number.
+func sourcesListing(sources []RowSource, drumNo int) []RowSource {
+	var listing []RowSource
+	for _, source := range sources {
+		if containsDrumNumber(source.DrumNumbers, drumNo) {
+			listing = append(listing, source)
+		}
+	}
+	return listing
+}
+
+// citeDrumConflicts reports the available and buffer drums of a row already listed in its drum partition by
+// earlier rows, citing every row that listed them. Rows that reject their drums list none.
+func (b *inventoryBuilder) citeDrumConflicts(key partitionKey, source RowSource, row CSVRow) {
+	if row.rejectsDrums() {
+		return
+	}
+	if b.drumRows[key] == nil {
+		b.drumRows[key] = make(map[int][]RowSource)
+	}
+	for _, drumNo := range distinctDrumNumbers(append(append([]int{}, row.AvailableDrumNos...), row.BufferDrumNo...)) {
+		if earlier := b.drumRows[key][drumNo]; len(earlier) > 0 {
+			refs := make([]string, 0, len(earlier))
+			for _, s := range earlier {
+				refs = append(refs, s.String())
+			}
+			b.errors = append(b.errors, Error{RowNo: source.RowNo, Err: fmt.Errorf("drum number %d of drum size %d in batch %s is also listed in %s", drumNo, key.drumSize, key.batchNo, strings.Join(refs, ", "))})
+		}
+		b.drumRows[key][drumNo] = append(b.drumRows[key][drumNo], source)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessRows_CitesConflictingRows(t *testing.T) {
+	rows := []CSVRow{
+		approvalTestRow("01-03-2024", "APPROVED", []int{1, 2}),
+		approvalTestRow("01-04-2024", "APPROVED", []int{3}),
+		approvalTestRow("01-05-2024", "APPROVED", []int{2, 3}),
+	}
+
+	builder := newInventoryBuilder()
+	builder.withProvenance = true
+	for rowIndex, row := range rows {
+		builder.addRow(row, rowIndex)
+	}
+	_, errs := builder.finish()
+
+	var got []string
+	for _, e := range errs {
+		if strings.HasPrefix(e.Err.Error(), "drum number") {
+			assert.Equal(t, 3, e.RowNo)
+			got = append(got, e.Err.Error())
+		}
+	}
+	assert.Equal(t, []string{
+		"drum number 2 of drum size 250 in batch 1/2 is also listed in row 1",
+		"drum number 3 of drum size 250 in batch 1/2 is also listed in row 2",
+	}, got)
+}
+
+func Test_parseNamedCSV_Provenance(t *testing.T) {
+	lines := strings.SplitAfter(generateCSV(2), "\n")
+	// the second row approves the drums of the first row in another batch of the same LI
+	lines[2] = strings.Replace(lines[1], ",1/50,", ",2/50,", 1)
+
+	options := defaultParseOptions()
+	options.withProvenance = true
+	got, errs := parseNamedCSV("2024-01.csv", strings.NewReader(strings.Join(lines, "")), options)
+
+	batch := got.Contracts[0].LIs[0].Batches[1]
+	assert.Equal(t, "2/50", batch.BatchNo)
+	assert.Equal(t, []RowSource{{File: "2024-01.csv", RowNo: 2, DrumNumbers: []int{1, 2, 3}}}, batch.DrumPartitions[0].Sources)
+	assert.Equal(t, []RowSource{{File: "2024-01.csv", RowNo: 2, DrumNumbers: []int{1, 2, 3}}},
+		batch.BatchTestApprovals[0].ApprovalDrumNumbers[0].Sources)
+
+	assert.Len(t, errs, 3)
+	assert.Equal(t, "overlapping drum numbers found for contract 9190369 material code 101642: drum 1 claimed by "+
+		"contract 9190369 LI Li-1 batch 1/50 drum size 250 approved 2024-12-30 (2024-01.csv row 1); "+
+		"contract 9190369 LI Li-1 batch 2/50 drum size 250 approved 2024-12-30 (2024-01.csv row 2)", errs[0].Err.Error())
+}
+
+func Test_parseNamedCSV_WithoutProvenance(t *testing.T) {
+	got, errs := parseNamedCSV("2024-01.csv", strings.NewReader(generateCSV(2)), defaultParseOptions())
+	assert.Empty(t, errs)
+
+	output, err := marshalRecords(got, outputOptions{})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(output), "sources")
+}
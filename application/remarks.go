@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Flags for the well-known vendor remarks
+const (
+	remarkFlagBuffer  = "BUFFER"
+	remarkFlagPartial = "PARTIAL"
+)
+
+// addRemark appends a remark unless it is empty or already there.
+func addRemark(remarks []string, remark string) []string {
+	if remarks == nil {
+		remarks = []string{}
+	}
+	remark = strings.TrimSpace(remark)
+	if remark == "" {
+		return remarks
+	}
+	for _, r := range remarks {
+		if strings.EqualFold(r, remark) {
+			return remarks
+		}
+	}
+	return append(remarks, remark)
+}
+
+// remarkFlags maps the well-known remarks to flags, a remark mentioning "buffer" flags BUFFER and a remark
+// mentioning "partial" flags PARTIAL.
+func remarkFlags(remarks []string) []string {
+	flags := make([]string, 0)
+	for _, flag := range []string{remarkFlagBuffer, remarkFlagPartial} {
+		for _, remark := range remarks {
+			if strings.Contains(strings.ToLower(remark), strings.ToLower(flag)) {
+				flags = append(flags, flag)
+				break
+			}
+		}
+	}
+	return flags
+}
+
+// aggregateRemarks sets the remarks of a batch to the distinct remarks of its drum partitions and batch test
+// approvals, in the order they were first given, and flags them.
+func aggregateRemarks(batch Batch) Batch {
+	var remarks []string
+	for _, dp := range batch.DrumPartitions {
+		for _, remark := range dp.Remarks {
+			remarks = addRemark(remarks, remark)
+		}
+	}
+	for _, bta := range batch.BatchTestApprovals {
+		for _, remark := range bta.Remarks {
+			remarks = addRemark(remarks, remark)
+		}
+	}
+
+	batch.Remarks = strings.Join(remarks, "; ")
+	batch.RemarkFlags = remarkFlags(remarks)
+	return batch
+}
+
+// statedStatus is the batch status the vendor's remarks claim, empty when the remarks claim none.
+func statedStatus(flags []string) string {
+	var buffer, partial bool
+	for _, flag := range flags {
+		buffer = buffer || flag == remarkFlagBuffer
+		partial = partial || flag == remarkFlagPartial
+	}
+	switch {
+	case partial:
+		return "PARTIAL_BUFFER"
+	case buffer:
+		return "BUFFER"
+	}
+	return ""
+}
+
+// reconcileRemarks warns about every batch whose remarks claim a status other than the computed one, listing the
+// quantities of each drum partition the computed status was derived from. The warnings are kept apart from the errors
+// of the file: a remark that disagrees does not make the conversion wrong.
+func (u UploadInventoryInput) reconcileRemarks() []Error {
+	warnings := make([]Error, 0)
+	for _, contract := range u.Contracts {
+		for _, li := range contract.LIs {
+			for _, batch := range li.Batches {
+				stated := statedStatus(batch.RemarkFlags)
+				if stated == "" || stated == batch.Status {
+					continue
+				}
+
+				quantities := make([]string, 0, len(batch.DrumPartitions))
+				for _, dp := range batch.DrumPartitions {
+					quantities = append(quantities, fmt.Sprintf("drum size %d: quantity %d, available %d, buffer %d, test %g, short %g, partial %g, unapproved %d",
+						dp.DrumSize, dp.Quantity, dp.AvailableQuantity, dp.BufferQuantity, dp.TestQuantity, dp.ShortQuantity, dp.PartialQuantity, dp.UnapprovedQuantity))
+				}
+
+				msg := fmt.Sprintf("remarks %q of contract %s LI %s-%s batch %s state %s but the computed status is %s; %s",
+					batch.Remarks, contract.ContractNo, li.LiCode, li.LiNumber, batch.BatchNo, stated, batch.Status, strings.Join(quantities, "; "))
+				if refs := batchSourceRefs(batch); len(refs) > 0 {
+					msg += " (" + strings.Join(refs, ", ") + ")"
+				}
+				warnings = append(warnings, Error{RowNo: 0, Err: fmt.Errorf("%s", msg)})
+			}
+		}
+	}
+	return warnings
+}
+
+// batchSourceRefs names the rows a batch was read from, when the sources of the rows are known.
+func batchSourceRefs(batch Batch) []string {
+	var sources []RowSource
+	for _, dp := range batch.DrumPartitions {
+		sources = append(sources, dp.Sources...)
+	}
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].RowNo < sources[j].RowNo
+	})
+	refs := make([]string, 0, len(sources))
+	for _, source := range sources {
+		refs = append(refs, source.String())
+	}
+	return refs
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_addRemark(t *testing.T) {
+	assert.Equal(t, []string{}, addRemark(nil, " "))
+	assert.Equal(t, []string{"Buffer"}, addRemark(nil, " Buffer "))
+	assert.Equal(t, []string{"Buffer"}, addRemark([]string{"Buffer"}, "buffer"))
+	assert.Equal(t, []string{"Buffer", "Partial"}, addRemark([]string{"Buffer"}, "Partial"))
+}
+
+func Test_remarkFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		remarks []string
+		want    []string
+	}{
+		{name: "no remarks", want: []string{}},
+		{name: "free text", remarks: []string{"Initial LI"}, want: []string{}},
+		{name: "buffer", remarks: []string{"Buffer"}, want: []string{remarkFlagBuffer}},
+		{name: "partial", remarks: []string{"partial"}, want: []string{remarkFlagPartial}},
+		{name: "both in one remark", remarks: []string{"Partial Buffer"}, want: []string{remarkFlagBuffer, remarkFlagPartial}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, remarkFlags(tt.remarks))
+		})
+	}
+}
+
+func TestProcessRows_RemarksPerDrumPartition(t *testing.T) {
+	first := approvalTestRow("01-03-2024", "APPROVED", []int{1, 2})
+	first.Remarks = "Buffer"
+	second := approvalTestRow("01-03-2024", "APPROVED", []int{3})
+	second.DrumSize = 500
+	second.TotalQty = 500
+	second.FullDrumTotalQuantity = 500
+	second.Remarks = "Partial"
+
+	got, errs := processRows([]CSVRow{first, second})
+	assert.Empty(t, errs)
+
+	batch := got.Contracts[0].LIs[0].Batches[0]
+	assert.Equal(t, []string{"Buffer"}, batch.DrumPartitions[0].Remarks)
+	assert.Equal(t, []string{remarkFlagBuffer}, batch.DrumPartitions[0].RemarkFlags)
+	assert.Equal(t, []string{"Partial"}, batch.DrumPartitions[1].Remarks)
+	assert.Equal(t, []string{remarkFlagPartial}, batch.DrumPartitions[1].RemarkFlags)
+	assert.Equal(t, []string{"Buffer", "Partial"}, batch.BatchTestApprovals[0].Remarks)
+	assert.Equal(t, "Buffer; Partial", batch.Remarks)
+	assert.Equal(t, []string{remarkFlagBuffer, remarkFlagPartial}, batch.RemarkFlags)
+}
+
+func TestUploadInventoryInput_reconcileRemarks(t *testing.T) {
+	stated := approvalTestRow("01-03-2024", "APPROVED", []int{1, 2})
+	stated.Remarks = "Buffer"
+	stated.BufferDrumNo = []int{2}
+	stated.BufferNoOfDrums = 1
+	stated.BufferQuantity = 250
+	stated.AvailableDrumNos = []int{1}
+	stated.AvailableFullDrums = 1
+	stated.FullDrumTotalQuantity = 250
+
+	matching := approvalTestRow("01-03-2024", "APPROVED", []int{3})
+	matching.BatchNo = "2/2"
+	matching.Remarks = "Partial"
+	matching.AvailableDrumNos = []int{}
+	matching.AvailableFullDrums = 0
+	matching.FullDrumTotalQuantity = 0
+	matching.BufferDrumNo = []int{3}
+	matching.BufferNoOfDrums = 1
+	matching.BufferQuantity = 250
+
+	got, errs := processRows([]CSVRow{stated, matching})
+	assert.Empty(t, errs)
+	assert.Equal(t, "PARTIAL_BUFFER", got.Contracts[0].LIs[0].Batches[0].Status)
+	assert.Equal(t, "BUFFER", got.Contracts[0].LIs[0].Batches[1].Status)
+
+	got.Contracts[0].LIs[0].Batches[0].DrumPartitions[0].Sources = []RowSource{{File: "a.csv", RowNo: 1, DrumNumbers: []int{1, 2}}}
+	warnings := got.reconcileRemarks()
+
+	assert.Len(t, warnings, 2)
+	assert.Equal(t, `remarks "Buffer" of contract C123 LI LI001-1 batch 1/2 state BUFFER but the computed status is PARTIAL_BUFFER; `+
+		`drum size 250: quantity 500, available 250, buffer 250, test 0, short 0, partial 0, unapproved 0 (a.csv row 1)`, warnings[0].Err.Error())
+	assert.Equal(t, `remarks "Partial" of contract C123 LI LI001-1 batch 2/2 state PARTIAL_BUFFER but the computed status is BUFFER; `+
+		`drum size 250: quantity 250, available 0, buffer 250, test 0, short 0, partial 0, unapproved 0`, warnings[1].Err.Error())
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Levels of the lines of the summary report, from the most detailed to the grand total
+const (
+	summaryLevelDrumSize = "drum size"
+	summaryLevelBatch    = "batch"
+	summaryLevelLI       = "LI"
+	summaryLevelContract = "contract"
+	summaryLevelTotal    = "total"
+)
+
+// Formats the summary report is written in
+const (
+	summaryFormatText     = "text"
+	summaryFormatCSV      = "csv"
+	summaryFormatMarkdown = "markdown"
+)
+
+// stockCounts are the quantities of stock by state and the number of drums holding them. The test and short
+// quantities are cut from the same sample drums, a sample drum is counted once in the drums in total.
+type stockCounts struct {
+	Quantity        int
+	Drums           int
+	Available       int
+	AvailableDrums  int
+	Buffer          int
+	BufferDrums     int
+	Test            float64
+	TestDrums       int
+	Short           float64
+	ShortDrums      int
+	Partial         float64
+	Unapproved      int
+	UnapprovedDrums int
+}
+
+func (c *stockCounts) add(other stockCounts) {
+	c.Quantity += other.Quantity
+	c.Drums += other.Drums
+	c.Available += other.Available
+	c.AvailableDrums += other.AvailableDrums
+	c.Buffer += other.Buffer
+	c.BufferDrums += other.BufferDrums
+	c.Test += other.Test
+	c.TestDrums += other.TestDrums
+	c.Short += other.Short
+	c.ShortDrums += other.ShortDrums
+	c.Partial += other.Partial
+	c.Unapproved += other.Unapproved
+	c.UnapprovedDrums += other.UnapprovedDrums
+}
+
+// summaryLine is one line of the summary report: a drum partition or the roll-up of a batch, LI, contract or of
+// everything. Lines above the LI level are rolled up per unit, as quantities in different units do not add up.
+type summaryLine struct {
+	Level      string
+	ContractNo string
+	LI         string
+	BatchNo    string
+	DrumSize   int
+	Unit       string
+	stockCounts
+}
+
+// drumPartitionCounts counts the stock of a drum partition. Unapproved drums are counted from their numbers, or
+// from the quantity for snapshots written before the numbers were listed.
+func drumPartitionCounts(dp DrumPartition) stockCounts {
+	counts := stockCounts{
+		Quantity:        dp.Quantity,
+		Available:       dp.AvailableQuantity,
+		AvailableDrums:  len(dp.AvailableDrumNumbers),
+		Buffer:          dp.BufferQuantity,
+		BufferDrums:     len(dp.BufferDrumNumbers),
+		Test:            dp.TestQuantity,
+		TestDrums:       len(drumDetailsNumbers(dp.TestDrumNumbers)),
+		Short:           dp.ShortQuantity,
+		ShortDrums:      len(drumDetailsNumbers(dp.ShortDrumNumbers)),
+		Partial:         dp.PartialQuantity,
+		Unapproved:      dp.UnapprovedQuantity,
+		UnapprovedDrums: len(dp.UnapprovedDrumNumbers),
+	}
+	if counts.UnapprovedDrums == 0 && dp.DrumSize > 0 {
+		counts.UnapprovedDrums = dp.UnapprovedQuantity / dp.DrumSize
+	}
+
+	sampled := drumDetailsNumbers(dp.TestDrumNumbers, dp.ShortDrumNumbers, dp.PartialDrumNumbers)
+	counts.Drums = counts.AvailableDrums + counts.BufferDrums + counts.UnapprovedDrums + len(sampled)
+	return counts
+}
+
+// drumDetailsNumbers returns the distinct drum numbers of the drum details, sorted.
+func drumDetailsNumbers(details ...[]DrumDetails) []int {
+	var numbers []int
+	for _, d := range details {
+		for _, drum := range d {
+			numbers = append(numbers, drum.DrumNumber)
+		}
+	}
+	return distinctDrumNumbers(numbers)
+}
+
+// summarize builds the summary report of an inventory: a line per drum partition followed by the roll-up of its
+// batch, the roll-up of each LI after its batches, of each contract after its LIs and the grand total last.
+func summarize(records UploadInventoryInput) []summaryLine {
+	lines := make([]summaryLine, 0)
+	totals := make(map[string]stockCounts)
+
+	for _, contract := range records.Contracts {
+		contractTotals := make(map[string]stockCounts)
+		for _, li := range contract.LIs {
+			liName := li.LiCode + "-" + li.LiNumber
+			var liCounts stockCounts
+			for _, batch := range li.Batches {
+				var batchCounts stockCounts
+				for _, dp := range batch.DrumPartitions {
+					counts := drumPartitionCounts(dp)
+					batchCounts.add(counts)
+					lines = append(lines, summaryLine{Level: summaryLevelDrumSize, ContractNo: contract.ContractNo, LI: liName, BatchNo: batch.BatchNo, DrumSize: dp.DrumSize, Unit: li.Unit, stockCounts: counts})
+				}
+				liCounts.add(batchCounts)
+				lines = append(lines, summaryLine{Level: summaryLevelBatch, ContractNo: contract.ContractNo, LI: liName, BatchNo: batch.BatchNo, Unit: li.Unit, stockCounts: batchCounts})
+			}
+			lines = append(lines, summaryLine{Level: summaryLevelLI, ContractNo: contract.ContractNo, LI: liName, Unit: li.Unit, stockCounts: liCounts})
+
+			counts := contractTotals[li.Unit]
+			counts.add(liCounts)
+			contractTotals[li.Unit] = counts
+		}
+
+		for _, unit := range sortedUnits(contractTotals) {
+			lines = append(lines, summaryLine{Level: summaryLevelContract, ContractNo: contract.ContractNo, Unit: unit, stockCounts: contractTotals[unit]})
+			counts := totals[unit]
+			counts.add(contractTotals[unit])
+			totals[unit] = counts
+		}
+	}
+
+	for _, unit := range sortedUnits(totals) {
+		lines = append(lines, summaryLine{Level: summaryLevelTotal, Unit: unit, stockCounts: totals[unit]})
+	}
+	return lines
+}
+
+func sortedUnits(counts map[string]stockCounts) []string {
+	units := make([]string, 0, len(counts))
+	for unit := range counts {
+		units = append(units, unit)
+	}
+	sort.Strings(units)
+	return units
+}
+
+var summaryHeader = []string{
+	"Level", "Contract", "LI", "Batch", "Drum size", "Unit",
+	"Total qty", "Total drums", "Available qty", "Available drums", "Buffer qty", "Buffer drums",
+	"Test qty", "Test drums", "Short qty", "Short drums", "Partial qty", "Unapproved qty", "Unapproved drums",
+}
+
+// record is the line as the cells of summaryHeader, the drum size is left empty on roll-ups.
+func (l summaryLine) record() []string {
+	drumSize := ""
+	if l.Level == summaryLevelDrumSize {
+		drumSize = strconv.Itoa(l.DrumSize)
+	}
+	return []string{
+		l.Level, l.ContractNo, l.LI, l.BatchNo, drumSize, l.Unit,
+		strconv.Itoa(l.Quantity), strconv.Itoa(l.Drums),
+		strconv.Itoa(l.Available), strconv.Itoa(l.AvailableDrums),
+		strconv.Itoa(l.Buffer), strconv.Itoa(l.BufferDrums),
+		formatSummaryQuantity(l.Test), strconv.Itoa(l.TestDrums),
+		formatSummaryQuantity(l.Short), strconv.Itoa(l.ShortDrums),
+		formatSummaryQuantity(l.Partial),
+		strconv.Itoa(l.Unapproved), strconv.Itoa(l.UnapprovedDrums),
+	}
+}
+
+func formatSummaryQuantity(qty float64) string {
+	return strconv.FormatFloat(qty, 'f', -1, 64)
+}
+
+// writeSummary writes the summary report as an aligned text table, CSV or a Markdown table.
+func writeSummary(w io.Writer, lines []summaryLine, format string) error {
+	records := make([][]string, 0, len(lines))
+	rollUps := make([]bool, 0, len(lines))
+	for _, line := range lines {
+		records = append(records, line.record())
+		rollUps = append(rollUps, line.Level != summaryLevelDrumSize)
+	}
+	return writeTable(w, summaryHeader, records, format, 6, rollUps)
+}
+
+// writeTable writes a report as an aligned text table, CSV or a Markdown table. The first leftColumns columns name
+// the line and are aligned left in Markdown, the counts after them right; emphasized lines are bold in Markdown.
+func writeTable(w io.Writer, header []string, records [][]string, format string, leftColumns int, emphasized []bool) error {
+	switch format {
+	case summaryFormatText:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(tw, strings.Join(header, "\t")+"\t")
+		for _, record := range records {
+			fmt.Fprintln(tw, strings.Join(record, "\t")+"\t")
+		}
+		return tw.Flush()
+	case summaryFormatCSV:
+		csvWriter := csv.NewWriter(w)
+		csvWriter.Write(header)
+		for _, record := range records {
+			csvWriter.Write(record)
+		}
+		csvWriter.Flush()
+		return csvWriter.Error()
+	case summaryFormatMarkdown:
+		fmt.Fprintf(w, "| %s |\n", strings.Join(header, " | "))
+		separators := make([]string, len(header))
+		for i := range separators {
+			separators[i] = "---:"
+			if i < leftColumns {
+				separators[i] = "---"
+			}
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(separators, " | "))
+		for i, record := range records {
+			cells := append([]string{}, record...)
+			if i < len(emphasized) && emphasized[i] {
+				for j, cell := range cells {
+					if cell != "" {
+						cells[j] = "**" + cell + "**"
+					}
+				}
+			}
+			fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown report format %s, expected %s, %s or %s", format, summaryFormatText, summaryFormatCSV, summaryFormatMarkdown)
+}
+
+// readInventory reads an inventory from a saved JSON snapshot, or converts it from a vendor CSV file.
+func readInventory(path string) (UploadInventoryInput, []Error, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return UploadInventoryInput{}, nil, err
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		records, errs := parseNamedCSV(path, file, defaultParseOptions())
+		return records, errs, nil
+	}
+
+	var records UploadInventoryInput
+	if err := json.NewDecoder(file).Decode(&records); err != nil {
+		return UploadInventoryInput{}, nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return records, nil, nil
+}
+
+// writeReport writes the report of the inventory of a JSON snapshot or vendor file to the out file, or to stdout when
+// out is empty. The format is checked before the inventory is read. A vendor file with errors still gets its report,
+// and the error returned names the inventory file and its first error.
+func writeReport(in, out, format string, stdout io.Writer, write func(w io.Writer, records UploadInventoryInput) error) (err error) {
+	if err := writeTable(io.Discard, nil, nil, format, 0, nil); err != nil {
+		return err
+	}
+
+	records, errs, err := readInventory(in)
+	if err != nil {
+		return err
+	}
+
+	w := stdout
+	if out != "" {
+		file, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		w = file
+	}
+	if err := write(w, records); err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("inventory %s failed to convert with %d errors, the first on row %d: %s", in, len(errs), errs[0].RowNo, errs[0].Err)
+	}
+	return nil
+}
+
+// runSummary is the summary command: it writes the summary report of a converted inventory or of a vendor file.
+func runSummary(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("summary", flag.ContinueOnError)
+	in := flags.String("in", "output.json", "JSON inventory, or vendor CSV file to convert, to summarize")
+	out := flags.String("out", "", "file to write the report to, standard output by default")
+	format := flags.String("format", summaryFormatText, "format of the report: text, csv or markdown")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	return writeReport(*in, *out, *format, stdout, func(w io.Writer, records UploadInventoryInput) error {
+		return writeSummary(w, summarize(records), *format)
+	})
+}
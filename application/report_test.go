@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_summarize(t *testing.T) {
+	records := testInventory(testContract("9190369", "",
+		testLI("1", "", UnitMetre, testBatch("1/50", nil,
+			DrumPartition{
+				DrumSize: 250, Quantity: 1000,
+				AvailableQuantity: 250, AvailableDrumNumbers: []int{1},
+				BufferQuantity: 250, BufferDrumNumbers: []int{2},
+				TestQuantity: 2.5, TestDrumNumbers: []DrumDetails{{DrumNumber: 3, Quantity: 2.5}},
+				ShortQuantity: 247.5, ShortDrumNumbers: []DrumDetails{{DrumNumber: 3, Quantity: 247.5}},
+				UnapprovedQuantity: 250, UnapprovedDrumNumbers: []int{4},
+			},
+			// written before unapproved drums were listed
+			DrumPartition{DrumSize: 500, Quantity: 1000, UnapprovedQuantity: 1000},
+		)),
+		testLI("2", "", UnitKilometre, testBatch("2/50", nil,
+			DrumPartition{DrumSize: 1, Quantity: 2, AvailableQuantity: 2, AvailableDrumNumbers: []int{1, 2}},
+		)),
+	))
+	lines := summarize(records)
+
+	metres250 := stockCounts{
+		Quantity: 1000, Drums: 4,
+		Available: 250, AvailableDrums: 1,
+		Buffer: 250, BufferDrums: 1,
+		Test: 2.5, TestDrums: 1,
+		Short: 247.5, ShortDrums: 1,
+		Unapproved: 250, UnapprovedDrums: 1,
+	}
+	metres500 := stockCounts{Quantity: 1000, Drums: 2, Unapproved: 1000, UnapprovedDrums: 2}
+	metres := metres250
+	metres.add(metres500)
+	kilometres := stockCounts{Quantity: 2, Drums: 2, Available: 2, AvailableDrums: 2}
+
+	assert.Equal(t, []summaryLine{
+		{Level: summaryLevelDrumSize, ContractNo: "9190369", LI: "Li-1", BatchNo: "1/50", DrumSize: 250, Unit: UnitMetre, stockCounts: metres250},
+		{Level: summaryLevelDrumSize, ContractNo: "9190369", LI: "Li-1", BatchNo: "1/50", DrumSize: 500, Unit: UnitMetre, stockCounts: metres500},
+		{Level: summaryLevelBatch, ContractNo: "9190369", LI: "Li-1", BatchNo: "1/50", Unit: UnitMetre, stockCounts: metres},
+		{Level: summaryLevelLI, ContractNo: "9190369", LI: "Li-1", Unit: UnitMetre, stockCounts: metres},
+		{Level: summaryLevelDrumSize, ContractNo: "9190369", LI: "Li-2", BatchNo: "2/50", DrumSize: 1, Unit: UnitKilometre, stockCounts: kilometres},
+		{Level: summaryLevelBatch, ContractNo: "9190369", LI: "Li-2", BatchNo: "2/50", Unit: UnitKilometre, stockCounts: kilometres},
+		{Level: summaryLevelLI, ContractNo: "9190369", LI: "Li-2", Unit: UnitKilometre, stockCounts: kilometres},
+		{Level: summaryLevelContract, ContractNo: "9190369", Unit: UnitKilometre, stockCounts: kilometres},
+		{Level: summaryLevelContract, ContractNo: "9190369", Unit: UnitMetre, stockCounts: metres},
+		{Level: summaryLevelTotal, Unit: UnitKilometre, stockCounts: kilometres},
+		{Level: summaryLevelTotal, Unit: UnitMetre, stockCounts: metres},
+	}, lines)
+
+	assert.Equal(t, []summaryLine{}, summarize(UploadInventoryInput{}))
+}
+
+func Test_writeSummary(t *testing.T) {
+	metres250 := stockCounts{
+		Quantity: 1000, Drums: 4, Available: 250, AvailableDrums: 1, Buffer: 250, BufferDrums: 1,
+		Test: 2.5, TestDrums: 1, Short: 247.5, ShortDrums: 1, Unapproved: 250, UnapprovedDrums: 1,
+	}
+	metres500 := stockCounts{Quantity: 1000, Drums: 2, Unapproved: 1000, UnapprovedDrums: 2}
+	metres := metres250
+	metres.add(metres500)
+	lines := []summaryLine{
+		{Level: summaryLevelDrumSize, ContractNo: "9190369", LI: "Li-1", BatchNo: "1/50", DrumSize: 250, Unit: UnitMetre, stockCounts: metres250},
+		{Level: summaryLevelDrumSize, ContractNo: "9190369", LI: "Li-1", BatchNo: "1/50", DrumSize: 500, Unit: UnitMetre, stockCounts: metres500},
+		{Level: summaryLevelBatch, ContractNo: "9190369", LI: "Li-1", BatchNo: "1/50", Unit: UnitMetre, stockCounts: metres},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: summaryFormatCSV,
+			want: "Level,Contract,LI,Batch,Drum size,Unit,Total qty,Total drums,Available qty,Available drums,Buffer qty,Buffer drums,Test qty,Test drums,Short qty,Short drums,Partial qty,Unapproved qty,Unapproved drums\n" +
+				"drum size,9190369,Li-1,1/50,250,m,1000,4,250,1,250,1,2.5,1,247.5,1,0,250,1\n" +
+				"drum size,9190369,Li-1,1/50,500,m,1000,2,0,0,0,0,0,0,0,0,0,1000,2\n" +
+				"batch,9190369,Li-1,1/50,,m,2000,6,250,1,250,1,2.5,1,247.5,1,0,1250,3\n",
+		},
+		{
+			format: summaryFormatMarkdown,
+			want: "| Level | Contract | LI | Batch | Drum size | Unit | Total qty | Total drums | Available qty | Available drums | Buffer qty | Buffer drums | Test qty | Test drums | Short qty | Short drums | Partial qty | Unapproved qty | Unapproved drums |\n" +
+				"| --- | --- | --- | --- | --- | --- | ---: | ---: | ---: | ---: | ---: | ---: | ---: | ---: | ---: | ---: | ---: | ---: | ---: |\n" +
+				"| drum size | 9190369 | Li-1 | 1/50 | 250 | m | 1000 | 4 | 250 | 1 | 250 | 1 | 2.5 | 1 | 247.5 | 1 | 0 | 250 | 1 |\n" +
+				"| drum size | 9190369 | Li-1 | 1/50 | 500 | m | 1000 | 2 | 0 | 0 | 0 | 0 | 0 | 0 | 0 | 0 | 0 | 1000 | 2 |\n" +
+				"| **batch** | **9190369** | **Li-1** | **1/50** |  | **m** | **2000** | **6** | **250** | **1** | **250** | **1** | **2.5** | **1** | **247.5** | **1** | **0** | **1250** | **3** |\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.NoError(t, writeSummary(&buf, lines, tt.format))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+
+	t.Run(summaryFormatText, func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, writeSummary(&buf, lines, summaryFormatText))
+		rows := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		assert.Len(t, rows, 4)
+		for _, row := range rows {
+			assert.Equal(t, len(rows[0]), len(row), "columns are aligned")
+		}
+		assert.Equal(t, []string{"batch", "9190369", "Li-1", "1/50", "m", "2000", "6", "250", "1", "250", "1", "2.5", "1", "247.5", "1", "0", "1250", "3"}, strings.Fields(rows[3]))
+	})
+
+	assert.EqualError(t, writeSummary(&bytes.Buffer{}, lines, "xlsx"), "unknown report format xlsx, expected text, csv or markdown")
+}
+
+// Test_runSummary_Snapshot checks the summary of a fresh conversion is the summary of its saved snapshot.
+func Test_runSummary_Snapshot(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "vendor.csv")
+	jsonPath := filepath.Join(dir, "vendor.json")
+	assert.NoError(t, os.WriteFile(csvPath, []byte(generateCSV(3)), 0644))
+
+	records, errs := parseNamedCSV(csvPath, strings.NewReader(generateCSV(3)), defaultParseOptions())
+	assert.Empty(t, errs)
+	jsonData, err := marshalRecords(records, outputOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(jsonPath, jsonData, 0644))
+
+	for _, format := range []string{summaryFormatText, summaryFormatCSV, summaryFormatMarkdown} {
+		var fresh, snapshot bytes.Buffer
+		assert.NoError(t, runSummary([]string{"-in", csvPath, "-format", format}, &fresh))
+		assert.NoError(t, runSummary([]string{"-in", jsonPath, "-format", format}, &snapshot))
+		assert.Equal(t, fresh.String(), snapshot.String())
+		assert.Contains(t, fresh.String(), "total")
+	}
+
+	outPath := filepath.Join(dir, "summary.csv")
+	assert.NoError(t, runSummary([]string{"-in", jsonPath, "-format", summaryFormatCSV, "-out", outPath}, &bytes.Buffer{}))
+	written, err := os.ReadFile(outPath)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(written), "Level,Contract,LI,Batch"))
+
+	assert.Error(t, runSummary([]string{"-in", jsonPath, "-format", "xlsx", "-out", filepath.Join(dir, "never.txt")}, &bytes.Buffer{}))
+	assert.NoFileExists(t, filepath.Join(dir, "never.txt"))
+}
+
+func Test_writeReport(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "vendor.csv")
+	outPath := filepath.Join(dir, "report.txt")
+	assert.NoError(t, os.WriteFile(csvPath, []byte(strings.Replace(generateCSV(2), ",1/50,", ",1-50,", 1)), 0644))
+
+	var contracts int
+	err := writeReport(csvPath, outPath, summaryFormatText, &bytes.Buffer{}, func(w io.Writer, records UploadInventoryInput) error {
+		contracts = len(records.Contracts)
+		_, err := io.WriteString(w, "report\n")
+		return err
+	})
+	assert.EqualError(t, err, "inventory "+csvPath+" failed to convert with 1 errors, the first on row 1: invalid batch no. format")
+	assert.Equal(t, 1, contracts)
+	written, err := os.ReadFile(outPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "report\n", string(written))
+
+	assert.Error(t, writeReport(filepath.Join(dir, "missing.csv"), "", summaryFormatText, &bytes.Buffer{}, nil))
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+)
+
+// defaultMaxHeldRows is the number of rows the inventory builder holds in memory, more rows are sorted into runs on
+// disk and merged back when they are placed.
+const defaultMaxHeldRows = 10000
+
+// comparePendingRows orders rows the way they are placed: by drum partition, by batch test report date within a drum
+// partition, then by their content and last by their position in the input. Rows without a report date come after
+// the rows with one, so they are checked against the whole approval history of their drum partition.
+func comparePendingRows(a, b pendingRow) int {
+	if c := comparePartitionKeys(partitionKeyOf(a.row), partitionKeyOf(b.row)); c != 0 {
+		return c
+	}
+	if a.approvalDate.IsZero() != b.approvalDate.IsZero() {
+		if b.approvalDate.IsZero() {
+			return -1
+		}
+		return 1
+	}
+	if !a.approvalDate.Equal(b.approvalDate) {
+		if a.approvalDate.Before(b.approvalDate) {
+			return -1
+		}
+		return 1
+	}
+	if c := compareRowContent(a.row, b.row); c != 0 {
+		return c
+	}
+	return a.rowIndex - b.rowIndex
+}
+
+func sortPendingRows(rows []pendingRow) {
+	sort.Slice(rows, func(i, j int) bool {
+		return comparePendingRows(rows[i], rows[j]) < 0
+	})
+}
+
+// heldRow is a pending row as written to a run on disk.
+type heldRow struct {
+	Row          CSVRow
+	RowIndex     int
+	ApprovalDate Date
+}
+
+// writeRun writes sorted rows to a temporary file, one JSON document per row, and returns it ready to be read back.
+func writeRun(rows []pendingRow) (*os.File, error) {
+	file, err := os.CreateTemp("", "vmi-stock-upload-rows-*.jsonl")
+	if err != nil {
+		return nil, err
+	}
+
+	w := bufio.NewWriter(file)
+	encoder := json.NewEncoder(w)
+	for _, pending := range rows {
+		if err = encoder.Encode(heldRow{Row: pending.row, RowIndex: pending.rowIndex, ApprovalDate: pending.approvalDate}); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		removeRun(file)
+		return nil, err
+	}
+	return file, nil
+}
+
+func removeRun(file *os.File) {
+	file.Close()
+	os.Remove(file.Name())
+}
+
+// rowRun is a run of sorted rows, read one row at a time.
+type rowRun interface {
+	next() (pendingRow, bool, error)
+}
+
+// heldRun is a run of sorted rows held in memory.
+type heldRun struct {
+	rows []pendingRow
+}
+
+func (r *heldRun) next() (pendingRow, bool, error) {
+	if len(r.rows) == 0 {
+		return pendingRow{}, false, nil
+	}
+	pending := r.rows[0]
+	r.rows = r.rows[1:]
+	return pending, true, nil
+}
+
+// fileRun is a run of sorted rows written by writeRun.
+type fileRun struct {
+	decoder *json.Decoder
+}
+
+func newFileRun(file *os.File) *fileRun {
+	return &fileRun{decoder: json.NewDecoder(bufio.NewReader(file))}
+}
+
+func (r *fileRun) next() (pendingRow, bool, error) {
+	var held heldRow
+	if err := r.decoder.Decode(&held); err == io.EOF {
+		return pendingRow{}, false, nil
+	} else if err != nil {
+		return pendingRow{}, false, err
+	}
+	return pendingRow{row: held.Row, rowIndex: held.RowIndex, approvalDate: held.ApprovalDate}, true, nil
+}
+
+// mergeRuns passes the rows of sorted runs to place in the order of comparePendingRows, holding one row per run.
+func mergeRuns(runs []rowRun, place func(pending pendingRow)) error {
+	h := make(runHeap, 0, len(runs))
+	for i, run := range runs {
+		pending, ok, err := run.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			h = append(h, runHead{pending: pending, run: i})
+		}
+	}
+	heap.Init(&h)
+
+	for h.Len() > 0 {
+		head := h[0]
+		place(head.pending)
+
+		pending, ok, err := runs[head.run].next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			h[0].pending = pending
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+	return nil
+}
+
+// runHead is the next row of a run.
+type runHead struct {
+	pending pendingRow
+	run     int
+}
+
+type runHeap []runHead
+
+func (h runHeap) Len() int { return len(h) }
+func (h runHeap) Less(i, j int) bool {
+	return comparePendingRows(h[i].pending, h[j].pending) < 0
+}
+func (h runHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x any)   { *h = append(*h, x.(runHead)) }
+func (h *runHeap) Pop() any {
+	old := *h
+	head := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return head
+}
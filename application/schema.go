@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+//go:generate go run . schema -out schemas/inventory-v2.schema.json
+
+// inventorySchemaVersion is written to every output file, it names the schema the file is valid against.
+const (
+	inventorySchemaVersion = "2"
+	schemaInventoryV2      = "inventory-v2"
+)
+
+// datePattern matches the dates of the output in either layout, the zero date is empty.
+const datePattern = `^(\d{2}-\d{2}-\d{4}|\d{4}-\d{2}-\d{2})?$`
+
+// schemaTypeRules add to the schema generated for a model type what its Go type cannot say.
+var schemaTypeRules = map[string]map[string]any{
+	"Batch": {
+		"description": "total_quantity is the sum of the quantity of the drum partitions",
+	},
+	"DrumPartition": {
+		"description": "quantity = unapproved_quantity + available_quantity + buffer_quantity + test_quantity + short_quantity + partial_quantity; " +
+			"available_quantity and buffer_quantity are drum_size times the number of available and buffer drums",
+	},
+	"LI": {
+		"description": "delivered_quantity is the quantity of the batches that is not unapproved",
+	},
+}
+
+// schemaFieldRules add to the schema generated for a field, by model type and JSON name, what its Go type cannot say.
+func schemaFieldRules() map[string]map[string]any {
+	quantity := map[string]any{"minimum": 0}
+	drumNumbers := map[string]any{"uniqueItems": true, "items": map[string]any{"minimum": 0}}
+	drumSize := map[string]any{"minimum": 1}
+
+	units := knownUnits()
+
+	return map[string]map[string]any{
+		"UploadInventoryInput.schema_version":   {"const": inventorySchemaVersion},
+		"LI.unit":                               {"enum": units},
+		"LI.ordered_quantity":                   quantity,
+		"LI.delivered_quantity":                 quantity,
+		"LI.status":                             {"enum": liStatuses},
+		"Batch.batch_no":                        {"pattern": batchNoPattern.String()},
+		"Batch.total_quantity":                  quantity,
+		"Batch.remark_flags":                    {"items": map[string]any{"enum": []string{remarkFlagBuffer, remarkFlagPartial}}},
+		"Batch.status":                          {"enum": batchStatuses()},
+		"DrumPartition.drum_size":               drumSize,
+		"DrumPartition.unit":                    {"enum": units},
+		"DrumPartition.quantity":                quantity,
+		"DrumPartition.unapproved_quantity":     quantity,
+		"DrumPartition.unapproved_drum_numbers": drumNumbers,
+		"DrumPartition.available_quantity":      quantity,
+		"DrumPartition.available_drum_numbers":  drumNumbers,
+		"DrumPartition.buffer_quantity":         quantity,
+		"DrumPartition.buffer_drum_numbers":     drumNumbers,
+		"DrumPartition.test_quantity":           quantity,
+		"DrumPartition.short_quantity":          quantity,
+		"DrumPartition.partial_quantity":        quantity,
+		"DrumPartition.remark_flags":            {"items": map[string]any{"enum": []string{remarkFlagBuffer, remarkFlagPartial}}},
+		"DrumDetails.number":                    {"minimum": 0},
+		"DrumDetails.quantity":                  quantity,
+		"BatchTestApproval.status":              {"enum": approvalStatuses},
+		"BatchTestDrumNumbers.drum_size":        drumSize,
+		"ApprovalDrumNumber.drum_size":          drumSize,
+		"ApprovalDrumNumber.drum_numbers":       drumNumbers,
+	}
+}
+
+// generateInventorySchema generates the JSON Schema of the output from the model types.
+func generateInventorySchema() map[string]any {
+	generator := schemaGenerator{
+		defs:  map[string]any{"Date": map[string]any{"type": "string", "pattern": datePattern}},
+		rules: schemaFieldRules(),
+	}
+	root := generator.objectSchema(reflect.TypeOf(UploadInventoryInput{}))
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["$id"] = "urn:vmi-stock-upload:schema:inventory:" + inventorySchemaVersion
+	root["title"] = "Vendor stock by contract, LI, batch and drum size"
+	root["$defs"] = generator.defs
+	return root
+}
+
+// inventorySchemaJSON is the published JSON Schema of the output.
+func inventorySchemaJSON() ([]byte, error) {
+	data, err := json.MarshalIndent(generateInventorySchema(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+type schemaGenerator struct {
+	defs  map[string]any
+	rules map[string]map[string]any
+}
+
+// schemaOf returns the schema of a Go type, model structs are defined once in $defs and referenced.
+func (g schemaGenerator) schemaOf(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Int:
+		return map[string]any{"type": "integer"}
+	case reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Slice:
+		// nil slices are written as null
+		return map[string]any{"type": []any{"array", "null"}, "items": g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if _, ok := g.defs[t.Name()]; !ok {
+			g.defs[t.Name()] = nil
+			g.defs[t.Name()] = g.objectSchema(t)
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	}
+	panic(fmt.Sprintf("no schema for %s", t))
+}
+
+func (g schemaGenerator) objectSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	required := make([]string, 0)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		property := g.schemaOf(field.Type)
+		mergeSchema(property, g.rules[t.Name()+"."+name])
+		properties[name] = property
+		if options != "omitempty" {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+	mergeSchema(schema, schemaTypeRules[t.Name()])
+	return schema
+}
+
+// mergeSchema adds the keywords of rules to a schema, merging the schemas both have for a keyword.
+func mergeSchema(schema map[string]any, rules map[string]any) {
+	for keyword, rule := range rules {
+		existing, ok := schema[keyword].(map[string]any)
+		if nested, isMap := rule.(map[string]any); ok && isMap {
+			mergeSchema(existing, nested)
+			continue
+		}
+		schema[keyword] = rule
+	}
+}
+
+// runSchema is the schema command: it writes the JSON Schema of the output.
+func runSchema(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("schema", flag.ContinueOnError)
+	out := flags.String("out", "", "file to write the schema to, standard output by default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	data, err := inventorySchemaJSON()
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*out, data, 0644)
+}
+
+// runValidateJSON is the validate-json command: it checks JSON files against the schema they are written in and
+// prints every violation.
+func runValidateJSON(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("validate-json", flag.ContinueOnError)
+	in := flags.String("in", "output.json", "JSON file to validate, further files may follow the flags")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{*in}
+	}
+
+	var invalid int
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		name, problems, err := validateJSON(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		for _, problem := range problems {
+			fmt.Fprintf(stdout, "%s: %s\n", file, problem)
+		}
+		if len(problems) > 0 {
+			invalid++
+			continue
+		}
+		fmt.Fprintf(stdout, "%s: valid %s\n", file, name)
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d files are not valid", invalid, len(files))
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_inventorySchema_Published fails when the model changed without running go generate.
+func Test_inventorySchema_Published(t *testing.T) {
+	generated, err := inventorySchemaJSON()
+	assert.NoError(t, err)
+
+	published, err := os.ReadFile("schemas/inventory-v2.schema.json")
+	assert.NoError(t, err)
+	assert.Equal(t, string(published), string(generated))
+}
+
+func Test_generateInventorySchema(t *testing.T) {
+	schema := generateInventorySchema()
+	defs := schema["$defs"].(map[string]any)
+	property := func(def, name string) map[string]any {
+		return defs[def].(map[string]any)["properties"].(map[string]any)[name].(map[string]any)
+	}
+
+	assert.Equal(t, "urn:vmi-stock-upload:schema:inventory:2", schema["$id"])
+	assert.Equal(t, []string{"schema_version", "contracts"}, schema["required"])
+	assert.Equal(t, inventorySchemaVersion, schema["properties"].(map[string]any)["schema_version"].(map[string]any)["const"])
+
+	assert.ElementsMatch(t, []string{"AVAILABLE", "PARTIAL_BUFFER", "BUFFER", "DOCS_PENDING_UPLOAD", "REJECTED", "RETEST_PENDING"}, property("Batch", "status")["enum"])
+	assert.Equal(t, liStatuses, property("LI", "status")["enum"])
+	assert.Equal(t, approvalStatuses, property("BatchTestApproval", "status")["enum"])
+	assert.Equal(t, map[string]any{"$ref": "#/$defs/Date"}, property("Batch", "submission_date"))
+	assert.Equal(t, 1, property("DrumPartition", "drum_size")["minimum"])
+	assert.Equal(t, 0, property("DrumPartition", "available_quantity")["minimum"])
+	assert.Equal(t, map[string]any{"type": "integer", "minimum": 0}, property("DrumPartition", "buffer_drum_numbers")["items"])
+	assert.Equal(t, true, property("DrumPartition", "buffer_drum_numbers")["uniqueItems"])
+	assert.Contains(t, defs["DrumPartition"].(map[string]any)["description"], "quantity = unapproved_quantity + available_quantity")
+}
+
+func Test_validateJSON_Version2(t *testing.T) {
+	records, errs := parseNamedCSV("a.csv", strings.NewReader(generateCSV(3)), defaultParseOptions())
+	assert.Empty(t, errs)
+	output, err := marshalRecords(records, outputOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, string(output), `"schema_version": "2"`)
+
+	tests := []struct {
+		name    string
+		replace [2]string
+		want    string
+		wantErr string
+	}{
+		{name: "batch status", replace: [2]string{`"status": "PARTIAL_BUFFER"`, `"status": "SOLD"`}, want: `"SOLD" is not one of`},
+		{name: "negative quantity", replace: [2]string{`"unapproved_quantity": 0`, `"unapproved_quantity": -1`}, want: `-1 is less than 0`},
+		{name: "date", replace: [2]string{`"submission_date": "`, `"submission_date": "x`}, want: `does not match`},
+		{name: "unknown version", replace: [2]string{`"schema_version": "2"`, `"schema_version": "3"`}, wantErr: `unknown schema version "3"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := strings.Replace(string(output), tt.replace[0], tt.replace[1], 1)
+			assert.NotEqual(t, string(output), document)
+
+			name, problems, err := validateJSON([]byte(document))
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, schemaInventoryV2, name)
+			assert.NotEmpty(t, problems)
+			assert.Contains(t, strings.Join(problems, "\n"), tt.want)
+		})
+	}
+}
+
+func Test_runValidateJSON(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.json")
+	invalid := filepath.Join(dir, "invalid.json")
+	assert.NoError(t, os.WriteFile(valid, []byte(`{"schema_version": "2", "contracts": []}`), 0644))
+	assert.NoError(t, os.WriteFile(invalid, []byte(`{"schema_version": "2"}`), 0644))
+
+	var stdout bytes.Buffer
+	assert.NoError(t, runValidateJSON([]string{"-in", valid}, &stdout))
+	assert.Equal(t, valid+": valid inventory-v2\n", stdout.String())
+
+	stdout.Reset()
+	assert.EqualError(t, runValidateJSON([]string{valid, invalid}, &stdout), "1 of 2 files are not valid")
+	assert.Equal(t, valid+": valid inventory-v2\n"+invalid+": $: contracts is required\n", stdout.String())
+}
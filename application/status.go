@@ -0,0 +1,122 @@
+package main
+
+// batchFacts are the quantities of a batch, summed over its drum partitions, and its batch test outcome that the
+// batch status is decided on.
+type batchFacts struct {
+	// Tested is set once a batch test report was uploaded for the batch
+	Tested bool
+	// Outcome is the status of the latest batch test approval that has not been superseded
+	Outcome    string
+	Available  float64 // available full drums and the remainder of partially sampled drums
+	Buffer     float64
+	Sampled    float64 // test and short quantity
+	Unapproved float64
+}
+
+// Approved is the quantity of the batch that some batch test approved.
+func (f batchFacts) Approved() float64 {
+	return f.Available + f.Buffer + f.Sampled
+}
+
+// batchStatusRule gives a batch the status when the condition holds for its facts.
+type batchStatusRule struct {
+	Status string
+	Reason string
+	When   func(f batchFacts) bool
+}
+
+// batchStatusRules decide the status of a batch, the first rule whose condition holds wins. The status describes
+// the approved stock of the batch; the unapproved remainder only counts when nothing is approved.
+var batchStatusRules = []batchStatusRule{
+	{
+		Status: "DOCS_PENDING_UPLOAD",
+		Reason: "no batch test report was uploaded",
+		When:   func(f batchFacts) bool { return !f.Tested },
+	},
+	{
+		Status: "REJECTED",
+		Reason: "nothing is approved and the latest batch test rejected the batch",
+		When:   func(f batchFacts) bool { return f.Approved() == 0 && f.Outcome == "REJECTED" },
+	},
+	{
+		Status: "RETEST_PENDING",
+		Reason: "nothing is approved and the latest batch test asked for a retest",
+		When:   func(f batchFacts) bool { return f.Approved() == 0 && f.Outcome == "RETEST" },
+	},
+	{
+		Status: "DOCS_PENDING_UPLOAD",
+		Reason: "nothing is approved",
+		When:   func(f batchFacts) bool { return f.Approved() == 0 },
+	},
+	{
+		Status: "PARTIAL_BUFFER",
+		Reason: "part of the approved stock is available and part is held as buffer",
+		When:   func(f batchFacts) bool { return f.Available > 0 && f.Buffer > 0 },
+	},
+	{
+		Status: "AVAILABLE",
+		Reason: "the approved stock is available",
+		When:   func(f batchFacts) bool { return f.Available > 0 },
+	},
+	{
+		Status: "BUFFER",
+		Reason: "the approved stock is held as buffer",
+		When:   func(f batchFacts) bool { return f.Buffer > 0 },
+	},
+	{
+		Status: "BUFFER",
+		Reason: "the whole batch was used up for samples",
+		When:   func(f batchFacts) bool { return f.Unapproved == 0 },
+	},
+	{
+		Status: "DOCS_PENDING_UPLOAD",
+		Reason: "only the samples are approved, the rest of the batch awaits its documents",
+		When:   func(f batchFacts) bool { return true },
+	},
+}
+
+// batchStatuses are the statuses batchStatusRules give, in the order of the rules.
+func batchStatuses() []string {
+	var statuses []string
+	seen := make(map[string]bool)
+	for _, rule := range batchStatusRules {
+		if !seen[rule.Status] {
+			seen[rule.Status] = true
+			statuses = append(statuses, rule.Status)
+		}
+	}
+	return statuses
+}
+
+// factsOf sums the quantities of the drum partitions of a batch.
+func factsOf(batch Batch) batchFacts {
+	facts := batchFacts{
+		Tested: len(batch.BatchTestApprovals) > 0,
+	}
+	if bta, ok := latestApproval(batch); ok {
+		facts.Outcome = bta.Status
+	}
+	for _, dp := range batch.DrumPartitions {
+		facts.Available += float64(dp.AvailableQuantity) + dp.PartialQuantity
+		facts.Buffer += float64(dp.BufferQuantity)
+		facts.Sampled += dp.TestQuantity + dp.ShortQuantity
+		facts.Unapproved += float64(dp.UnapprovedQuantity)
+	}
+	return facts
+}
+
+// batchStatusRuleFor returns the first rule of batchStatusRules that holds for the facts.
+func batchStatusRuleFor(facts batchFacts) batchStatusRule {
+	for _, rule := range batchStatusRules {
+		if rule.When(facts) {
+			return rule
+		}
+	}
+	// the last rule always holds
+	return batchStatusRules[len(batchStatusRules)-1]
+}
+
+// determineBatchStatus is the status of a batch by batchStatusRules.
+func determineBatchStatus(batch Batch) string {
+	return batchStatusRuleFor(factsOf(batch)).Status
+}
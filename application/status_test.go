@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetermineBatchStatus_Rules(t *testing.T) {
+	approved := []BatchTestApproval{{ApprovalDate: testDate("01-03-2024"), Status: "APPROVED"}}
+	rejected := []BatchTestApproval{{ApprovalDate: testDate("01-03-2024"), Status: "REJECTED"}}
+	retest := []BatchTestApproval{{ApprovalDate: testDate("01-03-2024"), Status: "RETEST"}}
+	approvedThenRejected := []BatchTestApproval{
+		{ApprovalDate: testDate("01-04-2024"), Status: "REJECTED"},
+		{ApprovalDate: testDate("01-03-2024"), Status: "APPROVED"},
+	}
+	rejectedThenApproved := []BatchTestApproval{
+		{ApprovalDate: testDate("01-03-2024"), Status: "REJECTED"},
+		{ApprovalDate: testDate("01-04-2024"), Status: "APPROVED"},
+	}
+	supersededRetest := []BatchTestApproval{
+		{ApprovalDate: testDate("01-03-2024"), Status: "REJECTED"},
+		{ApprovalDate: testDate("01-04-2024"), Status: "RETEST", Superseded: true},
+	}
+
+	tests := []struct {
+		name      string
+		approvals []BatchTestApproval
+		dps       []DrumPartition
+		want      string
+	}{
+		{name: "no report", dps: []DrumPartition{{Quantity: 500, AvailableQuantity: 500}}, want: "DOCS_PENDING_UPLOAD"},
+		{name: "no report, empty", want: "DOCS_PENDING_UPLOAD"},
+		{name: "rejected", approvals: rejected, dps: []DrumPartition{{Quantity: 500, UnapprovedQuantity: 500}}, want: "REJECTED"},
+		{name: "retest", approvals: retest, dps: []DrumPartition{{Quantity: 500, UnapprovedQuantity: 500}}, want: "RETEST_PENDING"},
+		{name: "approved, nothing listed", approvals: approved, dps: []DrumPartition{{Quantity: 500, UnapprovedQuantity: 500}}, want: "DOCS_PENDING_UPLOAD"},
+		{name: "available", approvals: approved, dps: []DrumPartition{{Quantity: 500, AvailableQuantity: 500}}, want: "AVAILABLE"},
+		{name: "available and unapproved", approvals: approved, dps: []DrumPartition{{Quantity: 500, AvailableQuantity: 250, UnapprovedQuantity: 250}}, want: "AVAILABLE"},
+		{name: "partial sample drum", approvals: approved, dps: []DrumPartition{{Quantity: 250, TestQuantity: 5, ShortQuantity: 100, PartialQuantity: 145}}, want: "AVAILABLE"},
+		{name: "available and buffer", approvals: approved, dps: []DrumPartition{{Quantity: 500, AvailableQuantity: 250, BufferQuantity: 250}}, want: "PARTIAL_BUFFER"},
+		{name: "buffer", approvals: approved, dps: []DrumPartition{{Quantity: 500, BufferQuantity: 500}}, want: "BUFFER"},
+		{name: "buffer and unapproved", approvals: approved, dps: []DrumPartition{{Quantity: 500, BufferQuantity: 250, UnapprovedQuantity: 250}}, want: "BUFFER"},
+		{name: "buffer and samples", approvals: approved, dps: []DrumPartition{{Quantity: 500, BufferQuantity: 250, TestQuantity: 5, ShortQuantity: 245}}, want: "BUFFER"},
+		{name: "all sampled", approvals: approved, dps: []DrumPartition{{Quantity: 250, TestQuantity: 5, ShortQuantity: 245}}, want: "BUFFER"},
+		{name: "samples and unapproved", approvals: approved, dps: []DrumPartition{{Quantity: 500, TestQuantity: 5, ShortQuantity: 245, UnapprovedQuantity: 250}}, want: "DOCS_PENDING_UPLOAD"},
+		{
+			name:      "available in one drum size, buffer in another",
+			approvals: approved,
+			dps:       []DrumPartition{{DrumSize: 250, Quantity: 250, AvailableQuantity: 250}, {DrumSize: 500, Quantity: 500, BufferQuantity: 500}},
+			want:      "PARTIAL_BUFFER",
+		},
+		{
+			name:      "available in one drum size, unapproved in another",
+			approvals: approved,
+			dps:       []DrumPartition{{DrumSize: 250, Quantity: 250, AvailableQuantity: 250}, {DrumSize: 500, Quantity: 500, UnapprovedQuantity: 500}},
+			want:      "AVAILABLE",
+		},
+		{
+			name:      "buffer in one drum size, unapproved in another",
+			approvals: approved,
+			dps:       []DrumPartition{{DrumSize: 250, Quantity: 250, BufferQuantity: 250}, {DrumSize: 500, Quantity: 500, UnapprovedQuantity: 500}},
+			want:      "BUFFER",
+		},
+		{name: "approved then rejected", approvals: approvedThenRejected, dps: []DrumPartition{{Quantity: 500, UnapprovedQuantity: 500}}, want: "REJECTED"},
+		{name: "rejected then approved", approvals: rejectedThenApproved, dps: []DrumPartition{{Quantity: 500, AvailableQuantity: 500}}, want: "AVAILABLE"},
+		{name: "rejected then partly approved", approvals: rejectedThenApproved, dps: []DrumPartition{{Quantity: 500, AvailableQuantity: 250, UnapprovedQuantity: 250}}, want: "AVAILABLE"},
+		{name: "superseded retest", approvals: supersededRetest, dps: []DrumPartition{{Quantity: 500, UnapprovedQuantity: 500}}, want: "REJECTED"},
+		{name: "rejected but stock left from an earlier approval", approvals: approvedThenRejected, dps: []DrumPartition{{Quantity: 500, BufferQuantity: 250, UnapprovedQuantity: 250}}, want: "BUFFER"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, determineBatchStatus(testBatch("1/50", tt.approvals, tt.dps...)))
+		})
+	}
+}
+
+// TestDetermineBatchStatus_Matrix runs every combination of batch test outcome and quantities through the rules,
+// split over one and over two drum sizes.
+func TestDetermineBatchStatus_Matrix(t *testing.T) {
+	outcomes := []string{"", "APPROVED", "REJECTED", "RETEST"}
+	quantities := []int{0, 500}
+
+	reached := make(map[int]bool)
+	for _, outcome := range outcomes {
+		var approvals []BatchTestApproval
+		if outcome != "" {
+			approvals = []BatchTestApproval{{ApprovalDate: testDate("01-03-2024"), Status: outcome}}
+		}
+		for _, available := range quantities {
+			for _, buffer := range quantities {
+				for _, sampled := range quantities {
+					for _, unapproved := range quantities {
+						name := fmt.Sprintf("outcome %q available %d buffer %d sampled %d unapproved %d", outcome, available, buffer, sampled, unapproved)
+						t.Run(name, func(t *testing.T) {
+							whole := testBatch("1/50", approvals, DrumPartition{
+								DrumSize: 500, Quantity: available + buffer + sampled + unapproved,
+								AvailableQuantity: available, BufferQuantity: buffer, TestQuantity: float64(sampled), UnapprovedQuantity: unapproved,
+							})
+							split := testBatch("1/50", approvals,
+								DrumPartition{DrumSize: 250, Quantity: (available + buffer + sampled + unapproved) / 2,
+									AvailableQuantity: available / 2, BufferQuantity: buffer / 2, TestQuantity: float64(sampled) / 2, UnapprovedQuantity: unapproved / 2},
+								DrumPartition{DrumSize: 500, Quantity: (available + buffer + sampled + unapproved) / 2,
+									AvailableQuantity: available / 2, BufferQuantity: buffer / 2, ShortQuantity: float64(sampled) / 2, UnapprovedQuantity: unapproved / 2},
+							)
+
+							facts := factsOf(whole)
+							assert.Equal(t, facts, factsOf(split))
+
+							status := determineBatchStatus(whole)
+							assert.Equal(t, status, determineBatchStatus(split))
+
+							for i, rule := range batchStatusRules {
+								if rule.When(facts) {
+									assert.Equal(t, rule.Status, status, rule.Reason)
+									reached[i] = true
+									break
+								}
+							}
+						})
+					}
+				}
+			}
+		}
+	}
+
+	for i, rule := range batchStatusRules {
+		assert.True(t, reached[i], "rule %d (%s) is never reached", i, rule.Reason)
+	}
+}
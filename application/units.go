@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Units of measure accepted in the optional "Unit" column. Drum sizes,
+// quantities and sample lengths on a row are all expressed in the row's unit.
+const (
+	UnitMetre     = "m"
+	UnitKilometre = "km"
+	UnitKilogram  = "kg"
+	UnitTonne     = "t"
+	UnitPiece     = "pc"
+)
+
+// defaultUnit is used when a row does not specify a unit, which is the case
+// for every file produced before the "Unit" column was introduced.
+const defaultUnit = UnitMetre
+
+type unitDefinition struct {
+	Dimension string
+	ToBase    float64 // factor to convert one of this unit to the dimension's base unit
+}
+
+// unitDefinitions are the units parseUnit accepts. Quantities convert between units of one dimension only.
+var unitDefinitions = map[string]unitDefinition{
+	UnitMetre:     {Dimension: "length", ToBase: 1},
+	UnitKilometre: {Dimension: "length", ToBase: 1000},
+	UnitKilogram:  {Dimension: "mass", ToBase: 1},
+	UnitTonne:     {Dimension: "mass", ToBase: 1000},
+	UnitPiece:     {Dimension: "count", ToBase: 1},
+}
+
+// knownUnits are the units parseUnit accepts, sorted.
+func knownUnits() []string {
+	units := make([]string, 0, len(unitDefinitions))
+	for unit := range unitDefinitions {
+		units = append(units, unit)
+	}
+	sort.Strings(units)
+	return units
+}
+
+var unitAliases = map[string]string{
+	"m":          UnitMetre,
+	"metre":      UnitMetre,
+	"metres":     UnitMetre,
+	"meter":      UnitMetre,
+	"meters":     UnitMetre,
+	"km":         UnitKilometre,
+	"kilometre":  UnitKilometre,
+	"kilometres": UnitKilometre,
+	"kilometer":  UnitKilometre,
+	"kilometers": UnitKilometre,
+	"kg":         UnitKilogram,
+	"kilogram":   UnitKilogram,
+	"kilograms":  UnitKilogram,
+	"t":          UnitTonne,
+	"tonne":      UnitTonne,
+	"tonnes":     UnitTonne,
+	"pc":         UnitPiece,
+	"pcs":        UnitPiece,
+	"piece":      UnitPiece,
+	"pieces":     UnitPiece,
+}
+
+// parseUnit normalises a unit as written by the vendor. An empty unit means metres.
+func parseUnit(raw string) (string, error) {
+	str := strings.ToLower(strings.TrimSpace(raw))
+	if str == "" {
+		return defaultUnit, nil
+	}
+	unit, ok := unitAliases[str]
+	if !ok {
+		return "", fmt.Errorf("unknown unit: %s", raw)
+	}
+	return unit, nil
+}
+
+// convertQuantity converts qty from one unit to another of the same dimension.
+func convertQuantity(qty float64, from, to string) (float64, error) {
+	fromDef, ok := unitDefinitions[from]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit: %s", from)
+	}
+	toDef, ok := unitDefinitions[to]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit: %s", to)
+	}
+	if fromDef.Dimension != toDef.Dimension {
+		return 0, fmt.Errorf("cannot convert %s to %s", from, to)
+	}
+	return qty * fromDef.ToBase / toDef.ToBase, nil
+}
+
+// validateDrumSizeForUnit checks the drum size against the standard drum
+// lengths for length units, and only requires a positive size otherwise.
+func validateDrumSizeForUnit(drumSize int, unit string) bool {
+	unit, err := parseUnit(unit)
+	if err != nil {
+		return false
+	}
+	if unitDefinitions[unit].Dimension != unitDefinitions[UnitMetre].Dimension {
+		return drumSize > 0
+	}
+	metres, err := convertQuantity(float64(drumSize), unit, UnitMetre)
+	if err != nil {
+		return false
+	}
+	return metres == float64(int(metres)) && validateDrumSize(int(metres))
+}
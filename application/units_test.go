@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseUnit(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty defaults to metres", raw: "", want: UnitMetre},
+		{name: "metres", raw: "m", want: UnitMetre},
+		{name: "spelled out and mixed case", raw: " Metres ", want: UnitMetre},
+		{name: "kilometres", raw: "KM", want: UnitKilometre},
+		{name: "kilograms", raw: "kg", want: UnitKilogram},
+		{name: "tonnes", raw: "Tonnes", want: UnitTonne},
+		{name: "pieces", raw: "pcs", want: UnitPiece},
+		{name: "unknown unit", raw: "ft", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseUnit(tt.raw)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_convertQuantity(t *testing.T) {
+	tests := []struct {
+		name    string
+		qty     float64
+		from    string
+		to      string
+		want    float64
+		wantErr error
+	}{
+		{name: "same unit", qty: 250, from: UnitMetre, to: UnitMetre, want: 250},
+		{name: "kilometres to metres", qty: 1.5, from: UnitKilometre, to: UnitMetre, want: 1500},
+		{name: "metres to kilometres", qty: 250, from: UnitMetre, to: UnitKilometre, want: 0.25},
+		{name: "tonnes to kilograms", qty: 2, from: UnitTonne, to: UnitKilogram, want: 2000},
+		{name: "kilograms to tonnes", qty: 500, from: UnitKilogram, to: UnitTonne, want: 0.5},
+		{name: "pieces", qty: 3, from: UnitPiece, to: UnitPiece, want: 3},
+		{name: "mass to length", qty: 250, from: UnitKilogram, to: UnitMetre, wantErr: fmt.Errorf("cannot convert kg to m")},
+		{name: "pieces to mass", qty: 3, from: UnitPiece, to: UnitKilogram, wantErr: fmt.Errorf("cannot convert pc to kg")},
+		{name: "unknown unit", qty: 250, from: "ft", to: UnitMetre, wantErr: fmt.Errorf("unknown unit: ft")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertQuantity(tt.qty, tt.from, tt.to)
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_everyAcceptedUnitConverts(t *testing.T) {
+	for alias := range unitAliases {
+		unit, err := parseUnit(alias)
+		assert.NoError(t, err)
+		_, err = convertQuantity(1, unit, unit)
+		assert.NoError(t, err, alias)
+	}
+}
+
+func Test_validateDrumSizeForUnit(t *testing.T) {
+	tests := []struct {
+		name     string
+		drumSize int
+		unit     string
+		want     bool
+	}{
+		{name: "standard size in metres", drumSize: 250, unit: UnitMetre, want: true},
+		{name: "non standard size in metres", drumSize: 200, unit: UnitMetre, want: false},
+		{name: "empty unit is metres", drumSize: 300, unit: "", want: true},
+		{name: "standard size in kilometres", drumSize: 1, unit: UnitKilometre, want: true},
+		{name: "non standard size in kilometres", drumSize: 2, unit: UnitKilometre, want: false},
+		{name: "any positive size in kilograms", drumSize: 75, unit: UnitKilogram, want: true},
+		{name: "any positive size in tonnes", drumSize: 2, unit: UnitTonne, want: true},
+		{name: "zero size in pieces", drumSize: 0, unit: UnitPiece, want: false},
+		{name: "unknown unit", drumSize: 250, unit: "ft", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, validateDrumSizeForUnit(tt.drumSize, tt.unit))
+		})
+	}
+}
+
+func TestProcessRows_UnitPerLI(t *testing.T) {
+	row := CSVRow{
+		Vendor:              "Supplier A",
+		MaterialCode:        "MAT100",
+		MaterialDesc:        "Material A",
+		ContractNo:          "C123",
+		LIName:              LIName{LICode: "LI001", LINumber: "1"},
+		LIDate:              "01-01-2024",
+		BatchNo:             "1/2",
+		BatchDueDate:        "01-01-2025",
+		DrumSize:            250,
+		TotalNoOfDrums:      2,
+		TotalQty:            500,
+		ApprovedDrumNumbers: []int{},
+	}
+	kmRow := row
+	kmRow.BatchNo = "2/2"
+	kmRow.Unit = UnitKilometre
+
+	got, errs := processRows([]CSVRow{row, kmRow})
+
+	assert.Equal(t, UnitMetre, got.Contracts[0].LIs[0].Unit)
+	assert.Equal(t, UnitMetre, got.Contracts[0].LIs[0].Batches[0].DrumPartitions[0].Unit)
+	assert.Equal(t, []Error{{RowNo: 2, Err: fmt.Errorf("unit km does not match LI unit m")}}, errs)
+}
+
+func Test_unknownUnitIsReportedOnce(t *testing.T) {
+	var row CSVRow
+	errs := row.UnmarshalCSV(append(fixTestRecord(nil), "ft"), 0)
+	errs = append(errs, row.validateRow(0)...)
+
+	assert.Equal(t, "ft", row.Unit)
+	assert.Equal(t, []Error{{RowNo: 1, Err: fmt.Errorf("invalid unit")}}, errs)
+}
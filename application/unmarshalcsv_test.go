@@ -28,7 +28,7 @@ func TestCSVRow_UnmarshalCSV(t *testing.T) {
 		BufferDrumNo            []int
 		BufferNoOfDrums         int
 		BufferQuantity          int
-		SampleDrum              string
+		SampleDrum              bool
 		SampleDrumNo            []int
 		SampleLength            []float64
 		NoOfShortLengthDrums    int
@@ -70,7 +70,7 @@ func TestCSVRow_UnmarshalCSV(t *testing.T) {
 				BufferDrumNo:            []int{6, 7},
 				BufferNoOfDrums:         2,
 				BufferQuantity:          1000,
-				SampleDrum:              "Yes",
+				SampleDrum:              true,
 				SampleDrumNo:            []int{8, 9},
 				SampleLength:            []float64{100.0, 200.0},
 				NoOfShortLengthDrums:    2,
@@ -108,7 +108,7 @@ func TestCSVRow_UnmarshalCSV(t *testing.T) {
 				BufferDrumNo:            []int{6, 7},
 				BufferNoOfDrums:         2,
 				BufferQuantity:          1000,
-				SampleDrum:              "Yes",
+				SampleDrum:              true,
 				SampleDrumNo:            []int{8, 9},
 				SampleLength:            []float64{100.0, 200.0},
 				NoOfShortLengthDrums:    2,
@@ -148,7 +148,7 @@ func TestCSVRow_UnmarshalCSV(t *testing.T) {
 				BufferDrumNo:            []int{3},
 				BufferNoOfDrums:         1,
 				BufferQuantity:          1000,
-				SampleDrum:              "Yes",
+				SampleDrum:              true,
 				SampleDrumNo:            []int{4},
 				SampleLength:            []float64{500.0},
 				NoOfShortLengthDrums:    1,
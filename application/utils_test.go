@@ -70,265 +70,171 @@ func Test_combineSortAndCheckDuplicates(t *testing.T) {
 	}
 }
 
-func Test_findBatchIndex(t *testing.T) {
+func Test_unpackSampleDrumNos(t *testing.T) {
 	type args struct {
-		batches []Batch
-		no      string
+		sampleDrumNumbers []int
+		sampleLength      []float64
+		shortLengths      []float64
+		drumSize          int
 	}
 	tests := []struct {
-		name string
-		args args
-		want int
+		name  string
+		args  args
+		want  []DrumDetails
+		want1 float64
+		want2 []DrumDetails
+		want3 float64
 	}{
 		{
-			name: "Batch found",
-			args: args{
-				batches: []Batch{
-					{BatchNo: "Batch1"},
-					{BatchNo: "Batch2"},
-					{BatchNo: "Batch3"},
-				},
-				no: "Batch2",
-			},
-			want: 1,
-		},
-		{
-			name: "Batch not found",
-			args: args{
-				batches: []Batch{
-					{BatchNo: "Batch1"},
-					{BatchNo: "Batch2"},
-					{BatchNo: "Batch3"},
-				},
-				no: "Batch4",
-			},
-			want: -1,
-		},
-		{
-			name: "Empty batches",
+			name: "valid sample drum numbers and sample length",
 			args: args{
-				batches: []Batch{},
-				no:      "Batch1",
+				sampleDrumNumbers: []int{1, 2, 3},
+				sampleLength:      []float64{2.5, 2.0, 5.0},
+				drumSize:          250,
 			},
-			want: -1,
+			want:  []DrumDetails{{DrumNumber: 1, Quantity: 2.5}, {DrumNumber: 2, Quantity: 2.0}, {DrumNumber: 3, Quantity: 5.0}},
+			want1: 9.5,
+			want2: []DrumDetails{{DrumNumber: 1, Quantity: 247.5}, {DrumNumber: 2, Quantity: 248}, {DrumNumber: 3, Quantity: 245}},
+			want3: 740.5,
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := findBatchIndex(tt.args.batches, tt.args.no)
-			assert.Equal(t, tt.want, got)
-		})
-	}
-}
-
-func Test_findBatchTestApprovalIndex(t *testing.T) {
-	type args struct {
-		bta  []BatchTestApproval
-		date string
-	}
-	tests := []struct {
-		name string
-		args args
-		want int
-	}{
 		{
-			name: "BatchTestApproval found",
+			name: "drum sampled twice",
 			args: args{
-				bta: []BatchTestApproval{
-					{ApprovalDate: "2022-01-01"},
-					{ApprovalDate: "2022-01-02"},
-					{ApprovalDate: "2022-01-03"},
-				},
-				date: "2022-01-02",
+				sampleDrumNumbers: []int{1, 1, 2},
+				sampleLength:      []float64{2.5, 1.5, 5.0},
+				drumSize:          250,
 			},
-			want: 1,
+			want:  []DrumDetails{{DrumNumber: 1, Quantity: 2.5}, {DrumNumber: 1, Quantity: 1.5}, {DrumNumber: 2, Quantity: 5.0}},
+			want1: 9,
+			want2: []DrumDetails{{DrumNumber: 1, Quantity: 246}, {DrumNumber: 2, Quantity: 245}},
+			want3: 491,
 		},
 		{
-			name: "BatchTestApproval not found",
+			name: "declared short lengths",
 			args: args{
-				bta: []BatchTestApproval{
-					{ApprovalDate: "2022-01-01"},
-					{ApprovalDate: "2022-01-02"},
-					{ApprovalDate: "2022-01-03"},
-				},
-				date: "2022-01-04",
+				sampleDrumNumbers: []int{1, 1, 2},
+				sampleLength:      []float64{2.5, 1.5, 5.0},
+				shortLengths:      []float64{100, 245},
+				drumSize:          250,
 			},
-			want: -1,
+			want:  []DrumDetails{{DrumNumber: 1, Quantity: 2.5}, {DrumNumber: 1, Quantity: 1.5}, {DrumNumber: 2, Quantity: 5.0}},
+			want1: 9,
+			want2: []DrumDetails{{DrumNumber: 1, Quantity: 100}, {DrumNumber: 2, Quantity: 245}},
+			want3: 345,
 		},
 		{
-			name: "Empty BatchTestApproval",
-			args: args{
-				bta:  []BatchTestApproval{},
-				date: "2022-01-01",
-			},
-			want: -1,
+			name:  "no sample drums",
+			args:  args{drumSize: 250},
+			want:  []DrumDetails{},
+			want2: []DrumDetails{},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := findBatchTestApprovalIndex(tt.args.bta, tt.args.date)
+			got, got1, got2, got3 := unpackSampleDrumNos(tt.args.sampleDrumNumbers, tt.args.sampleLength, tt.args.shortLengths, tt.args.drumSize)
 			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want1, got1)
+			assert.Equal(t, tt.want2, got2)
+			assert.Equal(t, tt.want3, got3)
 		})
 	}
 }
 
-func Test_findContractIndex(t *testing.T) {
-	type args struct {
-		slice      []Contracts
-		contractNo string
-	}
+func Test_unpackPartialDrums(t *testing.T) {
 	tests := []struct {
-		name string
-		args args
-		want int
+		name              string
+		sampleDrumNumbers []int
+		sampleLength      []float64
+		shortLengths      []float64
+		drumSize          int
+		want              []DrumDetails
+		wantQty           float64
 	}{
 		{
-			name: "Contract found",
-			args: args{
-				slice: []Contracts{
-					{ContractNo: "Contract1"},
-					{ContractNo: "Contract2"},
-					{ContractNo: "Contract3"},
-				},
-				contractNo: "Contract2",
-			},
-			want: 1,
-		},
-		{
-			name: "Contract not found",
-			args: args{
-				slice: []Contracts{
-					{ContractNo: "Contract1"},
-					{ContractNo: "Contract2"},
-					{ContractNo: "Contract3"},
-				},
-				contractNo: "Contract4",
-			},
-			want: -1,
+			name:              "no declared short lengths leaves no remainder",
+			sampleDrumNumbers: []int{1},
+			sampleLength:      []float64{2.5},
+			drumSize:          250,
+			want:              []DrumDetails{},
 		},
 		{
-			name: "Empty Contracts",
-			args: args{
-				slice:      []Contracts{},
-				contractNo: "Contract1",
-			},
-			want: -1,
+			name:              "remainder of each sample drum stays available",
+			sampleDrumNumbers: []int{1, 1, 2},
+			sampleLength:      []float64{2.5, 1.5, 5.0},
+			shortLengths:      []float64{100, 245},
+			drumSize:          250,
+			want:              []DrumDetails{{DrumNumber: 1, Quantity: 146}},
+			wantQty:           146,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := findContractIndex(tt.args.slice, tt.args.contractNo)
+			got, gotQty := unpackPartialDrums(tt.sampleDrumNumbers, tt.sampleLength, tt.shortLengths, tt.drumSize)
 			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantQty, gotQty)
 		})
 	}
 }
 
-func Test_findLiIndex(t *testing.T) {
-	type args struct {
-		slice []LI
-		liNo  LIName
-	}
+func Test_validateSampleCuts(t *testing.T) {
 	tests := []struct {
-		name string
-		args args
-		want int
+		name              string
+		sampleDrumNumbers []int
+		sampleLength      []float64
+		shortLengths      []float64
+		drumSize          int
+		wantErr           error
 	}{
 		{
-			name: "LI found",
-			args: args{
-				slice: []LI{
-					{LiCode: "LI1", LiNumber: "1"},
-					{LiCode: "LI2", LiNumber: "2"},
-					{LiCode: "LI3", LiNumber: "3"},
-				},
-				liNo: LIName{
-					LICode:   "LI2",
-					LINumber: "2",
-				},
-			},
-			want: 1,
+			name:              "single sample cut",
+			sampleDrumNumbers: []int{1},
+			sampleLength:      []float64{2.5},
+			drumSize:          250,
 		},
 		{
-			name: "LI not found",
-			args: args{
-				slice: []LI{
-					{LiCode: "LI1", LiNumber: "1"},
-					{LiCode: "LI2", LiNumber: "2"},
-					{LiCode: "LI3", LiNumber: "3"},
-				},
-				liNo: LIName{
-					LICode:   "LI1",
-					LINumber: "4",
-				},
-			},
-			want: -1,
+			name:              "pieces sum to drum size",
+			sampleDrumNumbers: []int{1, 1},
+			sampleLength:      []float64{2.5, 2.5},
+			shortLengths:      []float64{245},
+			drumSize:          250,
 		},
 		{
-			name: "Empty LI",
-			args: args{
-				slice: []LI{},
-				liNo: LIName{
-					LICode:   "LI1",
-					LINumber: "1",
-				},
-			},
-			want: -1,
+			name:              "sample cuts exceed drum size",
+			sampleDrumNumbers: []int{1, 1},
+			sampleLength:      []float64{200, 100},
+			drumSize:          250,
+			wantErr:           fmt.Errorf("sample and short lengths of drum 1 exceed drum size"),
 		},
 		{
-			name: "Empty LI",
-			args: args{
-				slice: []LI{},
-				liNo:  LIName{},
-			},
-			want: -1,
+			name:              "sample cuts and short length exceed drum size",
+			sampleDrumNumbers: []int{1, 2},
+			sampleLength:      []float64{2.5, 2.5},
+			shortLengths:      []float64{247.5, 248},
+			drumSize:          250,
+			wantErr:           fmt.Errorf("sample and short lengths of drum 2 exceed drum size"),
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := findLiIndex(tt.args.slice, tt.args.liNo)
-			assert.Equal(t, tt.want, got)
-		})
-	}
-}
-
-func Test_unpackSampleDrumNos(t *testing.T) {
-	type args struct {
-		sampleDrumNumbers []int
-		sampleLength      []float64
-		drumSize          int
-	}
-	tests := []struct {
-		name  string
-		args  args
-		want  []DrumDetails
-		want1 float64
-		want2 []DrumDetails
-		want3 float64
-	}{
 		{
-			name: "valid sample drum numbers and sample length",
-			args: args{
-				sampleDrumNumbers: []int{1, 2, 3},
-				sampleLength:      []float64{2.5, 2.0, 5.0},
-				drumSize:          250,
-			},
-			want:  []DrumDetails{{DrumNumber: 1, Quantity: 2.5}, {DrumNumber: 2, Quantity: 2.0}, {DrumNumber: 3, Quantity: 5.0}},
-			want1: 9.5,
-			want2: []DrumDetails{{DrumNumber: 1, Quantity: 247.5}, {DrumNumber: 2, Quantity: 248}, {DrumNumber: 3, Quantity: 245}},
-			want3: 740.5,
+			name:              "negative short length",
+			sampleDrumNumbers: []int{1},
+			sampleLength:      []float64{2.5},
+			shortLengths:      []float64{-1},
+			drumSize:          250,
+			wantErr:           fmt.Errorf("sample and short lengths of drum 1 must be positive"),
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, got1, got2, got3 := unpackSampleDrumNos(tt.args.sampleDrumNumbers, tt.args.sampleLength, tt.args.drumSize)
-			assert.Equal(t, tt.want, got)
-			assert.Equal(t, tt.want1, got1)
-			assert.Equal(t, tt.want2, got2)
-			assert.Equal(t, tt.want3, got3)
+			err := validateSampleCuts(tt.sampleDrumNumbers, tt.sampleLength, tt.shortLengths, tt.drumSize)
+			assert.Equal(t, tt.wantErr, err)
 		})
 	}
 }
 
+func Test_distinctDrumNumbers(t *testing.T) {
+	assert.Equal(t, []int{3, 1, 2}, distinctDrumNumbers([]int{3, 1, 3, 2, 1}))
+	assert.Equal(t, []int{}, distinctDrumNumbers(nil))
+}
+
 func Test_sumFloat64Slice(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -416,6 +322,31 @@ func Test_unpackDrumNoRange(t *testing.T) {
 	}
 }
 
+func Test_parseSampleDrum(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    bool
+		wantErr bool
+	}{
+		{raw: "Yes", want: true},
+		{raw: " yes ", want: true},
+		{raw: "No", want: false},
+		{raw: "", want: false},
+		{raw: "maybe", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := parseSampleDrum(tt.raw)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func Test_stringToFloat64Slice(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -607,7 +538,8 @@ func TestDetermineBatchStatus(t *testing.T) {
 		{
 			name: "Test BUFFER Status",
 			batch: Batch{
-				TotalQuantity: 10,
+				BatchTestApprovals: []BatchTestApproval{{Status: "APPROVED"}},
+				TotalQuantity:      10,
 				DrumPartitions: []DrumPartition{
 					{
 						BufferQuantity: 5,
@@ -621,7 +553,8 @@ func TestDetermineBatchStatus(t *testing.T) {
 		{
 			name: "Test PARTIAL_BUFFER Status",
 			batch: Batch{
-				TotalQuantity: 10,
+				BatchTestApprovals: []BatchTestApproval{{Status: "APPROVED"}},
+				TotalQuantity:      10,
 				DrumPartitions: []DrumPartition{
 					{
 						BufferQuantity:    4,
@@ -636,7 +569,8 @@ func TestDetermineBatchStatus(t *testing.T) {
 		{
 			name: "Test AVAILABLE Status",
 			batch: Batch{
-				TotalQuantity: 10,
+				BatchTestApprovals: []BatchTestApproval{{Status: "APPROVED"}},
+				TotalQuantity:      10,
 				DrumPartitions: []DrumPartition{
 					{
 						BufferQuantity:    0,
@@ -663,6 +597,21 @@ func TestDetermineBatchStatus(t *testing.T) {
 			},
 			expected: "DOCS_PENDING_UPLOAD",
 		},
+		{
+			name: "Test AVAILABLE Status from partial sample drum",
+			batch: Batch{
+				BatchTestApprovals: []BatchTestApproval{{Status: "APPROVED"}},
+				TotalQuantity:      250,
+				DrumPartitions: []DrumPartition{
+					{
+						TestQuantity:    5,
+						ShortQuantity:   100,
+						PartialQuantity: 145,
+					},
+				},
+			},
+			expected: "AVAILABLE",
+		},
 	}
 
 	for _, tt := range tests {
@@ -674,6 +623,89 @@ func TestDetermineBatchStatus(t *testing.T) {
 	}
 }
 
+func TestDetermineLIStatus(t *testing.T) {
+	approvedBatch := Batch{
+		Status: "AVAILABLE",
+		DrumPartitions: []DrumPartition{
+			{Quantity: 500, AvailableQuantity: 500},
+		},
+	}
+	drawnBatch := Batch{
+		Status: "BUFFER",
+		DrumPartitions: []DrumPartition{
+			{Quantity: 250, TestQuantity: 2.5, ShortQuantity: 247.5},
+		},
+	}
+	partialBatch := Batch{
+		Status: "AVAILABLE",
+		DrumPartitions: []DrumPartition{
+			{Quantity: 250, TestQuantity: 5, ShortQuantity: 100, PartialQuantity: 145},
+		},
+	}
+	pendingBatch := Batch{
+		Status: "DOCS_PENDING_UPLOAD",
+		DrumPartitions: []DrumPartition{
+			{Quantity: 500, UnapprovedQuantity: 500},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		li       LI
+		expected string
+	}{
+		{
+			name:     "Test VENDOR_ACKNOWLEDGED Status without batches",
+			li:       LI{OrderedQuantity: 1000},
+			expected: "VENDOR_ACKNOWLEDGED",
+		},
+		{
+			name:     "Test VENDOR_ACKNOWLEDGED Status with only unapproved batches",
+			li:       LI{OrderedQuantity: 1000, Batches: []Batch{pendingBatch}},
+			expected: "VENDOR_ACKNOWLEDGED",
+		},
+		{
+			name:     "Test PARTIALLY_DELIVERED Status",
+			li:       LI{OrderedQuantity: 1000, Batches: []Batch{approvedBatch, pendingBatch}},
+			expected: "PARTIALLY_DELIVERED",
+		},
+		{
+			name:     "Test PARTIALLY_DELIVERED Status with unknown ordered quantity",
+			li:       LI{Batches: []Batch{approvedBatch, drawnBatch}},
+			expected: "PARTIALLY_DELIVERED",
+		},
+		{
+			name:     "Test FULLY_DELIVERED Status with stock left",
+			li:       LI{OrderedQuantity: 750, Batches: []Batch{approvedBatch, drawnBatch}},
+			expected: "FULLY_DELIVERED",
+		},
+		{
+			name:     "Test FULLY_DELIVERED Status with a batch still pending",
+			li:       LI{OrderedQuantity: 250, Batches: []Batch{drawnBatch, pendingBatch}},
+			expected: "FULLY_DELIVERED",
+		},
+		{
+			name:     "Test FULLY_DELIVERED Status with a partial drum left",
+			li:       LI{OrderedQuantity: 250, Batches: []Batch{partialBatch}},
+			expected: "FULLY_DELIVERED",
+		},
+		{
+			name:     "Test CLOSED Status",
+			li:       LI{OrderedQuantity: 250, Batches: []Batch{drawnBatch}},
+			expected: "CLOSED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.li.DeliveredQuantity = deliveredQuantity(tt.li)
+			if got := determineLIStatus(tt.li); got != tt.expected {
+				t.Errorf("determineLIStatus() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
 func Test_collectApprovedDrumNumbers(t *testing.T) {
 	type args struct {
 		LI           LI
@@ -862,7 +894,11 @@ func Test_validateOverlappingDrumNumbers(t *testing.T) {
 			want: []Error{
 				{
 					RowNo: 0,
-					Err:   fmt.Errorf("overlapping drum numbers found for material code: material1, duplicates found: [3 4]"),
+					Err:   fmt.Errorf("overlapping drum numbers found for contract contract1 material code material1: drum 3 claimed by contract contract1 LI - batch  drum size 0 approved ; contract contract1 LI - batch  drum size 0 approved "),
+				},
+				{
+					RowNo: 0,
+					Err:   fmt.Errorf("overlapping drum numbers found for contract contract1 material code material1: drum 4 claimed by contract contract1 LI - batch  drum size 0 approved ; contract contract1 LI - batch  drum size 0 approved "),
 				},
 			},
 		},
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -28,7 +29,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 		BufferDrumNo            []int
 		BufferNoOfDrums         int
 		BufferQuantity          int
-		SampleDrum              string
+		SampleDrum              bool
 		SampleDrumNo            []int
 		SampleLength            []float64
 		NoOfShortLengthDrums    int
@@ -60,7 +61,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				LIName:                  LIName{LICode: "LICode1", LINumber: "LINumber1"},
 				LIDate:                  "01-01-2022",
 				BatchNo:                 "1/1",
-				BatchDueDate:            "01-01-2022",
+				BatchDueDate:            "01-01-2023",
 				DrumSize:                250,
 				TotalNoOfDrums:          6,
 				TotalQty:                1500,
@@ -70,7 +71,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				BufferDrumNo:            []int{4, 5},
 				BufferNoOfDrums:         2,
 				BufferQuantity:          500,
-				SampleDrum:              "Yes",
+				SampleDrum:              true,
 				SampleDrumNo:            []int{6},
 				SampleLength:            []float64{125.0},
 				NoOfShortLengthDrums:    1,
@@ -97,7 +98,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				LIName:                  LIName{LICode: "LICode1", LINumber: "LINumber1"},
 				LIDate:                  "01-01-2022",
 				BatchNo:                 "1/1",
-				BatchDueDate:            "01-01-2022",
+				BatchDueDate:            "01-01-2023",
 				DrumSize:                250,
 				TotalNoOfDrums:          6,
 				TotalQty:                1500,
@@ -107,7 +108,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				BufferDrumNo:            []int{4, 5},
 				BufferNoOfDrums:         2,
 				BufferQuantity:          500,
-				SampleDrum:              "Yes",
+				SampleDrum:              true,
 				SampleDrumNo:            []int{6},
 				SampleLength:            []float64{125.0},
 				NoOfShortLengthDrums:    1,
@@ -134,7 +135,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				LIName:                  LIName{LICode: "LICode1", LINumber: "LINumber1"},
 				LIDate:                  "01-01-2022",
 				BatchNo:                 "1/1",
-				BatchDueDate:            "01-01-2022",
+				BatchDueDate:            "01-01-2023",
 				DrumSize:                250,
 				TotalNoOfDrums:          6,
 				TotalQty:                1500,
@@ -144,7 +145,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				BufferDrumNo:            []int{4, 5},
 				BufferNoOfDrums:         2,
 				BufferQuantity:          500,
-				SampleDrum:              "Yes",
+				SampleDrum:              true,
 				SampleDrumNo:            []int{6},
 				SampleLength:            []float64{125.0},
 				NoOfShortLengthDrums:    1,
@@ -171,7 +172,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				LIName:                  LIName{LICode: "LICode1", LINumber: "LINumber1"},
 				LIDate:                  "01-01-2022",
 				BatchNo:                 "1/1",
-				BatchDueDate:            "01-01-2022",
+				BatchDueDate:            "01-01-2023",
 				DrumSize:                20,
 				TotalNoOfDrums:          6,
 				TotalQty:                120,
@@ -181,7 +182,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				BufferDrumNo:            []int{4, 5},
 				BufferNoOfDrums:         2,
 				BufferQuantity:          40,
-				SampleDrum:              "Yes",
+				SampleDrum:              true,
 				SampleDrumNo:            []int{6},
 				SampleLength:            []float64{2.5},
 				NoOfShortLengthDrums:    1,
@@ -208,7 +209,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				LIName:                  LIName{LICode: "LICode1", LINumber: "LINumber1"},
 				LIDate:                  "2022-01-01", // Invalid date format
 				BatchNo:                 "1/1",
-				BatchDueDate:            "01-01-2022",
+				BatchDueDate:            "01-01-2023",
 				DrumSize:                250,
 				TotalNoOfDrums:          6,
 				TotalQty:                1500,
@@ -218,7 +219,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				BufferDrumNo:            []int{4, 5},
 				BufferNoOfDrums:         2,
 				BufferQuantity:          500,
-				SampleDrum:              "Yes",
+				SampleDrum:              true,
 				SampleDrumNo:            []int{6},
 				SampleLength:            []float64{125.0},
 				NoOfShortLengthDrums:    1,
@@ -245,7 +246,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				LIName:                  LIName{LICode: "LICode1", LINumber: "LINumber1"},
 				LIDate:                  "01-01-2022",
 				BatchNo:                 "1-1",
-				BatchDueDate:            "01-01-2022",
+				BatchDueDate:            "01-01-2023",
 				DrumSize:                250,
 				TotalNoOfDrums:          6,
 				TotalQty:                1500,
@@ -255,7 +256,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				BufferDrumNo:            []int{4, 5},
 				BufferNoOfDrums:         2,
 				BufferQuantity:          500,
-				SampleDrum:              "Yes",
+				SampleDrum:              true,
 				SampleDrumNo:            []int{6},
 				SampleLength:            []float64{125.0},
 				NoOfShortLengthDrums:    1,
@@ -282,7 +283,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				LIName:                  LIName{LICode: "LICode1", LINumber: ""},
 				LIDate:                  "01-01-2022",
 				BatchNo:                 "1/1",
-				BatchDueDate:            "01-01-2022",
+				BatchDueDate:            "01-01-2023",
 				DrumSize:                250,
 				TotalNoOfDrums:          6,
 				TotalQty:                1500,
@@ -292,7 +293,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				BufferDrumNo:            []int{4, 5},
 				BufferNoOfDrums:         2,
 				BufferQuantity:          500,
-				SampleDrum:              "Yes",
+				SampleDrum:              true,
 				SampleDrumNo:            []int{6},
 				SampleLength:            []float64{125.0},
 				NoOfShortLengthDrums:    1,
@@ -329,7 +330,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				BufferDrumNo:            []int{4, 5},
 				BufferNoOfDrums:         2,
 				BufferQuantity:          500,
-				SampleDrum:              "Yes",
+				SampleDrum:              true,
 				SampleDrumNo:            []int{6},
 				SampleLength:            []float64{125.0},
 				NoOfShortLengthDrums:    1,
@@ -356,7 +357,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				LIName:                  LIName{LICode: "LICode1", LINumber: "LINumber1"},
 				LIDate:                  "01-01-2022",
 				BatchNo:                 "1/1",
-				BatchDueDate:            "01-01-2022",
+				BatchDueDate:            "01-01-2023",
 				DrumSize:                250,
 				TotalNoOfDrums:          0,
 				TotalQty:                1500,
@@ -366,7 +367,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				BufferDrumNo:            []int{4, 5},
 				BufferNoOfDrums:         2,
 				BufferQuantity:          500,
-				SampleDrum:              "Yes",
+				SampleDrum:              true,
 				SampleDrumNo:            []int{6},
 				SampleLength:            []float64{125.0},
 				NoOfShortLengthDrums:    1,
@@ -393,7 +394,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				LIName:                  LIName{LICode: "LICode1", LINumber: "LINumber1"},
 				LIDate:                  "01-01-2022",
 				BatchNo:                 "1/1",
-				BatchDueDate:            "01-01-2022",
+				BatchDueDate:            "01-01-2023",
 				DrumSize:                250,
 				TotalNoOfDrums:          6,
 				TotalQty:                1500,
@@ -403,7 +404,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				BufferDrumNo:            []int{4, 5},
 				BufferNoOfDrums:         2,
 				BufferQuantity:          500,
-				SampleDrum:              "Yes",
+				SampleDrum:              true,
 				SampleDrumNo:            []int{6},
 				SampleLength:            []float64{125.0},
 				NoOfShortLengthDrums:    1,
@@ -430,7 +431,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				LIName:                  LIName{LICode: "LICode1", LINumber: "LINumber1"},
 				LIDate:                  "01-01-2022",
 				BatchNo:                 "1/1",
-				BatchDueDate:            "01-01-2022",
+				BatchDueDate:            "01-01-2023",
 				DrumSize:                250,
 				TotalNoOfDrums:          6,
 				TotalQty:                1500,
@@ -440,7 +441,7 @@ func TestCSVRow_validateRow(t *testing.T) {
 				BufferDrumNo:            []int{4, 5},
 				BufferNoOfDrums:         2,
 				BufferQuantity:          500,
-				SampleDrum:              "Yes",
+				SampleDrum:              true,
 				SampleDrumNo:            []int{6},
 				SampleLength:            []float64{125.0},
 				NoOfShortLengthDrums:    1,
@@ -494,3 +495,44 @@ func TestCSVRow_validateRow(t *testing.T) {
 		})
 	}
 }
+
+func TestCSVRow_validateRow_SampleDrum(t *testing.T) {
+	tests := []struct {
+		name         string
+		sampleDrum   bool
+		sampleDrumNo []int
+		sampleLength []float64
+		want         []string
+	}{
+		{name: "no samples", sampleDrum: false},
+		{name: "samples", sampleDrum: true, sampleDrumNo: []int{3}, sampleLength: []float64{2.5}},
+		{
+			name: "No with sample drums", sampleDrum: false, sampleDrumNo: []int{3}, sampleLength: []float64{2.5},
+			want: []string{"sample drum is No but sample drum nos. or sample length are given"},
+		},
+		{
+			name: "No with sample length", sampleDrum: false, sampleLength: []float64{2.5},
+			want: []string{"sample drum is No but sample drum nos. or sample length are given"},
+		},
+		{
+			name: "Yes without sample drums", sampleDrum: true,
+			want: []string{"sample drum is Yes but no sample drum nos. are given"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := approvalTestRow("01-03-2024", "APPROVED", []int{1, 2})
+			row.SampleDrum = tt.sampleDrum
+			row.SampleDrumNo = tt.sampleDrumNo
+			row.SampleLength = tt.sampleLength
+
+			var got []string
+			for _, e := range row.validateRow(0) {
+				if strings.HasPrefix(e.Err.Error(), "sample drum is") {
+					got = append(got, e.Err.Error())
+				}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
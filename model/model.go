@@ -1,46 +1,72 @@
 package model
 
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
 type UploadInventoryInput struct {
-	Contracts []Contracts `json:"contracts"`
+	SchemaVersion string      `json:"schema_version"`
+	Contracts     []Contracts `json:"contracts"`
+}
+
+type RowSource struct {
+	File        string `json:"file,omitempty"`
+	RowNo       int    `json:"row_no"`
+	DrumNumbers []int  `json:"drum_numbers"`
 }
 
 type Contracts struct {
 	ContractNo string `json:"contract_no"`
+	Vendor     string `json:"vendor"`
 	LIs        []LI   `json:"lis"`
 }
 
 type LI struct {
-	MaterialCode    string  `json:"material_code"`
-	LiCode          string  `json:"li_code"`
-	LiNumber        string  `json:"li_number"`
-	Description     string  `json:"description"`
-	Batches         []Batch `json:"batches"`
-	HosApprovalDate string  `json:"hos_approval_date"`
-	Status          string  `json:"status"`
+	MaterialCode      string  `json:"material_code"`
+	LiCode            string  `json:"li_code"`
+	LiNumber          string  `json:"li_number"`
+	Description       string  `json:"description"`
+	Unit              string  `json:"unit"`
+	Batches           []Batch `json:"batches"`
+	HosApprovalDate   Date    `json:"hos_approval_date"`
+	OrderedQuantity   int     `json:"ordered_quantity"`
+	DeliveredQuantity int     `json:"delivered_quantity"`
+	Status            string  `json:"status"`
 }
 
 type Batch struct {
 	BatchNo            string              `json:"batch_no"`
 	TotalQuantity      int                 `json:"total_quantity"`
-	SubmissionDate     string              `json:"submission_date"`
+	SubmissionDate     Date                `json:"submission_date"`
 	DrumPartitions     []DrumPartition     `json:"drum_partition"`
 	BatchTestApprovals []BatchTestApproval `json:"batch_test_approvals"`
 	Remarks            string              `json:"remarks"`
+	RemarkFlags        []string            `json:"remark_flags"`
 	Status             string              `json:"status"`
 }
 
 type DrumPartition struct {
-	DrumSize             int           `json:"drum_size"`
-	Quantity             int           `json:"quantity"`
-	UnapprovedQuantity   int           `json:"unapproved_quantity"`
-	AvailableQuantity    int           `json:"available_quantity"`
-	AvailableDrumNumbers []int         `json:"available_drum_numbers"`
-	BufferQuantity       int           `json:"buffer_quantity"`
-	BufferDrumNumbers    []int         `json:"buffer_drum_numbers"`
-	TestQuantity         float64       `json:"test_quantity"`
-	TestDrumNumbers      []DrumDetails `json:"test_drum_numbers"`
-	ShortQuantity        float64       `json:"short_quantity"`
-	ShortDrumNumbers     []DrumDetails `json:"short_drum_numbers"`
+	DrumSize              int           `json:"drum_size"`
+	Unit                  string        `json:"unit"`
+	Quantity              int           `json:"quantity"`
+	UnapprovedQuantity    int           `json:"unapproved_quantity"`
+	UnapprovedDrumNumbers []int         `json:"unapproved_drum_numbers"`
+	AvailableQuantity     int           `json:"available_quantity"`
+	AvailableDrumNumbers  []int         `json:"available_drum_numbers"`
+	BufferQuantity        int           `json:"buffer_quantity"`
+	BufferDrumNumbers     []int         `json:"buffer_drum_numbers"`
+	TestQuantity          float64       `json:"test_quantity"`
+	TestDrumNumbers       []DrumDetails `json:"test_drum_numbers"`
+	ShortQuantity         float64       `json:"short_quantity"`
+	ShortDrumNumbers      []DrumDetails `json:"short_drum_numbers"`
+	PartialQuantity       float64       `json:"partial_quantity"`
+	PartialDrumNumbers    []DrumDetails `json:"partial_drum_numbers"`
+	Remarks               []string      `json:"remarks"`
+	RemarkFlags           []string      `json:"remark_flags"`
+	Sources               []RowSource   `json:"sources,omitempty"`
 }
 
 type DrumDetails struct {
@@ -49,11 +75,13 @@ type DrumDetails struct {
 }
 
 type BatchTestApproval struct {
-	ApprovalDate        string                 `json:"approval_date"`
+	ApprovalDate        Date                   `json:"approval_date"`
 	TestDrumNumbers     []BatchTestDrumNumbers `json:"test_drum_numbers"`
 	ApprovalDrumNumbers []ApprovalDrumNumber   `json:"approval_drum_numbers"`
 	Status              string                 `json:"status"`
 	ApprovalComment     string                 `json:"approval_comment"`
+	Remarks             []string               `json:"remarks"`
+	Superseded          bool                   `json:"superseded"`
 }
 
 type BatchTestDrumNumbers struct {
@@ -62,8 +90,9 @@ type BatchTestDrumNumbers struct {
 }
 
 type ApprovalDrumNumber struct {
-	DrumSize    int   `json:"drum_size"`
-	DrumNumbers []int `json:"drum_numbers"`
+	DrumSize    int         `json:"drum_size"`
+	DrumNumbers []int       `json:"drum_numbers"`
+	Sources     []RowSource `json:"sources,omitempty"`
 }
 
 type CSVRow struct {
@@ -86,7 +115,7 @@ type CSVRow struct {
 	BufferDrumNo            []int     `csv:"Buffer Drum No."`
 	BufferNoOfDrums         int       `csv:"Buffer No. of Drum"`
 	BufferQuantity          int       `csv:"Buffer Quantity"`
-	SampleDrum              string    `csv:"Sample Drum (Yes/No)"`
+	SampleDrum              bool      `csv:"Sample Drum (Yes/No)"`
 	SampleDrumNo            []int     `csv:"Sample Drum No."`
 	SampleLength            []float64 `csv:"Sample Length (m)"`
 	NoOfShortLengthDrums    int       `csv:"No of Short length Drums"`
@@ -95,6 +124,64 @@ type CSVRow struct {
 	BatchTestReportDate     string    `csv:"Batch Test Report Date"`
 	Remarks                 string    `csv:"Remarks"`
 	BatchTestReportFileName string    `csv:"Batch Test Report File Name"`
+	Unit                    string    `csv:"Unit"`
+	ShortLengths            []float64 `csv:"Short Length Qty per Drum"`
+	BatchTestStatus         string    `csv:"Batch Test Status"`
+	ApprovalComment         string    `csv:"Approval Comment"`
+	LIQuantity              int       `csv:"LI Quantity"`
+	DrumNos                 []int     `csv:"Drum Nos."`
+}
+
+// Date layouts. Vendor files carry dates as dd-mm-yyyy, the output uses ISO-8601 unless the legacy layout is asked for.
+const (
+	LegacyDateLayout = "02-01-2006"
+	ISODateLayout    = "2006-01-02"
+)
+
+// Date is a calendar date without a time of day. It is written as ISO-8601 and read in either layout, so the output
+// of the converter reads back whichever layout it was written in.
+type Date struct {
+	time.Time
+}
+
+// ParseDate reads a date in the vendor's dd-mm-yyyy layout or in ISO-8601.
+func ParseDate(str string) (Date, error) {
+	str = strings.TrimSpace(str)
+	for _, layout := range []string{LegacyDateLayout, ISODateLayout} {
+		if t, err := time.Parse(layout, str); err == nil {
+			return Date{Time: t}, nil
+		}
+	}
+	return Date{}, fmt.Errorf("invalid date: %q", str)
+}
+
+// String formats the date as ISO-8601, the zero date is empty.
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(ISODateLayout)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	if str == "" {
+		*d = Date{}
+		return nil
+	}
+	date, err := ParseDate(str)
+	if err != nil {
+		return err
+	}
+	*d = date
+	return nil
 }
 
 type LIName struct {